@@ -1,29 +1,22 @@
 package agentmanager
 
 import (
-	"strings"
-
-	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
-	"github.com/hostedgraphite/hg-cli/agentmanager/telegraf"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func GetAgent(agentName string) Agent {
-	switch strings.ToLower(agentName) {
-	case "telegraf":
-		return &telegraf.Telegraf{}
-	default:
+func NewAgent(agentName string, options map[string]interface{}, sysInfo sysinfo.SysInfo) Agent {
+	def, ok := Lookup(agentName)
+	if !ok {
 		return nil
 	}
+	return def.New(options, sysInfo)
 }
 
-func NewAgent(agentName string, options map[string]interface{}, sysInfo sysinfo.SysInfo) Agent {
-	switch strings.ToLower(agentName) {
-	case "telegraf":
-		return telegraf.NewTelegrafAgent(options, sysInfo)
-	case "otel", "opentelemetry":
-		return otel.NewOtelAgent(options, sysInfo)
-	default:
+// ServiceSettings returns the agent's service settings for this system.
+func ServiceSettings(agentName string, sysInfo sysinfo.SysInfo) map[string]string {
+	def, ok := Lookup(agentName)
+	if !ok {
 		return nil
 	}
+	return def.ServiceSettings(sysInfo)
 }
@@ -0,0 +1,12 @@
+// Package agents links in every supported agent, importing it registers
+// them with the agentmanager.
+package agents
+
+import (
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/alloy"
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/collectd"
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/nodeexporter"
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/otel"
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/telegraf"
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/vector"
+)
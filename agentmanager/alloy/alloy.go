@@ -0,0 +1,81 @@
+package alloy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+type Alloy struct {
+	apikey          string
+	sysinfo         sysinfo.SysInfo
+	options         map[string]interface{}
+	serviceSettings map[string]string
+	naming          naming.Naming
+	endpoint        endpoint.Endpoint
+	remoteWriteURL  string
+	interval        string
+}
+
+func NewAlloyAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *Alloy {
+	apikey, ok := options["apikey"].(string)
+	if !ok {
+		apikey = ""
+	}
+
+	remoteWriteURL, _ := options["remoteWriteUrl"].(string)
+	interval, _ := options["interval"].(string)
+
+	agent := &Alloy{
+		apikey:          apikey,
+		sysinfo:         sysInfo,
+		options:         options,
+		serviceSettings: utils.StagedSettings(sysInfo, GetServiceSettings(sysInfo), ""),
+		naming:          naming.New(options),
+		endpoint:        endpoint.New(options),
+		remoteWriteURL:  remoteWriteURL,
+		interval:        interval,
+	}
+
+	return agent
+}
+
+// ValidateEndpoint rejects the carbon endpoint options, alloy ships metrics
+// with prometheus remote write instead.
+func ValidateEndpoint(e endpoint.Endpoint) error {
+	if e.IsSet() || e.TLSCA != "" || e.InsecureSkipVerify {
+		return fmt.Errorf("alloy sends metrics with prometheus remote write, use --remote-write-url instead of the carbon endpoint options")
+	}
+	return nil
+}
+
+// metricPrefix is the prefix without the api key, remote write authenticates
+// with the key instead.
+func metricPrefix(n naming.Naming) string {
+	prefix := n.Prefix
+	if prefix == "" {
+		prefix = "alloy"
+	}
+	return strings.Join(append(append([]string{}, n.Segments...), prefix), ".")
+}
+
+func (a *Alloy) config(hostname string) Config {
+	config := Config{
+		Prefix:   metricPrefix(a.naming),
+		Hostname: hostname,
+		Interval: a.interval,
+		URL:      a.remoteWriteURL,
+		ApiKey:   a.apikey,
+	}
+	if config.Interval == "" {
+		config.Interval = DefaultScrapeInterval
+	}
+	if config.URL == "" {
+		config.URL = DefaultRemoteWriteURL
+	}
+	return config
+}
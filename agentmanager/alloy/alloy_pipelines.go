@@ -0,0 +1,206 @@
+package alloy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+
+	alloyPipes "github.com/hostedgraphite/hg-cli/agentmanager/alloy/pipes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// Validate checks the naming, endpoint and generated config options.
+func (a *Alloy) Validate() error {
+	if err := a.naming.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateEndpoint(a.endpoint); err != nil {
+		return err
+	}
+	return a.config("").Validate()
+}
+
+func (a *Alloy) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = a.sysinfo
+
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if sysInfo.Os != "linux" {
+		return nil, fmt.Errorf("alloy is only supported on linux")
+	}
+
+	version, _ := a.options["version"].(string)
+	pipes := alloyPipes.LinuxInstallPipes(sysInfo, version)
+	pipes = append(pipes, a.configPipe()...)
+
+	if start, _ := a.options["startService"].(bool); start {
+		pipes = append(pipes, alloyPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Alloy Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (a *Alloy) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = a.sysinfo
+
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	pipes := a.configPipe()
+	if start, _ := a.options["startService"].(bool); start {
+		pipes = append(pipes, alloyPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Configuring Alloy Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (a *Alloy) IsInstalled() bool {
+	info, err := os.Stat(a.serviceSettings["configPath"])
+	return err == nil && !info.IsDir()
+}
+
+func (a *Alloy) configPipe() []*pipeline.Pipe {
+	configPath := a.serviceSettings["configPath"]
+	configDir := path.Dir(configPath)
+
+	// The config holds the api key, so it's only readable by the alloy group.
+	chgrp := exec.Command("chgrp", "alloy", configPath)
+	if a.sysinfo.Root != "" {
+		chgrp = exec.Command("sh", "-c", utils.ChownScript(a.sysinfo.Root, ":alloy", "/etc/alloy/config.alloy"))
+	}
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Writing Alloy config.alloy", exec.Command("mkdir", "-p", configDir)).PostRun(
+			func(ctx context.Context) error {
+				hostname, err := a.naming.ResolveHostname()
+				if err != nil {
+					return err
+				}
+
+				config, err := a.config(hostname).Render()
+				if err != nil {
+					return err
+				}
+
+				return os.WriteFile(configPath, config, 0640)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				hostname, resolve, err := a.naming.ScriptHostname(shell, false)
+				if err != nil {
+					return "", err
+				}
+
+				config, err := a.config(hostname).Render()
+				if err != nil {
+					return "", err
+				}
+
+				script := "mkdir -p " + configDir + "\n" + utils.WriteFileScript(shell, configPath, config, 0640)
+				if resolve != "" {
+					script += "\n" + utils.ReplaceScript(shell, configPath, naming.HostnamePlaceholder, resolve)
+				}
+				return script, nil
+			},
+		),
+		pipeline.NewPipe("Setting config.alloy permissions", chgrp),
+	}
+	return pipes
+}
+
+func (a *Alloy) UninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = a.sysinfo
+
+	if sysInfo.Os != "linux" {
+		return nil, fmt.Errorf("alloy is only supported on linux")
+	}
+
+	pipes := alloyPipes.LinuxUninstallPipes(sysInfo)
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Alloy Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (a *Alloy) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = a.sysinfo
+	var configPath string
+
+	if err := a.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateEndpoint(a.endpoint); err != nil {
+		return nil, err
+	}
+
+	if a.options["config"] != nil {
+		configPath = a.options["config"].(string)
+	} else {
+		configPath = a.serviceSettings["configPath"]
+	}
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Updating Alloy config.alloy", exec.Command("sleep", "1")).PostRun(
+			func(ctx context.Context) error {
+				return a.updateConfig(configPath)
+			},
+		),
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Updating HostedGraphite Api Key (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+var (
+	passwordRegex = regexp.MustCompile(`password\s*=\s*".*?"`)
+	prefixRegex   = regexp.MustCompile(`replacement(\s*)=\s*".*?\.\$1"`)
+	hostRegex     = regexp.MustCompile(`(target_label\s*=\s*"host"\s*\n\s*replacement\s*=\s*)".*?"`)
+	urlRegex      = regexp.MustCompile(`url\s*=\s*".*?"`)
+)
+
+// updateConfig swaps the api key in an existing config, and the prefix,
+// hostname or url when they were given.
+func (a *Alloy) updateConfig(configPath string) error {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	config := a.config("")
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	updated := passwordRegex.ReplaceAllLiteralString(string(content), fmt.Sprintf(`password = "%s"`, a.apikey))
+
+	if a.naming.HasPrefix() {
+		updated = prefixRegex.ReplaceAllString(updated, fmt.Sprintf(`replacement${1}= "%s.$$1"`, config.Prefix))
+	}
+	if a.naming.HasHostname() {
+		hostname, err := a.naming.ResolveHostname()
+		if err != nil {
+			return err
+		}
+		updated = hostRegex.ReplaceAllString(updated, fmt.Sprintf(`${1}"%s"`, hostname))
+	}
+	if a.remoteWriteURL != "" {
+		updated = urlRegex.ReplaceAllLiteralString(updated, fmt.Sprintf(`url = "%s"`, config.URL))
+	}
+
+	if err := os.WriteFile(configPath, []byte(updated), 0640); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package alloy
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var configTemplate = template.Must(template.New("config.alloy").Parse(`// Generated by hg-cli.
+prometheus.exporter.unix "hg_cli" { }
+
+prometheus.scrape "hg_cli" {
+  targets         = prometheus.exporter.unix.hg_cli.targets
+  forward_to      = [prometheus.relabel.hg_cli.receiver]
+  scrape_interval = "{{.Interval}}"
+}
+
+prometheus.relabel "hg_cli" {
+  forward_to = [prometheus.remote_write.hosted_graphite.receiver]
+
+  rule {
+    source_labels = ["__name__"]
+    target_label  = "__name__"
+    replacement   = "{{.Prefix}}.$1"
+  }
+{{- if .Hostname}}
+
+  rule {
+    target_label = "host"
+    replacement  = "{{.Hostname}}"
+  }
+{{- end}}
+}
+
+prometheus.remote_write "hosted_graphite" {
+  endpoint {
+    url = "{{.URL}}"
+
+    basic_auth {
+      username = "hg-cli"
+      password = "{{.ApiKey}}"
+    }
+  }
+}
+`))
+
+// Config is what hg-cli writes to config.alloy: the unix exporter scraped and
+// relabelled under the metric prefix, then remote written to Hosted Graphite.
+type Config struct {
+	Prefix   string
+	Hostname string
+	Interval string
+	URL      string
+	ApiKey   string
+}
+
+func (c Config) Validate() error {
+	if _, err := time.ParseDuration(c.Interval); err != nil {
+		return fmt.Errorf("invalid scrape interval %q: %v", c.Interval, err)
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid remote write url %q", c.URL)
+	}
+
+	for _, value := range []string{c.Prefix, c.Hostname, c.ApiKey} {
+		if strings.ContainsAny(value, "\"\\\n") {
+			return fmt.Errorf("invalid value %q: quotes, backslashes and newlines aren't allowed", value)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) Render() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := configTemplate.Execute(&buf, c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
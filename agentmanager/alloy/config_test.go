@@ -0,0 +1,71 @@
+package alloy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderConfig(t *testing.T) {
+	config := Config{
+		Prefix:   "prod.alloy",
+		Hostname: "web-1",
+		Interval: DefaultScrapeInterval,
+		URL:      DefaultRemoteWriteURL,
+		ApiKey:   "my-key",
+	}
+
+	rendered, err := config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), `replacement   = "prod.alloy.$1"`)
+	require.Contains(t, string(rendered), `replacement  = "web-1"`)
+	require.Contains(t, string(rendered), `password = "my-key"`)
+
+	config.Hostname = ""
+	rendered, err = config.Render()
+	require.NoError(t, err)
+	require.NotContains(t, string(rendered), `target_label = "host"`)
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Prefix: "alloy", Interval: "30s", URL: DefaultRemoteWriteURL, ApiKey: "key"}
+	require.NoError(t, valid.Validate())
+
+	badInterval := valid
+	badInterval.Interval = "often"
+	require.Error(t, badInterval.Validate())
+
+	badURL := valid
+	badURL.URL = "carbon.hostedgraphite.com:2003"
+	require.Error(t, badURL.Validate())
+
+	quoted := valid
+	quoted.Hostname = `web"1`
+	require.Error(t, quoted.Validate())
+}
+
+func TestUpdateConfig(t *testing.T) {
+	original := NewAlloyAgent(map[string]interface{}{"apikey": "old-key", "hostname": "web-1"}, sysinfo.SysInfo{})
+	rendered, err := original.config("web-1").Render()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.alloy")
+	require.NoError(t, os.WriteFile(path, rendered, 0640))
+
+	updated := NewAlloyAgent(map[string]interface{}{
+		"apikey":   "new-key",
+		"segments": []string{"prod"},
+		"hostname": "web-2",
+	}, sysinfo.SysInfo{})
+	require.NoError(t, updated.updateConfig(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `password = "new-key"`)
+	require.Contains(t, string(content), `replacement   = "prod.alloy.$1"`)
+	require.Contains(t, string(content), `replacement  = "web-2"`)
+	require.Contains(t, string(content), DefaultRemoteWriteURL)
+}
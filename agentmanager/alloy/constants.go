@@ -0,0 +1,34 @@
+package alloy
+
+import (
+	"maps"
+
+	alloyPipes "github.com/hostedgraphite/hg-cli/agentmanager/alloy/pipes"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// DefaultRemoteWriteURL is Hosted Graphite's Prometheus remote write endpoint,
+// Alloy has no carbon exporter so metrics are shipped with remote write.
+const DefaultRemoteWriteURL = "https://www.hostedgraphite.com/api/v1/prometheus/write"
+
+const DefaultScrapeInterval = "30s"
+
+// The linux start and restart hints depend on the init system, see
+// GetServiceSettings.
+var ServiceDetails = map[string]map[string]string{
+	"linux": {
+		"configPath": "/etc/alloy/config.alloy",
+		"receiver":   "prometheus.exporter.unix",
+		"exporter":   "prometheus.remote_write",
+	},
+}
+
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
+	if sysInfo.Os != "linux" {
+		return nil
+	}
+
+	settings := maps.Clone(ServiceDetails[sysInfo.Os])
+	settings["startHint"], settings["restartHint"], settings["enableHint"] = alloyPipes.LinuxHints(sysInfo)
+	return settings
+}
@@ -0,0 +1,31 @@
+package alloy
+
+import (
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/spf13/pflag"
+)
+
+// Spec are the alloy fields of an `hg-cli apply` spec.
+type Spec struct {
+	Interval       string `yaml:"interval"`
+	RemoteWriteURL string `yaml:"remoteWriteUrl"`
+}
+
+func (s *Spec) Options(options map[string]interface{}) {
+	options["interval"] = s.Interval
+	options["remoteWriteUrl"] = s.RemoteWriteURL
+}
+
+func addFlags(flags *pflag.FlagSet) {
+	flags.String("collection-interval", DefaultScrapeInterval, "Collection interval, e.g. 30s or 1m")
+	flags.String("remote-write-url", "", "Alloy prometheus remote write url, defaults to Hosted Graphite")
+}
+
+func flagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	options["interval"], _ = flags.GetString("collection-interval")
+	options["remoteWriteUrl"], _ = flags.GetString("remote-write-url")
+}
+
+func validate(options map[string]interface{}, os string) error {
+	return NewAlloyAgent(options, sysinfo.SysInfo{}).Validate()
+}
@@ -0,0 +1,203 @@
+package pipes
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+const grafanaYumRepo = `[grafana]
+name=grafana
+baseurl=https://rpm.grafana.com
+repo_gpgcheck=1
+enabled=1
+gpgcheck=1
+gpgkey=https://rpm.grafana.com/gpg.key
+sslverify=1
+sslcacert=/etc/pki/tls/certs/ca-bundle.crt`
+
+// packaged reports whether alloy is installed from Grafana's repositories
+// rather than the release archive.
+func packaged(pkgMngr string) bool {
+	return pkgMngr == "apt" || pkgMngr == "yum" || pkgMngr == "dnf"
+}
+
+func linuxService(sysInfo sysinfo.SysInfo) service.Service {
+	command := "/usr/local/bin/alloy"
+	if packaged(sysInfo.PkgMngr) {
+		command = "/usr/bin/alloy"
+	}
+	return service.Service{
+		Name:        "alloy",
+		DisplayName: "Alloy",
+		Description: "Grafana Alloy",
+		Command:     command,
+		Args:        []string{"run", "/etc/alloy/config.alloy", "--storage.path=/var/lib/alloy/data"},
+		User:        "alloy",
+		Root:        sysInfo.Root,
+	}
+}
+
+// packagedService reports whether the install already provides a service for
+// the init system, the packages only ship a systemd unit.
+func packagedService(pkgMngr, init string) bool {
+	return packaged(pkgMngr) && init == sysinfo.InitSystemd
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo) (string, string, string) {
+	return linuxService(sysInfo).Hints(service.Init(sysInfo))
+}
+
+// LinuxInstallPipes installs alloy, an empty version installs the latest release.
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+
+	switch sysInfo.PkgMngr {
+	case "apt":
+		pipes = aptInstallPipes(version)
+	case "yum", "dnf":
+		pipes = yumInstallPipes(sysInfo.PkgMngr, version)
+	default:
+		pipes = binInstallPipes(sysInfo, version)
+	}
+
+	if !packagedService(sysInfo.PkgMngr, init) {
+		pipes = append(pipes, linuxService(sysInfo).InstallPipes(init)...)
+	}
+
+	return pipes
+}
+
+func aptInstallPipes(version string) []*pipeline.Pipe {
+	pkg := "alloy"
+	if version != "" {
+		pkg = "alloy=" + strings.TrimPrefix(version, "v")
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Adding Grafana apt Key",
+			Cmd:  exec.Command("sh", "-c", "mkdir -p /etc/apt/keyrings && wget -q -O - https://apt.grafana.com/gpg.key | gpg --dearmor --yes -o /etc/apt/keyrings/grafana.gpg"),
+		},
+		{
+			Name: "Adding Grafana apt Repository",
+			Cmd:  exec.Command("sh", "-c", `echo "deb [signed-by=/etc/apt/keyrings/grafana.gpg] https://apt.grafana.com stable main" > /etc/apt/sources.list.d/grafana.list`),
+		},
+		{
+			Name: "Updating apt Packages",
+			Cmd:  exec.Command("apt-get", "update"),
+		},
+		{
+			Name: "Installing Alloy Agent",
+			Cmd:  exec.Command("apt-get", "install", "-y", pkg),
+		},
+	}
+	return pipes
+}
+
+func yumInstallPipes(pkgMngr, version string) []*pipeline.Pipe {
+	pkg := "alloy"
+	if version != "" {
+		pkg = "alloy-" + strings.TrimPrefix(version, "v")
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Importing Grafana rpm Key",
+			Cmd:  exec.Command("rpm", "--import", "https://rpm.grafana.com/gpg.key"),
+		},
+		{
+			Name: "Adding Grafana yum Repository",
+			Cmd:  exec.Command("sh", "-c", "echo '"+grafanaYumRepo+"' > /etc/yum.repos.d/grafana.repo"),
+		},
+		{
+			Name: "Installing Alloy Agent",
+			Cmd:  exec.Command(pkgMngr, "install", "-y", pkg),
+		},
+	}
+	return pipes
+}
+
+func binInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
+	latest := utils.ReleaseTag("grafana", "alloy", version, "v1.8.3")
+	file := fmt.Sprintf("alloy-linux-%s", sysInfo.Arch)
+	url := sysInfo.DownloadURL("https://github.com/grafana/alloy/releases/download/" + latest + "/" + file + ".zip")
+	tmpDir := "/tmp/hg-cli/"
+
+	pipes := utils.StagedDirsPipes(sysInfo, "/usr/local/bin")
+	pipes = append(pipes, []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			Name: "Downloading Alloy to " + tmpDir,
+			Cmd:  exec.Command("curl", "--tlsv1.2", "-fL", "-o", tmpDir+file+".zip", url),
+		},
+		{
+			Name: "Extracting Alloy archive",
+			Cmd:  exec.Command("unzip", "-o", tmpDir+file+".zip", "-d", tmpDir),
+		},
+		{
+			Name: "Moving Alloy to /usr/local/bin",
+			Cmd:  exec.Command("install", "-m", "0755", tmpDir+file, sysInfo.Path("/usr/local/bin/alloy")),
+		},
+		{
+			Name: "Creating alloy User",
+			Cmd:  utils.UserAddCmd(sysInfo.Root, "alloy", "--system", "--home-dir", "/var/lib/alloy", "--shell", "/bin/false"),
+		},
+		{
+			Name: "Creating Alloy Directories",
+			Cmd:  exec.Command("sh", "-c", fmt.Sprintf("mkdir -p %s %s && %s", pipeline.ShellQuote(sysInfo.Path("/etc/alloy")), pipeline.ShellQuote(sysInfo.Path("/var/lib/alloy/data")), utils.ChownScript(sysInfo.Root, "alloy:alloy", "/var/lib/alloy"))),
+		},
+		{
+			Name: "Cleaning up Temporary Directory",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}...)
+	return pipes
+}
+
+func LinuxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo)
+
+	if service.Managed(sysInfo) {
+		pipes = svc.StopPipes(init)
+	}
+
+	if packaged(sysInfo.PkgMngr) {
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Uninstalling Alloy Agent",
+			Cmd:  exec.Command(sysInfo.PkgMngr, "remove", "-y", "alloy"),
+		})
+	} else {
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Removing Alloy Binary",
+			Cmd:  exec.Command("rm", "-f", sysInfo.Path("/usr/local/bin/alloy")),
+		})
+	}
+
+	if !packagedService(sysInfo.PkgMngr, init) {
+		pipes = append(pipes, svc.RemovePipes(init)...)
+	}
+
+	return pipes
+}
+
+func LinuxRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if !service.Managed(sysInfo) {
+		return nil
+	}
+
+	return linuxService(sysInfo).RestartPipes(service.Init(sysInfo))
+}
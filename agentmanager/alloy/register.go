@@ -0,0 +1,40 @@
+package alloy
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:        "alloy",
+		Aliases:     []string{"grafana-alloy"},
+		DisplayName: "Grafana Alloy",
+		Flags:       []string{"collection-interval", "remote-write-url"},
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewAlloyAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			if base.Endpoint != "" {
+				base.Endpoint = DefaultRemoteWriteURL
+				if url, _ := options["remoteWriteUrl"].(string); url != "" {
+					base.Endpoint = url
+				}
+			}
+			return &formatters.OtelContribSummary{
+				ActionSummary: base,
+				Receiver:      ServiceDetails["linux"]["receiver"],
+				Exporter:      ServiceDetails["linux"]["exporter"],
+			}
+		},
+		Validate: validate,
+		Spec: func() agentmanager.Spec {
+			return &Spec{}
+		},
+		AddFlags:    addFlags,
+		FlagOptions: flagOptions,
+		Fields:      newViews,
+	})
+}
@@ -0,0 +1,127 @@
+package alloy
+
+import (
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	header           string
+	path             string
+	confirmUninstall bool
+	interval         string
+	remoteWriteURL   string
+	output           forms.Output
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header: styles.MetricfireLogo,
+		apikey: forms.DefaultApiKey,
+	}
+}
+
+func (a *views) InstallView() (*huh.Group, error) {
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(a.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(a.apikey)
+			}).
+			Value(&a.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("interval").
+			Title("Scrape Interval").
+			Prompt("Interval: ").
+			Placeholder(DefaultScrapeInterval).
+			Value(&a.interval).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				_, err := time.ParseDuration(s)
+				return err
+			}),
+
+		huh.NewInput().
+			Key("remoteWriteUrl").
+			Title("Remote Write URL").
+			Description("Optional, defaults to Hosted Graphite's Prometheus remote write endpoint").
+			Prompt("URL: ").
+			Placeholder(DefaultRemoteWriteURL).
+			Value(&a.remoteWriteURL).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				return Config{Interval: DefaultScrapeInterval, URL: s}.Validate()
+			}),
+	)
+
+	return installGroup, nil
+}
+
+func (a *views) InstallOptions(form *huh.Form, options map[string]interface{}) {
+	options["interval"] = form.GetString("interval")
+	options["remoteWriteUrl"] = form.GetString("remoteWriteUrl")
+}
+
+// OutputView leaves out the carbon endpoint fields, alloy uses remote write.
+func (a *views) OutputView() (*huh.Group, error) {
+	return huh.NewGroup(forms.NamingFields(&a.output)...), nil
+}
+
+func (a *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(a.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall Grafana Alloy?").
+			Description("This will remove the agent, but not the configuration files").
+			Value(&a.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+
+func (a *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(a.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(a.apikey)
+			}).
+			Value(&a.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the config.alloy file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&a.path),
+	)
+
+	return updateGroup, nil
+}
@@ -0,0 +1,77 @@
+package collectd
+
+import (
+	"fmt"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+type Collectd struct {
+	apikey          string
+	sysinfo         sysinfo.SysInfo
+	options         map[string]interface{}
+	serviceSettings map[string]string
+	plugins         []string
+	naming          naming.Naming
+	endpoint        endpoint.Endpoint
+}
+
+func NewCollectdAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *Collectd {
+	apikey, ok := options["apikey"].(string)
+	if !ok {
+		apikey = ""
+	}
+
+	plugins, _ := options["collectdPlugins"].([]string)
+	if len(plugins) == 0 {
+		plugins = DefaultPlugins
+	}
+
+	agent := &Collectd{
+		apikey:          apikey,
+		sysinfo:         sysInfo,
+		options:         options,
+		serviceSettings: GetServiceSettings(sysInfo),
+		plugins:         plugins,
+		naming:          naming.New(options),
+		endpoint:        endpoint.New(options),
+	}
+
+	return agent
+}
+
+// ValidateEndpoint checks the endpoint can be used by write_graphite, which
+// speaks tcp and udp but not tls.
+func ValidateEndpoint(e endpoint.Endpoint) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.UseTLS() {
+		return fmt.Errorf("collectd's write_graphite doesn't support tls, use telegraf for the tls endpoint")
+	}
+	return nil
+}
+
+func (c *Collectd) Validate() error {
+	if c.sysinfo.Root != "" {
+		return fmt.Errorf("collectd is installed from the distro packages and can't be staged with --root")
+	}
+	if c.sysinfo.Os != "linux" || c.serviceSettings == nil {
+		return fmt.Errorf("collectd is only supported on linux with apt, yum or dnf")
+	}
+	if version, _ := c.options["version"].(string); version != "" {
+		return fmt.Errorf("collectd is installed from the distro packages, a version can't be chosen")
+	}
+	if err := ValidatePlugins(c.plugins); err != nil {
+		return err
+	}
+	if err := c.naming.Validate(); err != nil {
+		return err
+	}
+	if c.naming.Template != "" || c.naming.TagSupport {
+		return fmt.Errorf("graphite templates and tag support are only supported by telegraf")
+	}
+	return ValidateEndpoint(c.endpoint)
+}
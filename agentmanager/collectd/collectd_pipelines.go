@@ -0,0 +1,212 @@
+package collectd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	collectdPipes "github.com/hostedgraphite/hg-cli/agentmanager/collectd/pipes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+func (c *Collectd) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = c.sysinfo
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The service reads its config from inside any install root.
+	pipes := collectdPipes.LinuxInstallPipes(sysInfo, GetServiceSettings(sysInfo)["configPath"])
+	pipes = append(pipes, c.configPipe()...)
+
+	if start, _ := c.options["startService"].(bool); start {
+		pipes = append(pipes, collectdPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Collectd Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (c *Collectd) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = c.sysinfo
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	pipes := c.configPipe()
+	if start, _ := c.options["startService"].(bool); start {
+		pipes = append(pipes, collectdPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Configuring Collectd Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (c *Collectd) IsInstalled() bool {
+	if _, err := exec.LookPath("collectd"); err != nil {
+		return false
+	}
+	info, err := os.Stat(c.serviceSettings["configPath"])
+	return err == nil && !info.IsDir()
+}
+
+func (c *Collectd) configPipe() []*pipeline.Pipe {
+	configPath := c.serviceSettings["configPath"]
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Writing collectd.conf", exec.Command("sleep", "1")).PostRun(
+			func(ctx context.Context) error {
+				hostname, err := c.naming.ResolveHostname()
+				if err != nil {
+					return err
+				}
+
+				rendered, err := c.config(hostname).Render()
+				if err != nil {
+					return err
+				}
+
+				return os.WriteFile(configPath, rendered, 0640)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				hostname, resolve, err := c.naming.ScriptHostname(shell, false)
+				if err != nil {
+					return "", err
+				}
+
+				rendered, err := c.config(hostname).Render()
+				if err != nil {
+					return "", err
+				}
+
+				script := utils.WriteFileScript(shell, configPath, rendered, 0640)
+				if resolve != "" {
+					script += "\n" + utils.ReplaceScript(shell, configPath, naming.HostnamePlaceholder, resolve)
+				}
+				return script, nil
+			},
+		),
+	}
+	return pipes
+}
+
+func (c *Collectd) config(hostname string) Config {
+	return Config{
+		Plugins:  c.plugins,
+		Interval: DefaultInterval,
+		Hostname: hostname,
+		// Without a hostname collectd uses its own lookup, which
+		// matches the fqdn mode.
+		FQDNLookup: hostname == "",
+		Prefix:     c.naming.MetricPrefix(c.apikey, "collectd"),
+		Address:    c.endpoint.HostPort(),
+		Protocol:   c.endpoint.Transport(),
+	}
+}
+
+func (c *Collectd) UninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = c.sysinfo
+
+	if sysInfo.Os != "linux" {
+		return nil, fmt.Errorf("collectd is only supported on linux with apt, yum or dnf")
+	}
+	if sysInfo.Root != "" {
+		return nil, fmt.Errorf("collectd is installed from the distro packages and can't be staged with --root")
+	}
+
+	pipes := collectdPipes.LinuxUninstallPipes(sysInfo)
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Collectd Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (c *Collectd) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = c.sysinfo
+	var configPath string
+
+	if err := c.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateEndpoint(c.endpoint); err != nil {
+		return nil, err
+	}
+
+	if c.options["config"] != nil {
+		configPath = c.options["config"].(string)
+	} else {
+		configPath = c.serviceSettings["configPath"]
+	}
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Updating collectd write_graphite node", exec.Command("sleep", "1")).PostRun(
+			func(ctx context.Context) error {
+				return writeGraphiteUpdate(c.apikey, configPath, c.naming, c.endpoint)
+			},
+		),
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Updating HostedGraphite Api Key (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+var (
+	nodeBlock   = `<Node "hostedgraphite">(?:.|\s)*?</Node>`
+	prefixRegex = regexp.MustCompile(`Prefix\s+"(.*?)\.?"`)
+)
+
+// writeGraphiteUpdate edits only the hostedgraphite write_graphite node,
+// keeping the prefix segments and endpoint unless new ones were given.
+func writeGraphiteUpdate(apikey, configPath string, n naming.Naming, e endpoint.Endpoint) error {
+	fullConfig, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	block := regexp.MustCompile(nodeBlock).FindString(string(fullConfig))
+	if block == "" {
+		return fmt.Errorf("no hostedgraphite write_graphite node found in %s", configPath)
+	}
+
+	prefix := n.MetricPrefix(apikey, "collectd")
+	if !n.HasPrefix() {
+		if match := prefixRegex.FindStringSubmatch(block); match != nil {
+			prefix = naming.ReplaceKey(match[1], apikey, "collectd")
+		}
+	}
+
+	updates := map[string]string{
+		`Prefix\s+".*?"`: fmt.Sprintf(`Prefix "%s."`, prefix),
+	}
+
+	if e.IsSet() {
+		host, port, err := splitHostPort(e.HostPort())
+		if err != nil {
+			return err
+		}
+		updates[`Host\s+".*?"`] = fmt.Sprintf(`Host "%s"`, host)
+		updates[`Port\s+".*?"`] = fmt.Sprintf(`Port "%s"`, port)
+		updates[`Protocol\s+".*?"`] = fmt.Sprintf(`Protocol "%s"`, e.Transport())
+	}
+
+	updatedConfig, err := utils.UpdateConfigBlock(string(fullConfig), nodeBlock, updates)
+	if err != nil {
+		return fmt.Errorf("error during updating: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, []byte(updatedConfig), 0640); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package collectd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+var configTemplate = template.Must(template.New("collectd.conf").Parse(`# Generated by hg-cli.
+{{- if .Hostname}}
+Hostname "{{.Hostname}}"
+{{- end}}
+FQDNLookup {{.FQDNLookup}}
+Interval {{.Interval}}
+{{range .Plugins}}
+LoadPlugin {{.}}
+{{- end}}
+LoadPlugin write_graphite
+
+<Plugin write_graphite>
+  <Node "hostedgraphite">
+    Host "{{.Host}}"
+    Port "{{.Port}}"
+    Protocol "{{.Protocol}}"
+    Prefix "{{.Prefix}}."
+    StoreRates true
+    AlwaysAppendDS false
+    EscapeCharacter "_"
+  </Node>
+</Plugin>
+`))
+
+// Config is the collectd.conf hg-cli writes: the chosen read plugins and a
+// write_graphite node for Hosted Graphite.
+type Config struct {
+	Plugins    []string
+	Interval   int
+	Hostname   string
+	FQDNLookup bool
+	Prefix     string
+	// Address is the carbon host:port, Protocol is tcp or udp.
+	Address  string
+	Protocol string
+}
+
+func ValidatePlugins(plugins []string) error {
+	var unknown []string
+	for _, plugin := range plugins {
+		if !slices.Contains(Plugins, plugin) {
+			unknown = append(unknown, plugin)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown collectd plugins: %s (available: %s)", strings.Join(unknown, ", "), strings.Join(Plugins, ", "))
+	}
+	return nil
+}
+
+func (c Config) Render() ([]byte, error) {
+	if err := ValidatePlugins(c.Plugins); err != nil {
+		return nil, err
+	}
+
+	host, port, err := splitHostPort(c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Config
+		Host string
+		Port string
+	}{c, host, port}
+
+	var buf bytes.Buffer
+	if err := configTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func splitHostPort(address string) (string, string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid carbon address %q: %v", address, err)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,58 @@
+package collectd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderConfig(t *testing.T) {
+	config := Config{
+		Plugins:  []string{"cpu", "memory"},
+		Interval: DefaultInterval,
+		Hostname: "web-1",
+		Prefix:   "my-key.collectd",
+		Address:  "carbon.hostedgraphite.com:2003",
+		Protocol: "tcp",
+	}
+
+	rendered, err := config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "Hostname \"web-1\"\n")
+	require.Contains(t, string(rendered), "LoadPlugin cpu\nLoadPlugin memory\nLoadPlugin write_graphite\n")
+	require.Contains(t, string(rendered), `Prefix "my-key.collectd."`)
+	require.Contains(t, string(rendered), `Port "2003"`)
+
+	config.Plugins = []string{"cpu", "gpu"}
+	_, err = config.Render()
+	require.EqualError(t, err, "unknown collectd plugins: gpu (available: "+strings.Join(Plugins, ", ")+")")
+}
+
+func TestWriteGraphiteUpdate(t *testing.T) {
+	config := Config{
+		Plugins:  DefaultPlugins,
+		Interval: DefaultInterval,
+		Prefix:   "old-key.prod.collectd",
+		Address:  "carbon.hostedgraphite.com:2003",
+		Protocol: "tcp",
+	}
+	rendered, err := config.Render()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "collectd.conf")
+	require.NoError(t, os.WriteFile(path, rendered, 0644))
+
+	err = writeGraphiteUpdate("new-key", path, naming.Naming{}, endpoint.Endpoint{Mode: endpoint.UDP})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `Prefix "new-key.prod.collectd."`)
+	require.Contains(t, string(content), `Protocol "udp"`)
+	require.Contains(t, string(content), "LoadPlugin cpu\n")
+}
@@ -0,0 +1,70 @@
+package collectd
+
+import (
+	"maps"
+
+	collectdPipes "github.com/hostedgraphite/hg-cli/agentmanager/collectd/pipes"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// Plugins are the read plugins hg-cli can enable, all ship with the distro
+// collectd packages and need no extra configuration.
+var Plugins = []string{
+	"cpu",
+	"memory",
+	"disk",
+	"df",
+	"interface",
+	"load",
+	"swap",
+	"uptime",
+	"processes",
+	"entropy",
+	"users",
+	"irq",
+	"thermal",
+}
+
+var DefaultPlugins = []string{
+	"cpu",
+	"memory",
+	"disk",
+	"df",
+	"interface",
+	"load",
+	"swap",
+	"uptime",
+}
+
+const DefaultInterval = 10
+
+// The start and restart hints depend on the init system, see
+// GetServiceSettings.
+var ServiceDetails = map[string]map[string]string{
+	"apt": {
+		"configPath": "/etc/collectd/collectd.conf",
+		"exporter":   "write_graphite",
+	},
+	"yum": {
+		"configPath": "/etc/collectd.conf",
+		"exporter":   "write_graphite",
+	},
+}
+
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
+	if sysInfo.Os != "linux" {
+		return nil
+	}
+	pkgmngr := sysInfo.PkgMngr
+	if pkgmngr == "dnf" {
+		pkgmngr = "yum"
+	}
+	details, ok := ServiceDetails[pkgmngr]
+	if !ok {
+		return nil
+	}
+
+	settings := maps.Clone(details)
+	settings["startHint"], settings["restartHint"], settings["enableHint"] = collectdPipes.LinuxHints(sysInfo, settings["configPath"])
+	return settings
+}
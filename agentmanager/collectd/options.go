@@ -0,0 +1,35 @@
+package collectd
+
+import (
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/spf13/pflag"
+)
+
+// Spec are the collectd fields of an `hg-cli apply` spec.
+type Spec struct {
+	Plugins []string `yaml:"plugins"`
+}
+
+// Options keeps the plugins apart from telegraf's, they aren't in the
+// telegraf catalog.
+func (s *Spec) Options(options map[string]interface{}) {
+	options["collectdPlugins"] = s.Plugins
+}
+
+func addFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("collectd-plugins", []string{}, "Collectd read plugins, defaults to "+strings.Join(DefaultPlugins, ","))
+}
+
+func flagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	options["collectdPlugins"], _ = flags.GetStringSlice("collectd-plugins")
+}
+
+func validate(options map[string]interface{}, os string) error {
+	plugins, _ := options["collectdPlugins"].([]string)
+	if err := ValidatePlugins(plugins); err != nil {
+		return err
+	}
+	return ValidateEndpoint(endpoint.New(options))
+}
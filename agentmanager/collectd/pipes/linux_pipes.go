@@ -0,0 +1,105 @@
+package pipes
+
+import (
+	"os/exec"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// linuxService runs collectd in the foreground under the init system, as
+// root like the packaged services.
+func linuxService(root, configPath string) service.Service {
+	return service.Service{
+		Name:        "collectd",
+		DisplayName: "Collectd",
+		Description: "Collectd statistics daemon",
+		Command:     "/usr/sbin/collectd",
+		Args:        []string{"-f", "-C", configPath},
+		Root:        root,
+	}
+}
+
+// packagedService reports whether the package already provides a service for
+// the init system. Debian's ships a systemd unit and an init.d script, EPEL's
+// only a systemd unit.
+func packagedService(pkgMngr, init string) bool {
+	if pkgMngr == "apt" {
+		return init == sysinfo.InitSystemd || init == sysinfo.InitSysV
+	}
+	return init == sysinfo.InitSystemd
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo, configPath string) (string, string, string) {
+	return linuxService("", configPath).Hints(service.Init(sysInfo))
+}
+
+// LinuxInstallPipes installs collectd from the distro packages, on yum based
+// distros it lives in EPEL.
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, configPath string) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+
+	switch sysInfo.PkgMngr {
+	case "apt":
+		pipes = []*pipeline.Pipe{
+			{
+				Name: "Updating Package List",
+				Cmd:  exec.Command("apt-get", "update"),
+			},
+			{
+				Name: "Installing Collectd",
+				Cmd:  exec.Command("apt-get", "install", "-y", "--no-install-recommends", "collectd"),
+			},
+		}
+	case "yum", "dnf":
+		pipes = []*pipeline.Pipe{
+			{
+				Name: "Enabling EPEL Repository",
+				Cmd:  exec.Command(sysInfo.PkgMngr, "install", "-y", "epel-release"),
+			},
+			{
+				Name: "Installing Collectd",
+				Cmd:  exec.Command(sysInfo.PkgMngr, "install", "-y", "collectd"),
+			},
+		}
+	}
+
+	if !packagedService(sysInfo.PkgMngr, init) {
+		pipes = append(pipes, linuxService(sysInfo.Root, configPath).InstallPipes(init)...)
+	}
+
+	return pipes
+}
+
+func LinuxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo.Root, "")
+
+	if service.Managed(sysInfo) {
+		pipes = svc.StopPipes(init)
+	}
+
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Uninstalling Collectd",
+		Cmd:  exec.Command(sysInfo.PkgMngr, "remove", "-y", "collectd"),
+	})
+
+	if !packagedService(sysInfo.PkgMngr, init) {
+		pipes = append(pipes, svc.RemovePipes(init)...)
+	}
+
+	return pipes
+}
+
+func LinuxRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if !service.Managed(sysInfo) {
+		return nil
+	}
+
+	return linuxService(sysInfo.Root, "").RestartPipes(service.Init(sysInfo))
+}
@@ -0,0 +1,36 @@
+package collectd
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:        "collectd",
+		DisplayName: "Collectd",
+		Flags:       []string{"collectd-plugins"},
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewCollectdAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			plugins, _ := options["collectdPlugins"].([]string)
+			if len(plugins) == 0 && base.Action == "Install" {
+				plugins = DefaultPlugins
+			}
+			return &formatters.TelegrafSummary{
+				ActionSummary: base,
+				Plugins:       plugins,
+			}
+		},
+		Validate: validate,
+		Spec: func() agentmanager.Spec {
+			return &Spec{}
+		},
+		AddFlags:    addFlags,
+		FlagOptions: flagOptions,
+		Fields:      newViews,
+	})
+}
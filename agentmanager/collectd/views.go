@@ -0,0 +1,103 @@
+package collectd
+
+import (
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	header           string
+	path             string
+	confirmUninstall bool
+	plugins          []string
+	output           forms.Output
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header:  styles.MetricfireLogo,
+		apikey:  forms.DefaultApiKey,
+		plugins: DefaultPlugins,
+	}
+}
+
+func (c *views) InstallView() (*huh.Group, error) {
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(c.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(c.apikey)
+			}).
+			Value(&c.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewMultiSelect[string]().
+			Key("collectdPlugins").
+			Title("Select the collectd plugins to enable").
+			Options(huh.NewOptions(Plugins...)...).
+			Value(&c.plugins),
+	)
+
+	return installGroup, nil
+}
+
+func (c *views) InstallOptions(form *huh.Form, options map[string]interface{}) {
+	options["collectdPlugins"] = c.plugins
+}
+
+// OutputView leaves out tls, write_graphite can't use it.
+func (c *views) OutputView() (*huh.Group, error) {
+	return forms.OutputGroup(&c.output, false, endpoint.Plaintext, endpoint.UDP), nil
+}
+
+func (c *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(c.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall Collectd?").
+			Description("This will remove the agent, but not the configuration files").
+			Value(&c.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+
+func (c *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(c.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(c.apikey)
+			}).
+			Value(&c.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the conf file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&c.path),
+	)
+
+	return updateGroup, nil
+}
@@ -0,0 +1,242 @@
+// Package container runs agents as containers rather than installing them on
+// the host, for hosts that only run containers.
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ModeHost   = "host"
+	ModeDocker = "docker"
+
+	// ConfigRoot holds the configs generated for containers, mounted read
+	// only into each one.
+	ConfigRoot = "/etc/hg-cli"
+	// HostRoot is where the host filesystem is mounted inside the container.
+	HostRoot = "/hostfs"
+)
+
+var Modes = []string{ModeHost, ModeDocker}
+
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+func (m Mount) String() string {
+	if m.ReadOnly {
+		return m.Source + ":" + m.Target + ":ro"
+	}
+	return m.Source + ":" + m.Target
+}
+
+// Container is how an agent is run with docker.
+type Container struct {
+	Name  string
+	Image string
+	User  string
+	Env   map[string]string
+	// EnvFile holds the environment only root can read, such as the api key
+	// the config references.
+	EnvFile string
+	Mounts  []Mount
+	Command []string
+}
+
+// Mode returns the deployment mode from the agent options.
+func Mode(options map[string]interface{}) string {
+	if mode, _ := options["mode"].(string); mode != "" {
+		return mode
+	}
+	return ModeHost
+}
+
+func ValidateMode(mode, os string) error {
+	switch mode {
+	case "", ModeHost:
+		return nil
+	case ModeDocker:
+		if os != "linux" {
+			return fmt.Errorf("docker mode is only supported on linux")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid mode %q (available: %s, %s)", mode, ModeHost, ModeDocker)
+}
+
+// ConfigDir is the host directory holding an agent's container config.
+func ConfigDir(agent string) string {
+	return path.Join(ConfigRoot, agent)
+}
+
+// HostMounts give the agent a read only view of the host, /proc and /sys
+// included, and the docker socket so containers can be monitored.
+func HostMounts() []Mount {
+	return []Mount{
+		{Source: "/", Target: HostRoot, ReadOnly: true},
+		{Source: "/var/run/docker.sock", Target: "/var/run/docker.sock", ReadOnly: true},
+	}
+}
+
+// Settings are the service settings shown in summaries for a container.
+func Settings(c Container, configPath, composeFile string) map[string]string {
+	settings := map[string]string{
+		"configPath":  configPath,
+		"startHint":   "docker start " + c.Name,
+		"restartHint": "docker restart " + c.Name,
+	}
+	if composeFile != "" {
+		settings["startHint"] = fmt.Sprintf("docker compose -f %s up -d", composeFile)
+		settings["restartHint"] = fmt.Sprintf("docker compose -f %s restart", composeFile)
+	}
+	return settings
+}
+
+func envLines(vars map[string]string) []string {
+	var env []string
+	for key, value := range vars {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func (c Container) env() []string {
+	return envLines(c.Env)
+}
+
+// RunArgs are the `docker run` arguments for the container. Host networking
+// and pid namespace let the agent report the host rather than itself.
+func (c Container) RunArgs() []string {
+	args := []string{
+		"run", "-d",
+		"--name", c.Name,
+		"--restart", "unless-stopped",
+		"--network", "host",
+		"--pid", "host",
+	}
+	if c.User != "" {
+		args = append(args, "--user", c.User)
+	}
+	for _, env := range c.env() {
+		args = append(args, "-e", env)
+	}
+	if c.EnvFile != "" {
+		args = append(args, "--env-file", c.EnvFile)
+	}
+	for _, mount := range c.Mounts {
+		args = append(args, "-v", mount.String())
+	}
+	args = append(args, c.Image)
+	return append(args, c.Command...)
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image         string   `yaml:"image"`
+	ContainerName string   `yaml:"container_name"`
+	Restart       string   `yaml:"restart"`
+	NetworkMode   string   `yaml:"network_mode"`
+	Pid           string   `yaml:"pid"`
+	User          string   `yaml:"user,omitempty"`
+	Environment   []string `yaml:"environment,omitempty"`
+	EnvFile       []string `yaml:"env_file,omitempty"`
+	Volumes       []string `yaml:"volumes"`
+	Command       []string `yaml:"command,omitempty"`
+}
+
+// Compose renders a docker-compose.yml running the same container.
+func (c Container) Compose() ([]byte, error) {
+	service := composeService{
+		Image:         c.Image,
+		ContainerName: c.Name,
+		Restart:       "unless-stopped",
+		NetworkMode:   "host",
+		Pid:           "host",
+		User:          c.User,
+		Environment:   c.env(),
+		Command:       c.Command,
+	}
+	if c.EnvFile != "" {
+		service.EnvFile = []string{c.EnvFile}
+	}
+	for _, mount := range c.Mounts {
+		service.Volumes = append(service.Volumes, mount.String())
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(composeFile{Services: map[string]composeService{c.Name: service}}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Pipes start the container, or only write the compose file when one was
+// asked for.
+func Pipes(c Container, composePath string) []*pipeline.Pipe {
+	if composePath != "" {
+		return []*pipeline.Pipe{
+			pipeline.NewPipe("Writing "+composePath, exec.Command("mkdir", "-p", path.Dir(composePath))).PostRun(
+				func(ctx context.Context) error {
+					compose, err := c.Compose()
+					if err != nil {
+						return err
+					}
+					return os.WriteFile(composePath, compose, 0644)
+				},
+			),
+		}
+	}
+
+	return []*pipeline.Pipe{
+		{
+			Name: "Removing old " + c.Name + " container",
+			Cmd:  exec.Command("sh", "-c", fmt.Sprintf("docker rm -f %s >/dev/null 2>&1 || true", c.Name)),
+		},
+		{
+			Name: "Starting " + c.Name + " container",
+			Cmd:  exec.Command("docker", c.RunArgs()...),
+		},
+	}
+}
+
+// EnvFilePipe writes env to the container's environment file, which only root
+// can read. docker reads it when the container is created.
+func EnvFilePipe(c Container, env map[string]string) *pipeline.Pipe {
+	content := []byte(strings.Join(envLines(env), "\n") + "\n")
+
+	return pipeline.NewPipe("Writing "+c.EnvFile, exec.Command("mkdir", "-p", path.Dir(c.EnvFile))).PostRun(
+		func(ctx context.Context) error {
+			if err := os.WriteFile(c.EnvFile, content, 0600); err != nil {
+				return err
+			}
+			// WriteFile keeps the mode of an existing file.
+			return os.Chmod(c.EnvFile, 0600)
+		},
+	)
+}
+
+// PullPipe fetches the image, so a bad tag fails before any config is written.
+func PullPipe(image string) *pipeline.Pipe {
+	return &pipeline.Pipe{
+		Name: "Pulling " + image,
+		Cmd:  exec.Command("docker", "pull", image),
+	}
+}
@@ -0,0 +1,83 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testContainer() Container {
+	return Container{
+		Name:    "hg-cli-telegraf",
+		Image:   "telegraf:latest",
+		Env:     map[string]string{"HOST_SYS": "/hostfs/sys", "HOST_PROC": "/hostfs/proc"},
+		EnvFile: "/etc/hg-cli/telegraf/telegraf.env",
+		Mounts: append([]Mount{
+			{Source: "/etc/hg-cli/telegraf/telegraf.conf", Target: "/etc/telegraf/telegraf.conf", ReadOnly: true},
+		}, HostMounts()...),
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	require.Equal(t, []string{
+		"run", "-d",
+		"--name", "hg-cli-telegraf",
+		"--restart", "unless-stopped",
+		"--network", "host",
+		"--pid", "host",
+		"-e", "HOST_PROC=/hostfs/proc",
+		"-e", "HOST_SYS=/hostfs/sys",
+		"--env-file", "/etc/hg-cli/telegraf/telegraf.env",
+		"-v", "/etc/hg-cli/telegraf/telegraf.conf:/etc/telegraf/telegraf.conf:ro",
+		"-v", "/:/hostfs:ro",
+		"-v", "/var/run/docker.sock:/var/run/docker.sock:ro",
+		"telegraf:latest",
+	}, testContainer().RunArgs())
+}
+
+func TestCompose(t *testing.T) {
+	compose, err := testContainer().Compose()
+	require.NoError(t, err)
+
+	require.Equal(t, `services:
+  hg-cli-telegraf:
+    image: telegraf:latest
+    container_name: hg-cli-telegraf
+    restart: unless-stopped
+    network_mode: host
+    pid: host
+    environment:
+      - HOST_PROC=/hostfs/proc
+      - HOST_SYS=/hostfs/sys
+    env_file:
+      - /etc/hg-cli/telegraf/telegraf.env
+    volumes:
+      - /etc/hg-cli/telegraf/telegraf.conf:/etc/telegraf/telegraf.conf:ro
+      - /:/hostfs:ro
+      - /var/run/docker.sock:/var/run/docker.sock:ro
+`, string(compose))
+}
+
+func TestEnvFilePipe(t *testing.T) {
+	c := testContainer()
+	c.EnvFile = filepath.Join(t.TempDir(), "telegraf", "telegraf.env")
+
+	_, err := EnvFilePipe(c, map[string]string{"HG_API_KEY": "my-key"}).Run()
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(c.EnvFile)
+	require.NoError(t, err)
+	require.Equal(t, "HG_API_KEY=my-key\n", string(content))
+	info, err := os.Stat(c.EnvFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestValidateMode(t *testing.T) {
+	require.NoError(t, ValidateMode("", "darwin"))
+	require.NoError(t, ValidateMode(ModeDocker, "linux"))
+	require.Error(t, ValidateMode(ModeDocker, "windows"))
+	require.Error(t, ValidateMode("podman", "linux"))
+}
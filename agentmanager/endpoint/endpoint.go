@@ -0,0 +1,102 @@
+package endpoint
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+const (
+	Plaintext = "plaintext"
+	TLS       = "tls"
+	UDP       = "udp"
+)
+
+var Modes = []string{Plaintext, TLS, UDP}
+
+const carbonHost = "carbon.hostedgraphite.com"
+
+var defaultPorts = map[string]string{
+	Plaintext: "2003",
+	TLS:       "20030",
+	UDP:       "2003",
+}
+
+// Endpoint is where an agent sends its metrics. A zero value means the
+// endpoint wasn't chosen, installs fall back to plaintext and updates keep
+// whatever the config already has.
+type Endpoint struct {
+	Mode string
+	// Address overrides the Hosted Graphite carbon host:port, e.g. for a
+	// private endpoint or relay.
+	Address string
+	// TLSCA is the path to a CA bundle used to verify the endpoint.
+	TLSCA              string
+	InsecureSkipVerify bool
+}
+
+func New(options map[string]interface{}) Endpoint {
+	var e Endpoint
+
+	e.Mode, _ = options["endpoint"].(string)
+	e.Address, _ = options["endpointAddress"].(string)
+	e.TLSCA, _ = options["tlsCA"].(string)
+	e.InsecureSkipVerify, _ = options["tlsInsecureSkipVerify"].(bool)
+
+	return e
+}
+
+func (e Endpoint) IsSet() bool {
+	return e.Mode != "" || e.Address != ""
+}
+
+func (e Endpoint) Validate() error {
+	if e.Mode != "" && !slices.Contains(Modes, e.Mode) {
+		return fmt.Errorf("invalid endpoint %q (available: %s)", e.Mode, strings.Join(Modes, ", "))
+	}
+
+	if e.Address != "" {
+		if _, _, err := net.SplitHostPort(e.Address); err != nil {
+			return fmt.Errorf("invalid endpoint address %q: %v", e.Address, err)
+		}
+	}
+
+	if (e.TLSCA != "" || e.InsecureSkipVerify) && e.mode() != TLS {
+		return fmt.Errorf("tls options can only be used with the tls endpoint")
+	}
+
+	return nil
+}
+
+func (e Endpoint) mode() string {
+	if e.Mode == "" {
+		return Plaintext
+	}
+	return e.Mode
+}
+
+// Transport returns the protocol used to reach the endpoint: tcp or udp.
+func (e Endpoint) Transport() string {
+	if e.mode() == UDP {
+		return "udp"
+	}
+	return "tcp"
+}
+
+func (e Endpoint) UseTLS() bool {
+	return e.mode() == TLS
+}
+
+// HostPort returns the address metrics are sent to.
+func (e Endpoint) HostPort() string {
+	if e.Address != "" {
+		return e.Address
+	}
+	return net.JoinHostPort(carbonHost, defaultPorts[e.mode()])
+}
+
+// Describe is a short human readable summary, used in install summaries.
+func (e Endpoint) Describe() string {
+	return fmt.Sprintf("%s (%s)", e.HostPort(), e.mode())
+}
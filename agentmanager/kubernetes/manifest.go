@@ -0,0 +1,366 @@
+// Package kubernetes renders the manifests deploying an agent to every node
+// of a cluster, for `hg-cli k8s manifest`.
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultNamespace = "hg-cli"
+
+	// HostRoot is where the node filesystem is mounted in node workloads.
+	HostRoot = "/hostfs"
+
+	// ApiKeyEnv holds the api key in every pod, configs reference it rather
+	// than embedding the key.
+	ApiKeyEnv = "HG_API_KEY"
+	// NodeNameEnv and NodeIPEnv are the node the pod is scheduled on.
+	NodeNameEnv = "K8S_NODE_NAME"
+	NodeIPEnv   = "K8S_NODE_IP"
+
+	secretName = "hg-cli-api-key"
+	secretKey  = "api-key"
+)
+
+// Workload is an agent container along with its generated config.
+type Workload struct {
+	Name  string
+	Image string
+	// Config is mounted from a ConfigMap as ConfigDir/ConfigFile.
+	ConfigDir  string
+	ConfigFile string
+	Config     []byte
+	Args       []string
+	Env        map[string]string
+	RunAsRoot  bool
+}
+
+// Manifest is everything deployed for an agent. Node runs as a DaemonSet,
+// the optional Cluster workload collects cluster wide metrics once from a
+// single replica Deployment.
+type Manifest struct {
+	Namespace string
+	ApiKey    string
+	Node      Workload
+	Cluster   *Workload
+}
+
+// File is one rendered manifest file, made of one or more documents.
+type File struct {
+	Name      string
+	Documents []interface{}
+}
+
+type object = map[string]interface{}
+
+func (m Manifest) Validate() error {
+	if m.ApiKey == "" {
+		return fmt.Errorf("an api key is required for the secret")
+	}
+	if m.Node.Name == "" || m.Node.Image == "" {
+		return fmt.Errorf("the node workload needs a name and image")
+	}
+	return nil
+}
+
+func (m Manifest) namespace() string {
+	if m.Namespace == "" {
+		return DefaultNamespace
+	}
+	return m.Namespace
+}
+
+func (m Manifest) metadata(name string) object {
+	return object{
+		"name":      name,
+		"namespace": m.namespace(),
+		"labels":    object{"app.kubernetes.io/managed-by": "hg-cli"},
+	}
+}
+
+// Files renders the manifests in the order they should be applied.
+func (m Manifest) Files() ([]File, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	configMaps := []interface{}{m.configMap(m.Node)}
+	workloads := []interface{}{m.daemonSet(m.Node)}
+	if m.Cluster != nil {
+		configMaps = append(configMaps, m.configMap(*m.Cluster))
+		workloads = append(workloads, m.deployment(*m.Cluster))
+	}
+
+	return []File{
+		{Name: "00-namespace.yaml", Documents: []interface{}{m.namespaceObject()}},
+		{Name: "01-rbac.yaml", Documents: m.rbac()},
+		{Name: "02-secret.yaml", Documents: []interface{}{m.secret()}},
+		{Name: "03-configmap.yaml", Documents: configMaps},
+		{Name: "04-workloads.yaml", Documents: workloads},
+	}, nil
+}
+
+func (m Manifest) namespaceObject() object {
+	return object{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": object{
+			"name":   m.namespace(),
+			"labels": object{"app.kubernetes.io/managed-by": "hg-cli"},
+		},
+	}
+}
+
+func (m Manifest) rbac() []interface{} {
+	readOnly := []string{"get", "list", "watch"}
+	clusterRole := object{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   object{"name": "hg-cli-" + m.namespace()},
+		"rules": []object{
+			{
+				"apiGroups": []string{""},
+				"resources": []string{
+					"nodes", "nodes/stats", "nodes/proxy", "nodes/metrics",
+					"pods", "namespaces", "services", "endpoints", "events",
+					"persistentvolumes", "persistentvolumeclaims",
+					"replicationcontrollers", "resourcequotas",
+				},
+				"verbs": readOnly,
+			},
+			{
+				"apiGroups": []string{"apps"},
+				"resources": []string{"daemonsets", "deployments", "replicasets", "statefulsets"},
+				"verbs":     readOnly,
+			},
+			{
+				"apiGroups": []string{"batch"},
+				"resources": []string{"jobs", "cronjobs"},
+				"verbs":     readOnly,
+			},
+			{
+				"apiGroups": []string{"autoscaling"},
+				"resources": []string{"horizontalpodautoscalers"},
+				"verbs":     readOnly,
+			},
+		},
+	}
+
+	return []interface{}{
+		object{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   m.metadata("hg-cli"),
+		},
+		clusterRole,
+		object{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRoleBinding",
+			"metadata":   object{"name": "hg-cli-" + m.namespace()},
+			"roleRef": object{
+				"apiGroup": "rbac.authorization.k8s.io",
+				"kind":     "ClusterRole",
+				"name":     "hg-cli-" + m.namespace(),
+			},
+			"subjects": []object{
+				{"kind": "ServiceAccount", "name": "hg-cli", "namespace": m.namespace()},
+			},
+		},
+	}
+}
+
+func (m Manifest) secret() object {
+	return object{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   m.metadata(secretName),
+		"type":       "Opaque",
+		"stringData": object{secretKey: m.ApiKey},
+	}
+}
+
+func (m Manifest) configMap(w Workload) object {
+	return object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   m.metadata(w.Name),
+		"data":       object{w.ConfigFile: string(w.Config)},
+	}
+}
+
+func (m Manifest) container(w Workload, node bool) object {
+	env := []object{
+		{
+			"name": ApiKeyEnv,
+			"valueFrom": object{
+				"secretKeyRef": object{"name": secretName, "key": secretKey},
+			},
+		},
+		{
+			"name":      NodeNameEnv,
+			"valueFrom": object{"fieldRef": object{"fieldPath": "spec.nodeName"}},
+		},
+		{
+			"name":      NodeIPEnv,
+			"valueFrom": object{"fieldRef": object{"fieldPath": "status.hostIP"}},
+		},
+	}
+	for _, name := range sortedKeys(w.Env) {
+		env = append(env, object{"name": name, "value": w.Env[name]})
+	}
+
+	mounts := []object{
+		{"name": "config", "mountPath": w.ConfigDir, "readOnly": true},
+	}
+	if node {
+		mounts = append(mounts, object{
+			"name":             "hostfs",
+			"mountPath":        HostRoot,
+			"readOnly":         true,
+			"mountPropagation": "HostToContainer",
+		})
+	}
+
+	container := object{
+		"name":         w.Name,
+		"image":        w.Image,
+		"env":          env,
+		"volumeMounts": mounts,
+		"resources": object{
+			"requests": object{"cpu": "50m", "memory": "64Mi"},
+			"limits":   object{"memory": "256Mi"},
+		},
+	}
+	if len(w.Args) > 0 {
+		container["args"] = w.Args
+	}
+	if w.RunAsRoot {
+		container["securityContext"] = object{"runAsUser": 0}
+	}
+	return container
+}
+
+func (m Manifest) podSpec(w Workload, node bool) object {
+	volumes := []object{
+		{"name": "config", "configMap": object{"name": w.Name}},
+	}
+
+	spec := object{
+		"serviceAccountName": "hg-cli",
+		"containers":         []object{m.container(w, node)},
+		"volumes":            volumes,
+	}
+
+	if node {
+		// The host namespaces let the agent report the node's network and
+		// processes rather than its own pod.
+		spec["hostNetwork"] = true
+		spec["hostPID"] = true
+		spec["dnsPolicy"] = "ClusterFirstWithHostNet"
+		spec["tolerations"] = []object{{"operator": "Exists"}}
+		spec["volumes"] = append(volumes, object{
+			"name":     "hostfs",
+			"hostPath": object{"path": "/"},
+		})
+	}
+
+	return spec
+}
+
+func (m Manifest) workload(kind string, w Workload, node bool) object {
+	labels := object{"app.kubernetes.io/name": w.Name}
+
+	spec := object{
+		"selector": object{"matchLabels": labels},
+		"template": object{
+			"metadata": object{"labels": labels},
+			"spec":     m.podSpec(w, node),
+		},
+	}
+	if kind == "Deployment" {
+		spec["replicas"] = 1
+	}
+
+	return object{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata":   m.metadata(w.Name),
+		"spec":       spec,
+	}
+}
+
+func (m Manifest) daemonSet(w Workload) object {
+	return m.workload("DaemonSet", w, true)
+}
+
+func (m Manifest) deployment(w Workload) object {
+	return m.workload("Deployment", w, false)
+}
+
+// Encode renders the documents as a single yaml stream.
+func (f File) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, document := range f.Documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Stream joins every file into one yaml stream, for stdout.
+func Stream(files []File) ([]byte, error) {
+	var docs []string
+	for _, file := range files {
+		content, err := file.Encode()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, strings.TrimSuffix(string(content), "\n"))
+	}
+	return []byte(strings.Join(docs, "\n---\n") + "\n"), nil
+}
+
+// WriteDir writes each file into dir, creating it when needed.
+func WriteDir(files []File, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	for _, file := range files {
+		content, err := file.Encode()
+		if err != nil {
+			return err
+		}
+		// The secret holds the api key.
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(file.Name, "secret.yaml") {
+			mode = 0600
+		}
+		if err := os.WriteFile(filepath.Join(dir, file.Name), content, mode); err != nil {
+			return fmt.Errorf("error writing %s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
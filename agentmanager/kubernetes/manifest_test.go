@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testManifest() Manifest {
+	return Manifest{
+		ApiKey: "key",
+		Node: Workload{
+			Name:       "hg-cli-telegraf",
+			Image:      "telegraf:latest",
+			ConfigDir:  "/etc/telegraf",
+			ConfigFile: "telegraf.conf",
+			Config:     []byte("[agent]\n"),
+		},
+	}
+}
+
+func kinds(stream []byte) []string {
+	var result []string
+	decoder := yaml.NewDecoder(strings.NewReader(string(stream)))
+	for {
+		var document struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := decoder.Decode(&document); err != nil {
+			break
+		}
+		result = append(result, document.Kind)
+	}
+	return result
+}
+
+func TestManifestStream(t *testing.T) {
+	files, err := testManifest().Files()
+	require.NoError(t, err)
+
+	stream, err := Stream(files)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Namespace", "ServiceAccount", "ClusterRole", "ClusterRoleBinding",
+		"Secret", "ConfigMap", "DaemonSet",
+	}, kinds(stream))
+	require.Contains(t, string(stream), "namespace: hg-cli")
+}
+
+func TestManifestCluster(t *testing.T) {
+	manifest := testManifest()
+	manifest.Namespace = "monitoring"
+	manifest.Cluster = &Workload{Name: "hg-cli-telegraf-cluster", Image: "telegraf:latest", ConfigDir: "/etc/telegraf", ConfigFile: "telegraf.conf"}
+
+	files, err := manifest.Files()
+	require.NoError(t, err)
+
+	stream, err := Stream(files)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DaemonSet", "Deployment"}, kinds(stream)[7:])
+	require.NotContains(t, string(stream), "namespace: hg-cli\n")
+}
+
+func TestManifestWriteDir(t *testing.T) {
+	files, err := testManifest().Files()
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "manifests")
+	require.NoError(t, WriteDir(files, dir))
+
+	info, err := os.Stat(filepath.Join(dir, "02-secret.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestManifestValidate(t *testing.T) {
+	manifest := testManifest()
+	manifest.ApiKey = ""
+	require.Error(t, manifest.Validate())
+}
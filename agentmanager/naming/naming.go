@@ -0,0 +1,285 @@
+package naming
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var HostnameModes = []string{"short", "fqdn", "cloud"}
+
+var segmentRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Naming controls the metric namespace and host identity an agent reports
+// with. A zero value keeps each agent's defaults.
+type Naming struct {
+	// Prefix replaces the agent name in "<apikey>.<segments>.<prefix>".
+	Prefix string
+	// Segments such as env, team or region, inserted after the api key.
+	Segments []string
+	// Hostname overrides the detected hostname when set.
+	Hostname string
+	// HostnameMode picks how the hostname is detected: short, fqdn or cloud.
+	HostnameMode string
+	// Template is the graphite template string, telegraf only.
+	Template string
+	// TagSupport enables graphite_tag_support, telegraf only.
+	TagSupport bool
+}
+
+func New(options map[string]interface{}) Naming {
+	var n Naming
+
+	n.Prefix, _ = options["prefix"].(string)
+	n.Segments, _ = options["segments"].([]string)
+	n.Hostname, _ = options["hostname"].(string)
+	n.HostnameMode, _ = options["hostnameMode"].(string)
+	n.Template, _ = options["template"].(string)
+	n.TagSupport, _ = options["tagSupport"].(bool)
+
+	return n
+}
+
+func (n Naming) Validate() error {
+	if n.Prefix != "" {
+		for _, segment := range strings.Split(n.Prefix, ".") {
+			if !segmentRegex.MatchString(segment) {
+				return fmt.Errorf("invalid prefix %q: segments may only contain letters, numbers, '-' and '_'", n.Prefix)
+			}
+		}
+	}
+
+	for _, segment := range n.Segments {
+		if !segmentRegex.MatchString(segment) {
+			return fmt.Errorf("invalid prefix segment %q: only letters, numbers, '-' and '_' are allowed", segment)
+		}
+	}
+
+	if n.HostnameMode != "" && !slices.Contains(HostnameModes, n.HostnameMode) {
+		return fmt.Errorf("invalid hostname mode %q (available: %s)", n.HostnameMode, strings.Join(HostnameModes, ", "))
+	}
+
+	if n.Hostname != "" && n.HostnameMode != "" {
+		return fmt.Errorf("hostname and hostname mode can't be used together")
+	}
+
+	return nil
+}
+
+// HasPrefix reports whether the metric prefix was customised.
+func (n Naming) HasPrefix() bool {
+	return n.Prefix != "" || len(n.Segments) > 0
+}
+
+// HasHostname reports whether the hostname was customised.
+func (n Naming) HasHostname() bool {
+	return n.Hostname != "" || n.HostnameMode != ""
+}
+
+// MetricPrefix builds "<apikey>.<segments...>.<prefix>", using the agent
+// default when no prefix was given.
+func (n Naming) MetricPrefix(apikey, agentDefault string) string {
+	prefix := n.Prefix
+	if prefix == "" {
+		prefix = agentDefault
+	}
+
+	parts := append([]string{apikey}, n.Segments...)
+	parts = append(parts, prefix)
+
+	return strings.Join(parts, ".")
+}
+
+// ReplaceKey swaps the api key at the start of an existing prefix, keeping
+// any segments that were set at install time.
+func ReplaceKey(existingPrefix, apikey, agentDefault string) string {
+	_, rest, found := strings.Cut(existingPrefix, ".")
+	if !found || rest == "" {
+		return apikey + "." + agentDefault
+	}
+	return apikey + "." + rest
+}
+
+var execCommand = exec.Command
+
+// ResolveHostname returns the hostname to report. An empty string with no
+// error means the agent's own detection should be used.
+func (n Naming) ResolveHostname() (string, error) {
+	if n.Hostname != "" {
+		return n.Hostname, nil
+	}
+
+	switch n.HostnameMode {
+	case "short":
+		hostname, err := os.Hostname()
+		if err != nil {
+			return "", fmt.Errorf("error getting hostname: %v", err)
+		}
+		short, _, _ := strings.Cut(hostname, ".")
+		return short, nil
+	case "fqdn":
+		output, err := execCommand("hostname", "-f").Output()
+		if err != nil {
+			return "", fmt.Errorf("error getting fqdn: %v", err)
+		}
+		return strings.TrimSpace(string(output)), nil
+	case "cloud":
+		return CloudInstanceID()
+	}
+
+	return "", nil
+}
+
+var metadataTimeout = 2 * time.Second
+
+type metadataSource struct {
+	name    string
+	url     string
+	headers map[string]string
+}
+
+var metadataSources = []metadataSource{
+	{
+		name:    "gcp",
+		url:     "http://metadata.google.internal/computeMetadata/v1/instance/id",
+		headers: map[string]string{"Metadata-Flavor": "Google"},
+	},
+	{
+		name:    "azure",
+		url:     "http://169.254.169.254/metadata/instance/compute/vmId?api-version=2021-02-01&format=text",
+		headers: map[string]string{"Metadata": "true"},
+	},
+}
+
+// HostnamePlaceholder stands in for the hostname in configs written by
+// exported scripts, where it has to be resolved on the target host.
+const HostnamePlaceholder = "__HG_HOSTNAME__"
+
+// ScriptHostname is ResolveHostname for exported scripts. It returns the
+// hostname to render into configs and, when that's the placeholder, the shell
+// expression resolving it on the target. With required set the default mode
+// resolves the plain hostname instead of leaving it to the agent.
+func (n Naming) ScriptHostname(shell string, required bool) (string, string, error) {
+	if n.Hostname != "" {
+		return n.Hostname, "", nil
+	}
+
+	mode := n.HostnameMode
+	if mode == "" && required {
+		mode = "default"
+	}
+
+	var expression string
+	switch mode {
+	case "":
+		return "", "", nil
+	case "cloud":
+		return "", "", fmt.Errorf("hostname mode cloud can't be resolved in a script, use --hostname")
+	case "fqdn":
+		expression = "hostname -f"
+		if shell == "powershell" {
+			expression = "[System.Net.Dns]::GetHostEntry('').HostName"
+		}
+	case "short":
+		expression = "hostname -s"
+		if shell == "powershell" {
+			expression = "$env:COMPUTERNAME"
+		}
+	default:
+		expression = "hostname"
+		if shell == "powershell" {
+			expression = "$env:COMPUTERNAME"
+		}
+	}
+
+	return HostnamePlaceholder, expression, nil
+}
+
+// CloudInstanceID asks the AWS, GCP and Azure metadata services for the
+// instance id, returning the first one that answers.
+func CloudInstanceID() (string, error) {
+	client := &http.Client{Timeout: metadataTimeout}
+
+	if id, err := awsInstanceID(client); err == nil {
+		return id, nil
+	}
+
+	for _, source := range metadataSources {
+		id, err := metadataGet(client, source.url, source.headers)
+		if err == nil {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to get cloud instance id: no metadata service responded")
+}
+
+func awsInstanceID(client *http.Client) (string, error) {
+	// IMDSv2 requires a session token before any metadata can be read.
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, "http://169.254.169.254/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code: %d", resp.StatusCode)
+	}
+
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return metadataGet(client, "http://169.254.169.254/latest/meta-data/instance-id", map[string]string{
+		"X-aws-ec2-metadata-token": string(token),
+	})
+}
+
+func metadataGet(client *http.Client, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.TrimSpace(string(body))
+	if id == "" {
+		return "", fmt.Errorf("empty instance id")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,47 @@
+package naming
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricPrefix(t *testing.T) {
+	require.Equal(t, "key.telegraf", Naming{}.MetricPrefix("key", "telegraf"))
+	require.Equal(t, "key.prod.core.eu-west-1.telegraf", Naming{Segments: []string{"prod", "core", "eu-west-1"}}.MetricPrefix("key", "telegraf"))
+	require.Equal(t, "key.prod.hosts", Naming{Prefix: "hosts", Segments: []string{"prod"}}.MetricPrefix("key", "opentel"))
+}
+
+func TestReplaceKey(t *testing.T) {
+	require.Equal(t, "new.prod.telegraf", ReplaceKey("old.prod.telegraf", "new", "telegraf"))
+	require.Equal(t, "new.telegraf", ReplaceKey("", "new", "telegraf"))
+	require.Equal(t, "new.opentel", ReplaceKey("old", "new", "opentel"))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Naming{Prefix: "hosts.linux", Segments: []string{"prod"}, HostnameMode: "fqdn"}.Validate())
+	require.Error(t, Naming{Segments: []string{"prod env"}}.Validate())
+	require.Error(t, Naming{Prefix: "hosts..linux"}.Validate())
+	require.Error(t, Naming{HostnameMode: "dns"}.Validate())
+	require.Error(t, Naming{Hostname: "web-1", HostnameMode: "fqdn"}.Validate())
+}
+
+func TestResolveHostname(t *testing.T) {
+	defer func() { execCommand = exec.Command }()
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		return exec.Command("echo", "web-1.example.com")
+	}
+
+	hostname, err := Naming{HostnameMode: "fqdn"}.ResolveHostname()
+	require.NoError(t, err)
+	require.Equal(t, "web-1.example.com", hostname)
+
+	hostname, err = Naming{Hostname: "override"}.ResolveHostname()
+	require.NoError(t, err)
+	require.Equal(t, "override", hostname)
+
+	hostname, err = Naming{}.ResolveHostname()
+	require.NoError(t, err)
+	require.Equal(t, "", hostname)
+}
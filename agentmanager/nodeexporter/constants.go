@@ -0,0 +1,53 @@
+package nodeexporter
+
+import (
+	"maps"
+
+	nodePipes "github.com/hostedgraphite/hg-cli/agentmanager/nodeexporter/pipes"
+	otelPipes "github.com/hostedgraphite/hg-cli/agentmanager/otel/pipes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// ListenAddress is where node_exporter serves metrics, the collector bridge
+// scrapes it from here.
+const ListenAddress = "localhost:9100"
+
+// The linux start and restart hints depend on the init system, see
+// GetServiceSettings.
+var ServiceDetails = map[string]map[string]string{
+	"linux": {
+		"configPath": "/etc/otelcol-contrib/config.yaml",
+		"receiver":   "prometheus (node_exporter " + ListenAddress + ")",
+		"exporter":   "carbon",
+	},
+	"darwin": {
+		"configPath":  "/usr/local/etc/otelcol-contrib/config.yaml",
+		"startHint":   "launchctl start com.node-exporter-agent && launchctl start com.otelcol-contrib-agent",
+		"restartHint": "launchctl stop/start com.node-exporter-agent com.otelcol-contrib-agent",
+		"receiver":    "prometheus (node_exporter " + ListenAddress + ")",
+		"exporter":    "carbon",
+	},
+}
+
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
+	if sysInfo.Os != "linux" {
+		return ServiceDetails[sysInfo.Os]
+	}
+
+	settings := maps.Clone(ServiceDetails["linux"])
+	nodeStart, nodeRestart, nodeEnable := nodePipes.LinuxHints(sysInfo, ListenAddress)
+	otelStart, otelRestart, otelEnable := otelPipes.LinuxHints(sysInfo)
+	// Without an init system both are run by hand, node_exporter in the
+	// background.
+	sep := " && "
+	if service.Init(sysInfo) == sysinfo.InitNone {
+		sep = " & "
+	}
+	settings["startHint"] = nodeStart + sep + otelStart
+	settings["restartHint"] = nodeRestart + sep + otelRestart
+	if nodeEnable != "" {
+		settings["enableHint"] = nodeEnable + " && " + otelEnable
+	}
+	return settings
+}
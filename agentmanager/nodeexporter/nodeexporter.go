@@ -0,0 +1,51 @@
+package nodeexporter
+
+import (
+	"maps"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// NodeExporter installs Prometheus node_exporter alongside an otel collector
+// that scrapes it and forwards the metrics to Hosted Graphite, keeping the
+// node_exporter metric names.
+type NodeExporter struct {
+	sysinfo         sysinfo.SysInfo
+	options         map[string]interface{}
+	serviceSettings map[string]string
+}
+
+func NewNodeExporterAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *NodeExporter {
+	agent := &NodeExporter{
+		sysinfo:         sysInfo,
+		options:         options,
+		serviceSettings: utils.StagedSettings(sysInfo, GetServiceSettings(sysInfo), ""),
+	}
+	return agent
+}
+
+// bridge is the collector that scrapes node_exporter. The version option is
+// node_exporter's, the collector always gets its latest. Installs default the
+// prefix to "node", updates keep whatever prefix the config already has.
+func (n *NodeExporter) bridge(install bool) *otel.Otel {
+	return otel.NewOtelAgent(bridgeOptions(n.options, install), n.sysinfo)
+}
+
+func bridgeOptions(options map[string]interface{}, install bool) map[string]interface{} {
+	bridge := maps.Clone(options)
+	if bridge == nil {
+		bridge = map[string]interface{}{}
+	}
+
+	delete(bridge, "version")
+	bridge["hostmetrics"] = false
+	bridge["receivers"] = []string{"prometheus"}
+	bridge["endpoints"] = map[string]string{"prometheus": ListenAddress}
+	if prefix, _ := bridge["prefix"].(string); install && prefix == "" {
+		bridge["prefix"] = "node"
+	}
+
+	return bridge
+}
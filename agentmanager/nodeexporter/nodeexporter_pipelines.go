@@ -0,0 +1,84 @@
+package nodeexporter
+
+import (
+	_ "embed"
+	"fmt"
+
+	nodePipes "github.com/hostedgraphite/hg-cli/agentmanager/nodeexporter/pipes"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+//go:embed com.node-exporter-agent.plist
+var plistFile []byte
+
+func (n *NodeExporter) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = n.sysinfo
+	var pipes []*pipeline.Pipe
+
+	bridge := n.bridge(true)
+	if bridge.IsInstalled() {
+		return nil, fmt.Errorf(
+			"otelcol-contrib is already installed, scrape node_exporter from it instead: " +
+				"hg-cli agent install otel --receivers prometheus --receiver-endpoint prometheus=" + ListenAddress,
+		)
+	}
+
+	version, _ := n.options["version"].(string)
+
+	switch sysInfo.Os {
+	case "linux":
+		pipes = nodePipes.LinuxInstallPipes(sysInfo, version, ListenAddress)
+	case "darwin":
+		pipes = nodePipes.DarwinInstallPipes(sysInfo, version, string(plistFile))
+	default:
+		return nil, fmt.Errorf("node_exporter is only supported on linux and darwin")
+	}
+
+	bridgePipeline, err := bridge.InstallPipeline(updates)
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, bridgePipeline.Pipes...)
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Node Exporter (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (n *NodeExporter) UninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = n.sysinfo
+	var pipes []*pipeline.Pipe
+
+	switch sysInfo.Os {
+	case "linux":
+		pipes = nodePipes.LinuxUninstallPipes(sysInfo)
+	case "darwin":
+		pipes = nodePipes.DarwinUninstallPipes()
+	default:
+		return nil, fmt.Errorf("node_exporter is only supported on linux and darwin")
+	}
+
+	bridgePipeline, err := n.bridge(false).UninstallPipeline(updates)
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, bridgePipeline.Pipes...)
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Node Exporter (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+// UpdateApiKeyPipeline only touches the collector, node_exporter itself has
+// no knowledge of Hosted Graphite.
+func (n *NodeExporter) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	return n.bridge(false).UpdateApiKeyPipeline(updates)
+}
+
+func (n *NodeExporter) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	return n.bridge(true).ConfigurePipeline(updates)
+}
+
+func (n *NodeExporter) IsInstalled() bool {
+	return nodePipes.IsInstalled(n.sysinfo) && n.bridge(false).IsInstalled()
+}
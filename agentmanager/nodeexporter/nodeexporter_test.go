@@ -0,0 +1,96 @@
+package nodeexporter
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridgeOptions(t *testing.T) {
+	options := map[string]interface{}{"apikey": "key", "version": "1.9.1", "hostmetrics": true}
+
+	bridge := bridgeOptions(options, true)
+	require.Equal(t, false, bridge["hostmetrics"])
+	require.Equal(t, []string{"prometheus"}, bridge["receivers"])
+	require.Equal(t, map[string]string{"prometheus": ListenAddress}, bridge["endpoints"])
+	require.Equal(t, "node", bridge["prefix"])
+	require.Equal(t, "key", bridge["apikey"])
+	require.NotContains(t, bridge, "version")
+
+	// The agent's own options are left alone.
+	require.Equal(t, true, options["hostmetrics"])
+	require.Equal(t, "1.9.1", options["version"])
+}
+
+func TestBridgeOptionsPrefix(t *testing.T) {
+	require.Equal(t, "custom", bridgeOptions(map[string]interface{}{"prefix": "custom"}, true)["prefix"])
+	require.NotContains(t, bridgeOptions(nil, false), "prefix")
+}
+
+func pipeNames(t *testing.T, sysInfo sysinfo.SysInfo, install bool) ([]string, []string) {
+	agent := NewNodeExporterAgent(map[string]interface{}{"apikey": "key", "version": "1.9.1"}, sysInfo)
+	p, err := agent.UninstallPipeline(nil)
+	if install {
+		p, err = agent.InstallPipeline(nil)
+	}
+	require.NoError(t, err)
+
+	var names, cmds []string
+	for _, pipe := range p.Pipes {
+		names = append(names, pipe.Name)
+		cmds = append(cmds, filepath.Base(pipe.Cmd.Path))
+	}
+	return names, cmds
+}
+
+func TestInstallPipelineInitSystems(t *testing.T) {
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64", PkgMngr: "apt", InitSystem: sysinfo.InitSystemd}
+	names, cmds := pipeNames(t, host, true)
+	require.Contains(t, names, "Writing Node Exporter Systemd Service")
+	require.Contains(t, names, "Restarting Node Exporter Service")
+	require.Contains(t, cmds, "systemctl")
+
+	for _, init := range []string{sysinfo.InitOpenRC, sysinfo.InitRunit} {
+		host.InitSystem = init
+		host.PkgMngr = ""
+		names, cmds := pipeNames(t, host, true)
+		require.NotContains(t, cmds, "systemctl", init)
+		require.Contains(t, names, "Restarting Node Exporter Service", init)
+		require.False(t, slices.Contains(names, "Writing Node Exporter Systemd Service"), init)
+	}
+
+	host.InitSystem = sysinfo.InitNone
+	names, cmds = pipeNames(t, host, true)
+	require.NotContains(t, cmds, "systemctl")
+	for _, name := range names {
+		require.NotContains(t, name, "Node Exporter Service")
+	}
+}
+
+func TestUninstallPipelineInitSystems(t *testing.T) {
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64", PkgMngr: "", InitSystem: sysinfo.InitRunit}
+	names, cmds := pipeNames(t, host, false)
+	require.NotContains(t, cmds, "systemctl")
+	require.Contains(t, names, "Stopping Node Exporter Service")
+	require.Contains(t, names, "Removing Node Exporter Service")
+	require.Contains(t, names, "Removing Node Exporter Binary")
+
+	host.InitSystem = sysinfo.InitNone
+	names, _ = pipeNames(t, host, false)
+	require.NotContains(t, names, "Stopping Node Exporter Service")
+	require.NotContains(t, names, "Removing Node Exporter Service")
+	require.Contains(t, names, "Removing Node Exporter Binary")
+}
+
+func TestServiceSettingsHints(t *testing.T) {
+	settings := GetServiceSettings(sysinfo.SysInfo{Os: "linux", InitSystem: sysinfo.InitOpenRC})
+	require.Equal(t, "sudo rc-service node_exporter restart && sudo rc-service otelcol-contrib restart", settings["restartHint"])
+	require.Equal(t, "sudo rc-update add node_exporter default && sudo rc-update add otelcol-contrib default", settings["enableHint"])
+
+	settings = GetServiceSettings(sysinfo.SysInfo{Os: "linux", InitSystem: sysinfo.InitNone})
+	require.Equal(t, "/usr/local/bin/node_exporter --web.listen-address="+ListenAddress+" & /usr/bin/otelcol-contrib --config=/etc/otelcol-contrib/config.yaml", settings["startHint"])
+	require.NotContains(t, settings, "enableHint")
+}
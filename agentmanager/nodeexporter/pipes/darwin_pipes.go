@@ -0,0 +1,60 @@
+package pipes
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// Like the otel collector, node_exporter runs as a launch agent of the user
+// that ran sudo.
+func plistPath() string {
+	return fmt.Sprintf("/Users/%s/Library/LaunchAgents/com.node-exporter-agent.plist", os.Getenv("SUDO_USER"))
+}
+
+func DarwinInstallPipes(sysInfo sysinfo.SysInfo, version, plistFile string) []*pipeline.Pipe {
+	file, url := release(sysInfo, "darwin", version)
+	origUser := os.Getenv("SUDO_USER")
+
+	pipes := downloadPipes(sysInfo, file, url)
+	pipes = append(pipes, []*pipeline.Pipe{
+		{
+			Name: "Creating Node Exporter Plist File",
+			Cmd:  exec.Command("bash", "-c", fmt.Sprintf("echo '%s' > %s", plistFile, plistPath())),
+		},
+		{
+			Name: "Loading Node Exporter Agent",
+			Cmd:  exec.Command("sudo", "-u", origUser, "launchctl", "load", "-w", plistPath()),
+		},
+	}...)
+
+	return pipes
+}
+
+func DarwinUninstallPipes() []*pipeline.Pipe {
+	origUser := os.Getenv("SUDO_USER")
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Unloading Node Exporter Agent",
+			Cmd:  exec.Command("sudo", "-u", origUser, "launchctl", "unload", plistPath()),
+		},
+		{
+			Name: "Removing Node Exporter Plist File",
+			Cmd:  exec.Command("rm", "-f", plistPath()),
+		},
+		{
+			Name: "Removing Node Exporter Binary",
+			Cmd:  exec.Command("rm", "-f", binPath),
+		},
+	}
+	return pipes
+}
+
+func IsInstalled(sysInfo sysinfo.SysInfo) bool {
+	info, err := os.Stat(sysInfo.Path(binPath))
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,121 @@
+package pipes
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+const binPath = "/usr/local/bin/node_exporter"
+
+var archNames = map[string]string{
+	"amd64":  "amd64",
+	"386":    "386",
+	"arm64":  "arm64",
+	"armv7l": "armv7",
+}
+
+// release returns the release archive name, without extension, and its url.
+func release(sysInfo sysinfo.SysInfo, os, version string) (string, string) {
+	latest := utils.ReleaseTag("prometheus", "node_exporter", version, "v1.9.1")
+	arch := sysInfo.Arch
+	if name, ok := archNames[arch]; ok {
+		arch = name
+	}
+	file := fmt.Sprintf("node_exporter-%s.%s-%s", latest[1:], os, arch)
+	url := sysInfo.DownloadURL("https://github.com/prometheus/node_exporter/releases/download/" + latest + "/" + file + ".tar.gz")
+
+	return file, url
+}
+
+// downloadPipes install the binary to binPath, under the root of staged
+// installs.
+func downloadPipes(sysInfo sysinfo.SysInfo, file, url string) []*pipeline.Pipe {
+	tmpDir := "/tmp/hg-cli/"
+	bin := sysInfo.Path(binPath)
+	tarPath := tmpDir + file + ".tar.gz"
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			Name: "Downloading Node Exporter to " + tmpDir,
+			Cmd:  exec.Command("curl", "--tlsv1.2", "-fL", "-o", tarPath, url),
+		},
+		{
+			Name: "Extracting Node Exporter archive",
+			Cmd:  exec.Command("tar", "-xzf", tarPath, "-C", tmpDir),
+		},
+		{
+			Name: "Moving Node Exporter to /usr/local/bin",
+			Cmd:  exec.Command("sh", "-c", "mkdir -p "+pipeline.ShellQuote(path.Dir(bin))+" && mv "+tmpDir+file+"/node_exporter "+pipeline.ShellQuote(bin)),
+		},
+		{
+			Name: "Cleaning up Temporary Directory",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}
+	return pipes
+}
+
+// linuxService runs node_exporter under the init system, serving metrics on
+// listenAddress.
+func linuxService(root, listenAddress string) service.Service {
+	return service.Service{
+		Name:        "node_exporter",
+		DisplayName: "Node Exporter",
+		Description: "Prometheus Node Exporter",
+		Command:     binPath,
+		Args:        []string{"--web.listen-address=" + listenAddress},
+		User:        "node_exporter",
+		Root:        root,
+	}
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo, listenAddress string) (string, string, string) {
+	return linuxService("", listenAddress).Hints(service.Init(sysInfo))
+}
+
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, version, listenAddress string) []*pipeline.Pipe {
+	file, url := release(sysInfo, "linux", version)
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo.Root, listenAddress)
+
+	pipes := append(downloadPipes(sysInfo, file, url), &pipeline.Pipe{
+		Name: "Creating node_exporter User",
+		Cmd:  utils.UserAddCmd(sysInfo.Root, "node_exporter", "--system", "--no-create-home", "--shell", "/bin/false"),
+	})
+	pipes = append(pipes, svc.InstallPipes(init)...)
+
+	if service.Managed(sysInfo) {
+		pipes = append(pipes, svc.RestartPipes(init)...)
+	}
+
+	return pipes
+}
+
+func LinuxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo.Root, "")
+
+	if service.Managed(sysInfo) {
+		pipes = append(pipes, svc.StopPipes(init)...)
+	}
+
+	pipes = append(pipes, svc.RemovePipes(init)...)
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Removing Node Exporter Binary",
+		Cmd:  exec.Command("rm", "-f", sysInfo.Path(binPath)),
+	})
+	return pipes
+}
@@ -0,0 +1,33 @@
+package nodeexporter
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:        "node_exporter",
+		Aliases:     []string{"node-exporter", "nodeexporter"},
+		DisplayName: "Node Exporter",
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewNodeExporterAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			return &formatters.OtelContribSummary{
+				ActionSummary: base,
+				Receiver:      ServiceDetails["linux"]["receiver"],
+				Exporter:      "carbon",
+			}
+		},
+		// The collector forwarding the metrics is otel's.
+		Validate: func(options map[string]interface{}, os string) error {
+			return otel.ValidateEndpoint(endpoint.New(options))
+		},
+		Fields: newViews,
+	})
+}
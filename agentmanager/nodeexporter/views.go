@@ -0,0 +1,101 @@
+package nodeexporter
+
+import (
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	header           string
+	path             string
+	confirmUninstall bool
+	output           forms.Output
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header: styles.MetricfireLogo,
+		apikey: forms.DefaultApiKey,
+	}
+}
+
+func (n *views) InstallView() (*huh.Group, error) {
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(n.header).
+			Description("Installs Prometheus node_exporter and an OpenTelemetry collector that forwards its metrics to Hosted Graphite."),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(n.apikey)
+			}).
+			Value(&n.apikey).
+			EchoMode(huh.EchoModePassword),
+	)
+
+	return installGroup, nil
+}
+
+// InstallOptions has nothing to add, the scrape target is fixed.
+func (n *views) InstallOptions(form *huh.Form, options map[string]interface{}) {}
+
+func (n *views) OutputView() (*huh.Group, error) {
+	return forms.OutputGroup(&n.output, false, endpoint.Plaintext), nil
+}
+
+func (n *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(n.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall Node Exporter?").
+			Description("This will remove node_exporter and the collector forwarding its metrics").
+			Value(&n.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+
+func (n *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(n.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(n.apikey)
+			}).
+			Value(&n.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the OpenTelemetry yaml file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&n.path).
+			Validate(func(s string) error {
+				if s == "" {
+					s = defaultPath
+				}
+				return otel.ValidateFilePath(s)
+			}),
+	)
+
+	return updateGroup, nil
+}
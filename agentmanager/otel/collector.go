@@ -0,0 +1,301 @@
+package otel
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var HostmetricsScrapers = []string{
+	"cpu",
+	"memory",
+	"load",
+	"filesystem",
+	"disk",
+	"paging",
+	"network",
+	"processes",
+	"process",
+	"system",
+}
+
+const DefaultCollectionInterval = "30s"
+
+// OptionalReceivers are the receivers that can be added alongside hostmetrics,
+// mapped to the endpoint (or file pattern for filelog) used when none is given.
+var OptionalReceivers = map[string]string{
+	"prometheus":   "localhost:9090",
+	"filelog":      "/var/log/*.log",
+	"docker_stats": "unix:///var/run/docker.sock",
+	"kubeletstats": "${env:K8S_NODE_NAME}:10250",
+	// k8s_cluster talks to the api server through its service account.
+	"k8s_cluster": "",
+	"nginx":       "http://localhost:80/status",
+	"postgresql":  "localhost:5432",
+	"redis":       "localhost:6379",
+}
+
+// OptionalReceiverNames returns the optional receivers in a stable order.
+func OptionalReceiverNames() []string {
+	var names []string
+	for name := range OptionalReceivers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type CollectorConfig struct {
+	// Hostmetrics adds the hostmetrics receiver, it's on unless the
+	// "hostmetrics" option is false, e.g. when bridging another exporter.
+	Hostmetrics bool
+	Scrapers    []string
+	Interval    string
+	Receivers   []string
+	Endpoints   map[string]string
+	// RootPath is where the host filesystem is mounted when the collector
+	// runs in a container.
+	RootPath string
+}
+
+// NewCollectorConfig builds the collector selection from the agent options,
+// falling back to every hostmetrics scraper at the default interval.
+func NewCollectorConfig(options map[string]interface{}) CollectorConfig {
+	config := CollectorConfig{
+		Hostmetrics: true,
+		Scrapers:    HostmetricsScrapers,
+		Interval:    DefaultCollectionInterval,
+		Endpoints:   map[string]string{},
+	}
+
+	if hostmetrics, ok := options["hostmetrics"].(bool); ok {
+		config.Hostmetrics = hostmetrics
+	}
+	if scrapers, ok := options["scrapers"].([]string); ok && len(scrapers) > 0 {
+		config.Scrapers = scrapers
+	}
+	if interval, ok := options["interval"].(string); ok && interval != "" {
+		config.Interval = interval
+	}
+	if receivers, ok := options["receivers"].([]string); ok {
+		config.Receivers = receivers
+	}
+	if endpoints, ok := options["endpoints"].(map[string]string); ok {
+		for name, endpoint := range endpoints {
+			config.Endpoints[name] = endpoint
+		}
+	}
+
+	return config
+}
+
+func (c CollectorConfig) Validate() error {
+	if c.Hostmetrics {
+		if len(c.Scrapers) == 0 {
+			return fmt.Errorf("at least one hostmetrics scraper is required")
+		}
+		for _, scraper := range c.Scrapers {
+			if !slices.Contains(HostmetricsScrapers, scraper) {
+				return fmt.Errorf("unknown hostmetrics scraper: %s", scraper)
+			}
+		}
+	} else if len(c.Receivers) == 0 {
+		return fmt.Errorf("at least one receiver is required without hostmetrics")
+	}
+
+	if _, err := time.ParseDuration(c.Interval); err != nil {
+		return fmt.Errorf("invalid collection interval %q: %v", c.Interval, err)
+	}
+
+	for _, receiver := range c.Receivers {
+		if _, ok := OptionalReceivers[receiver]; !ok {
+			return fmt.Errorf("unknown receiver: %s (available: %s)", receiver, strings.Join(OptionalReceiverNames(), ", "))
+		}
+	}
+	for name := range c.Endpoints {
+		if !slices.Contains(c.Receivers, name) {
+			return fmt.Errorf("endpoint given for receiver %s which was not selected", name)
+		}
+	}
+
+	return nil
+}
+
+// ReceiverNames lists the receivers in the metrics pipeline, used for summaries.
+func (c CollectorConfig) ReceiverNames() []string {
+	if !c.Hostmetrics {
+		return slices.Clone(c.Receivers)
+	}
+	return append([]string{"hostmetrics"}, c.Receivers...)
+}
+
+func (c CollectorConfig) endpoint(receiver string) string {
+	if endpoint, ok := c.Endpoints[receiver]; ok && endpoint != "" {
+		return endpoint
+	}
+	return OptionalReceivers[receiver]
+}
+
+type collectorYaml struct {
+	Receivers  map[string]interface{} `yaml:"receivers"`
+	Connectors map[string]interface{} `yaml:"connectors,omitempty"`
+	Processors map[string]interface{} `yaml:"processors"`
+	Exporters  map[string]interface{} `yaml:"exporters"`
+	Service    serviceYaml            `yaml:"service"`
+}
+
+type serviceYaml struct {
+	Pipelines map[string]pipelineYaml `yaml:"pipelines"`
+}
+
+type pipelineYaml struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors,omitempty"`
+	Exporters  []string `yaml:"exporters"`
+}
+
+// Render generates the otelcol-contrib config.yaml for the selection. Every
+// metric is renamed under the metric prefix, tagged with the host and sent to
+// the carbon endpoint.
+func (c CollectorConfig) Render(metricPrefix, hostname, carbonEndpoint string) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := collectorYaml{
+		Receivers: map[string]interface{}{},
+		Processors: map[string]interface{}{
+			"batch": map[string]interface{}{},
+			"metricstransform": map[string]interface{}{
+				"transforms": []interface{}{
+					map[string]interface{}{
+						"include":    ".*",
+						"match_type": "regexp",
+						"action":     "update",
+						// $$ escapes the collector's env expansion, leaving the $0 regex group.
+						"new_name": metricPrefix + ".$$0",
+						"operations": []interface{}{
+							map[string]interface{}{
+								"action":    "add_label",
+								"new_label": "host",
+								"new_value": hostname,
+							},
+						},
+					},
+				},
+			},
+		},
+		Exporters: map[string]interface{}{
+			"carbon": map[string]interface{}{
+				"endpoint": carbonEndpoint,
+				"timeout":  "10s",
+			},
+		},
+		Service: serviceYaml{
+			Pipelines: map[string]pipelineYaml{},
+		},
+	}
+
+	var metricsReceivers []string
+	if c.Hostmetrics {
+		scrapers := map[string]interface{}{}
+		for _, scraper := range c.Scrapers {
+			scrapers[scraper] = map[string]interface{}{}
+		}
+		hostmetrics := map[string]interface{}{
+			"collection_interval": c.Interval,
+			"scrapers":            scrapers,
+		}
+		if c.RootPath != "" {
+			hostmetrics["root_path"] = c.RootPath
+		}
+		config.Receivers["hostmetrics"] = hostmetrics
+		metricsReceivers = append(metricsReceivers, "hostmetrics")
+	}
+
+	for _, receiver := range c.Receivers {
+		config.Receivers[receiver] = c.receiverConfig(receiver)
+		if receiver == "filelog" {
+			// Log lines are turned into metrics by the count connector,
+			// which sits between a logs pipeline and the metrics pipeline.
+			config.Connectors = map[string]interface{}{"count": map[string]interface{}{}}
+			config.Service.Pipelines["logs"] = pipelineYaml{
+				Receivers: []string{"filelog"},
+				Exporters: []string{"count"},
+			}
+			metricsReceivers = append(metricsReceivers, "count")
+			continue
+		}
+		metricsReceivers = append(metricsReceivers, receiver)
+	}
+
+	config.Service.Pipelines["metrics"] = pipelineYaml{
+		Receivers:  metricsReceivers,
+		Processors: []string{"batch", "metricstransform"},
+		Exporters:  []string{"carbon"},
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c CollectorConfig) receiverConfig(receiver string) map[string]interface{} {
+	endpoint := c.endpoint(receiver)
+
+	switch receiver {
+	case "prometheus":
+		return map[string]interface{}{
+			"config": map[string]interface{}{
+				"scrape_configs": []interface{}{
+					map[string]interface{}{
+						"job_name":        "hg-cli",
+						"scrape_interval": c.Interval,
+						"static_configs": []interface{}{
+							map[string]interface{}{"targets": []string{endpoint}},
+						},
+					},
+				},
+			},
+		}
+	case "filelog":
+		return map[string]interface{}{
+			"include":  strings.Split(endpoint, ","),
+			"start_at": "end",
+		}
+	case "kubeletstats":
+		return map[string]interface{}{
+			"collection_interval":  c.Interval,
+			"auth_type":            "serviceAccount",
+			"endpoint":             endpoint,
+			"insecure_skip_verify": true,
+		}
+	case "k8s_cluster":
+		return map[string]interface{}{
+			"collection_interval": c.Interval,
+			"auth_type":           "serviceAccount",
+		}
+	case "postgresql":
+		return map[string]interface{}{
+			"collection_interval": c.Interval,
+			"endpoint":            endpoint,
+			"username":            "${env:POSTGRESQL_USERNAME}",
+			"password":            "${env:POSTGRESQL_PASSWORD}",
+			"tls":                 map[string]interface{}{"insecure": true},
+		}
+	default:
+		return map[string]interface{}{
+			"collection_interval": c.Interval,
+			"endpoint":            endpoint,
+		}
+	}
+}
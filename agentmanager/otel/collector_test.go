@@ -0,0 +1,95 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDefaultCollectorConfig(t *testing.T) {
+	config := NewCollectorConfig(nil)
+	require.Equal(t, HostmetricsScrapers, config.Scrapers)
+	require.Equal(t, DefaultCollectionInterval, config.Interval)
+
+	rendered, err := config.Render("my-key.opentel", "web-1", "carbon.hostedgraphite.com:2003")
+	require.NoError(t, err)
+
+	var parsed map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(rendered, &parsed))
+	require.Contains(t, string(rendered), "new_name: my-key.opentel.$$0")
+	require.Contains(t, string(rendered), "new_value: web-1")
+	require.NotContains(t, parsed, "connectors")
+	require.NotContains(t, string(rendered), "root_path")
+}
+
+func TestCollectorConfigRootPath(t *testing.T) {
+	config := NewCollectorConfig(nil)
+	config.RootPath = "/hostfs"
+
+	rendered, err := config.Render("my-key.opentel", "web-1", "carbon.hostedgraphite.com:2003")
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "root_path: /hostfs")
+}
+
+func TestCollectorConfigWithReceivers(t *testing.T) {
+	config := NewCollectorConfig(map[string]interface{}{
+		"scrapers":  []string{"cpu", "memory"},
+		"interval":  "1m",
+		"receivers": []string{"nginx", "filelog"},
+		"endpoints": map[string]string{"nginx": "http://localhost:8080/status"},
+	})
+
+	rendered, err := config.Render("my-key.opentel", "web-1", "carbon.hostedgraphite.com:2003")
+	require.NoError(t, err)
+
+	var parsed struct {
+		Receivers map[string]map[string]interface{} `yaml:"receivers"`
+		Service   struct {
+			Pipelines map[string]struct {
+				Receivers []string `yaml:"receivers"`
+				Exporters []string `yaml:"exporters"`
+			} `yaml:"pipelines"`
+		} `yaml:"service"`
+	}
+	require.NoError(t, yaml.Unmarshal(rendered, &parsed))
+
+	require.Equal(t, "http://localhost:8080/status", parsed.Receivers["nginx"]["endpoint"])
+	require.Len(t, parsed.Receivers["hostmetrics"]["scrapers"], 2)
+	require.Equal(t, []string{"hostmetrics", "nginx", "count"}, parsed.Service.Pipelines["metrics"].Receivers)
+	require.Equal(t, []string{"count"}, parsed.Service.Pipelines["logs"].Exporters)
+}
+
+func TestCollectorConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{"Unknown Scraper", map[string]interface{}{"scrapers": []string{"gpu"}}},
+		{"Bad Interval", map[string]interface{}{"interval": "often"}},
+		{"Unknown Receiver", map[string]interface{}{"receivers": []string{"mongodb"}}},
+		{"Endpoint Without Receiver", map[string]interface{}{"endpoints": map[string]string{"redis": "localhost:6380"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Error(t, NewCollectorConfig(test.options).Validate())
+		})
+	}
+}
+
+func TestCollectorConfigWithoutHostmetrics(t *testing.T) {
+	config := NewCollectorConfig(map[string]interface{}{
+		"hostmetrics": false,
+		"receivers":   []string{"prometheus"},
+		"endpoints":   map[string]string{"prometheus": "localhost:9100"},
+	})
+	require.Equal(t, []string{"prometheus"}, config.ReceiverNames())
+
+	rendered, err := config.Render("my-key.node", "web-1", "carbon.hostedgraphite.com:2003")
+	require.NoError(t, err)
+	require.NotContains(t, string(rendered), "hostmetrics")
+	require.Contains(t, string(rendered), "localhost:9100")
+
+	require.Error(t, NewCollectorConfig(map[string]interface{}{"hostmetrics": false}).Validate())
+}
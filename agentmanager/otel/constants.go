@@ -6,13 +6,13 @@ var DefaultConfig = []string{
 }
 
 var ServiceDetails = map[string]map[string]map[string]string{
+	// The linux start and restart hints depend on the init system, see
+	// GetServiceSettings.
 	"linux": {
 		"default": {
-			"configPath":  "/etc/otelcol-contrib/config.yaml",
-			"startHint":   "sudo systemctl start otelcol-contrib",
-			"restartHint": "sudo systemctl restart otelcol-contrib",
-			"receiver":    "hostmetrics",
-			"exporter":    "carbon",
+			"configPath": "/etc/otelcol-contrib/config.yaml",
+			"receiver":   "hostmetrics",
+			"exporter":   "carbon",
 		},
 	},
 	"windows": {
@@ -0,0 +1,89 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/container"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+func (o *Otel) container() container.Container {
+	version, _ := o.options["version"].(string)
+	tag := "latest"
+	if version != "" {
+		tag = strings.TrimPrefix(version, "v")
+	}
+
+	c := container.Container{
+		Name:    "hg-cli-otelcol-contrib",
+		Image:   "otel/opentelemetry-collector-contrib:" + tag,
+		EnvFile: path.Join(container.ConfigDir("otelcol-contrib"), "otelcol-contrib.env"),
+		Mounts: append([]container.Mount{
+			{Source: o.containerConfigPath(), Target: "/etc/otelcol-contrib/config.yaml", ReadOnly: true},
+		}, container.HostMounts()...),
+	}
+	// The image runs as an unprivileged user, which can't read the docker socket.
+	if slices.Contains(o.collector.Receivers, "docker_stats") {
+		c.User = "0"
+	}
+	return c
+}
+
+func (o *Otel) containerConfigPath() string {
+	return path.Join(container.ConfigDir("otelcol-contrib"), "config.yaml")
+}
+
+func (o *Otel) ContainerSettings() map[string]string {
+	composeFile, _ := o.options["composeFile"].(string)
+	return container.Settings(o.container(), o.containerConfigPath(), composeFile)
+}
+
+// ContainerPipeline writes the collector config for the host and starts the
+// otelcol-contrib container with it.
+func (o *Otel) ContainerPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = o.sysinfo
+
+	if err := o.collector.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateEndpoint(o.endpoint); err != nil {
+		return nil, err
+	}
+	if err := container.ValidateMode(container.ModeDocker, sysInfo.Os); err != nil {
+		return nil, err
+	}
+	// The container gets the api key from its environment file, the config
+	// references it.
+	o.keyEnvFile = true
+
+	c := o.container()
+	configPath := o.containerConfigPath()
+	collector := o.collector
+	collector.RootPath = container.HostRoot
+
+	pipes := []*pipeline.Pipe{
+		container.PullPipe(c.Image),
+		container.EnvFilePipe(c, map[string]string{service.ApiKeyVar: o.apikey}),
+		pipeline.NewPipe("Writing Otel config.yaml", exec.Command("mkdir", "-p", path.Dir(configPath))).PostRun(
+			func(ctx context.Context) error {
+				return writeCollectorConfig(collector, o.naming, o.endpoint, o.configKey(), configPath)
+			},
+		),
+	}
+
+	composeFile, _ := o.options["composeFile"].(string)
+	pipes = append(pipes, container.Pipes(c, composeFile)...)
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Deploying Otel Container (%s-docker)", sysInfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
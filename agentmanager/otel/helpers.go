@@ -2,11 +2,16 @@ package otel
 
 import (
 	"fmt"
+	"maps"
 	"os"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/otel/pipes"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func GetServiceSettings(os, arch, pkgmngr string) map[string]string {
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
 	var settings map[string]string
+	os, arch, pkgmngr := sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr
 
 	switch os {
 	case "windows":
@@ -15,7 +20,8 @@ func GetServiceSettings(os, arch, pkgmngr string) map[string]string {
 		if pkgmngr == "brew" {
 			settings = ServiceDetails[os][pkgmngr]
 		} else {
-			settings = ServiceDetails[os]["default"]
+			settings = maps.Clone(ServiceDetails[os]["default"])
+			settings["startHint"], settings["restartHint"], settings["enableHint"] = pipes.LinuxHints(sysInfo)
 		}
 	case "darwin":
 		switch arch {
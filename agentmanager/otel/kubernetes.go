@@ -0,0 +1,86 @@
+package otel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/kubernetes"
+)
+
+func (o *Otel) kubernetesConfig(collector CollectorConfig) ([]byte, error) {
+	prefix := o.naming.MetricPrefix("${env:"+kubernetes.ApiKeyEnv+"}", "opentel")
+	hostname := "${env:" + kubernetes.NodeNameEnv + "}"
+
+	config, err := collector.Render(prefix, hostname, o.endpoint.HostPort())
+	if err != nil {
+		return nil, fmt.Errorf("error generating config: %v", err)
+	}
+	return config, nil
+}
+
+// KubernetesWorkloads runs the collector on each node reading the node's
+// filesystem, and k8s_cluster once for the cluster when it was asked for.
+func (o *Otel) KubernetesWorkloads() (kubernetes.Workload, *kubernetes.Workload, error) {
+	var node kubernetes.Workload
+
+	if err := o.naming.Validate(); err != nil {
+		return node, nil, err
+	}
+	if err := ValidateEndpoint(o.endpoint); err != nil {
+		return node, nil, err
+	}
+
+	version, _ := o.options["version"].(string)
+	image := "otel/opentelemetry-collector-contrib:latest"
+	if version != "" {
+		image = "otel/opentelemetry-collector-contrib:" + strings.TrimPrefix(version, "v")
+	}
+
+	collector := o.collector
+	collector.RootPath = kubernetes.HostRoot
+	collector.Receivers = slices.DeleteFunc(slices.Clone(collector.Receivers), func(r string) bool {
+		return r == "k8s_cluster"
+	})
+	if kubelet, _ := o.options["kubeletstats"].(bool); kubelet && !slices.Contains(collector.Receivers, "kubeletstats") {
+		collector.Receivers = append(collector.Receivers, "kubeletstats")
+	}
+
+	config, err := o.kubernetesConfig(collector)
+	if err != nil {
+		return node, nil, err
+	}
+
+	node = kubernetes.Workload{
+		Name:       "hg-cli-otelcol-contrib",
+		Image:      image,
+		ConfigDir:  "/etc/otelcol-contrib",
+		ConfigFile: "config.yaml",
+		Config:     config,
+		// Reading every process under the node's /proc needs root.
+		RunAsRoot: true,
+	}
+
+	inventory, _ := o.options["kubeInventory"].(bool)
+	if !inventory && !slices.Contains(o.collector.Receivers, "k8s_cluster") {
+		return node, nil, nil
+	}
+
+	clusterConfig, err := o.kubernetesConfig(CollectorConfig{
+		Interval:  collector.Interval,
+		Receivers: []string{"k8s_cluster"},
+		Endpoints: map[string]string{},
+	})
+	if err != nil {
+		return node, nil, err
+	}
+	cluster := &kubernetes.Workload{
+		Name:       "hg-cli-otelcol-contrib-cluster",
+		Image:      image,
+		ConfigDir:  "/etc/otelcol-contrib",
+		ConfigFile: "config.yaml",
+		Config:     clusterConfig,
+	}
+
+	return node, cluster, nil
+}
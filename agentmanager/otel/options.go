@@ -0,0 +1,44 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/spf13/pflag"
+)
+
+// Spec are the otel fields of an `hg-cli apply` spec.
+type Spec struct {
+	Scrapers          []string          `yaml:"scrapers"`
+	Interval          string            `yaml:"interval"`
+	Receivers         []string          `yaml:"receivers"`
+	ReceiverEndpoints map[string]string `yaml:"receiverEndpoints"`
+}
+
+func (s *Spec) Options(options map[string]interface{}) {
+	options["scrapers"] = s.Scrapers
+	options["interval"] = s.Interval
+	options["receivers"] = s.Receivers
+	options["endpoints"] = s.ReceiverEndpoints
+}
+
+func addFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("scrapers", []string{}, "Otel hostmetrics scrapers to enable, defaults to all (comma separated)")
+	flags.String("collection-interval", DefaultCollectionInterval, "Collection interval, e.g. 30s or 1m")
+	flags.StringSlice("receivers", []string{}, "Additional Otel receivers: "+strings.Join(OptionalReceiverNames(), ", "))
+	flags.StringToString("receiver-endpoint", map[string]string{}, "Override a receiver endpoint, e.g. nginx=http://localhost:8080/status")
+}
+
+func flagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	options["scrapers"], _ = flags.GetStringSlice("scrapers")
+	options["interval"], _ = flags.GetString("collection-interval")
+	options["receivers"], _ = flags.GetStringSlice("receivers")
+	options["endpoints"], _ = flags.GetStringToString("receiver-endpoint")
+}
+
+func validate(options map[string]interface{}, os string) error {
+	if err := NewCollectorConfig(options).Validate(); err != nil {
+		return err
+	}
+	return ValidateEndpoint(endpoint.New(options))
+}
@@ -1,6 +1,12 @@
 package otel
 
 import (
+	"fmt"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
@@ -9,6 +15,10 @@ type Otel struct {
 	sysinfo         sysinfo.SysInfo
 	options         map[string]interface{}
 	serviceSettings map[string]string
+	collector       CollectorConfig
+	naming          naming.Naming
+	endpoint        endpoint.Endpoint
+	keyEnvFile      bool
 	updates         chan<- string
 }
 
@@ -22,8 +32,40 @@ func NewOtelAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *Otel
 		apikey:          apikey,
 		sysinfo:         sysInfo,
 		options:         options,
-		serviceSettings: GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr),
+		serviceSettings: utils.StagedSettings(sysInfo, GetServiceSettings(sysInfo), ""),
+		collector:       NewCollectorConfig(options),
+		naming:          naming.New(options),
+		endpoint:        endpoint.New(options),
 	}
+	agent.keyEnvFile, _ = options["apiKeyEnvFile"].(bool)
 
 	return agent
 }
+
+func (o *Otel) validateKeyEnvFile() error {
+	if o.keyEnvFile && o.sysinfo.Os != "linux" {
+		return fmt.Errorf("the api key environment file is only supported for linux services")
+	}
+	return nil
+}
+
+// configKey is the api key as written to the config, a reference to the
+// environment variable when the key is kept in the environment file.
+func (o *Otel) configKey() string {
+	if o.keyEnvFile {
+		return "${env:" + service.ApiKeyVar + "}"
+	}
+	return o.apikey
+}
+
+// ValidateEndpoint checks the endpoint can be used by the carbon exporter,
+// which only sends plaintext over tcp.
+func ValidateEndpoint(e endpoint.Endpoint) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.UseTLS() || e.Transport() != "tcp" {
+		return fmt.Errorf("the otel carbon exporter only supports plaintext tcp, use telegraf for the %s endpoint", e.Mode)
+	}
+	return nil
+}
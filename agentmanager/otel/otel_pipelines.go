@@ -6,16 +6,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
 	otelPipes "github.com/hostedgraphite/hg-cli/agentmanager/otel/pipes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
 	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/pipeline"
 )
 
-//go:embed config.yaml
-var configYaml []byte
-
 //go:embed com.otelcol-contrib-agent.plist
 var plistFile []byte
 
@@ -27,13 +28,28 @@ func (o *Otel) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline,
 	var sysInfo = o.sysinfo
 	var pipes []*pipeline.Pipe
 
+	if err = o.collector.Validate(); err != nil {
+		return nil, err
+	}
+	if err = o.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err = ValidateEndpoint(o.endpoint); err != nil {
+		return nil, err
+	}
+	if err = o.validateKeyEnvFile(); err != nil {
+		return nil, err
+	}
+
+	version, _ := o.options["version"].(string)
+
 	switch sysInfo.Os {
 	case "linux":
-		pipes = otelPipes.LinuxInstallPipes(sysInfo)
+		pipes = otelPipes.LinuxInstallPipes(sysInfo, version)
 	case "darwin":
-		pipes = otelPipes.DarwinInstallPipes(sysInfo)
+		pipes = otelPipes.DarwinInstallPipes(sysInfo, version)
 	case "windows":
-		pipes, err = otelPipes.WindowsInstallPipes(sysInfo)
+		pipes, err = otelPipes.WindowsInstallPipes(sysInfo, version)
 		if err != nil {
 			return nil, err
 		}
@@ -46,6 +62,16 @@ func (o *Otel) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline,
 
 	pipes = append(pipes, configPipes...)
 
+	secretPipes, err := o.secretPipes(o.serviceSettings["configPath"])
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
+	if start, _ := o.options["startService"].(bool); start {
+		pipes = append(pipes, o.restartPipes()...)
+	}
+
 	pipeline := pipeline.NewPipeline(
 		fmt.Sprintf("Installing Otel Agent (%s-%s)",
 			sysInfo.Os,
@@ -58,6 +84,65 @@ func (o *Otel) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline,
 	return &pipeline, err
 }
 
+// ConfigurePipeline regenerates config.yaml for an existing install, the
+// service itself was set up at install time.
+func (o *Otel) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = o.sysinfo
+
+	if err := o.collector.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateEndpoint(o.endpoint); err != nil {
+		return nil, err
+	}
+	if err := o.validateKeyEnvFile(); err != nil {
+		return nil, err
+	}
+
+	pipes := o.collectorConfigPipe()
+
+	secretPipes, err := o.secretPipes(o.serviceSettings["configPath"])
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
+	if start, _ := o.options["startService"].(bool); start {
+		pipes = append(pipes, o.restartPipes()...)
+	}
+
+	pipeline := pipeline.NewPipeline(
+		fmt.Sprintf("Configuring Otel Agent (%s-%s)",
+			sysInfo.Os,
+			sysInfo.PkgMngr,
+		),
+		pipes,
+		updates,
+	)
+
+	return &pipeline, nil
+}
+
+// IsInstalled reports whether the collector already has a config on this host.
+func (o *Otel) IsInstalled() bool {
+	return ValidateFilePath(o.serviceSettings["configPath"]) == nil
+}
+
+func (o *Otel) restartPipes() []*pipeline.Pipe {
+	switch o.sysinfo.Os {
+	case "linux":
+		return otelPipes.LinuxRestartPipes(o.sysinfo)
+	case "darwin":
+		return otelPipes.DarwinRestartPipes()
+	case "windows":
+		return otelPipes.WindowsRestartPipes()
+	}
+	return nil
+}
+
 func (o *Otel) configPipeline() ([]*pipeline.Pipe, error) {
 	var err error
 	var pipes []*pipeline.Pipe
@@ -66,38 +151,99 @@ func (o *Otel) configPipeline() ([]*pipeline.Pipe, error) {
 		pipes = otelPipes.WindowsConfigPipes(o.options, o.serviceSettings)
 	} else if o.sysinfo.Os == "darwin" {
 		pipes = otelPipes.DarwinConfigPipes(o.options, o.serviceSettings, string(plistFile))
-	} else if o.sysinfo.Os == "linux" && o.sysinfo.PkgMngr == "" {
-		pipes = otelPipes.LinuxManualConfigPipes(o.options, o.serviceSettings, string(systemdFile))
+	} else if o.sysinfo.Os == "linux" && otelPipes.ManualInstall(o.sysinfo.PkgMngr) {
+		pipes = otelPipes.LinuxManualConfigPipes(o.sysinfo, o.options, o.serviceSettings, string(systemdFile))
 	}
 
-	updatePipe := o.graphiteOutputUpdatePipe()
-
-	pipes = append(pipes, updatePipe...)
+	pipes = append(pipes, o.collectorConfigPipe()...)
 
 	return pipes, err
 }
 
-func (o *Otel) graphiteOutputUpdatePipe() []*pipeline.Pipe {
-	os := o.sysinfo.Os
+func (o *Otel) collectorConfigPipe() []*pipeline.Pipe {
 	var cmd *exec.Cmd
-	var configPath string
 
-	if os == "windows" {
+	if o.sysinfo.Os == "windows" {
 		cmd = exec.Command("powershell", "-Command", "echo test")
 	} else {
 		cmd = exec.Command("sleep", "1")
 	}
 
-	if o.options["configPath"] != nil {
-		configPath = o.options["configPath"].(string)
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Writing Otel config.yaml", cmd).PostRun(
+			func(ctx context.Context) error {
+				return writeCollectorConfig(o.collector, o.naming, o.endpoint, o.configKey(), o.serviceSettings["configPath"])
+			},
+		).Script(
+			func(shell string) (string, error) {
+				return collectorConfigScript(shell, o.collector, o.naming, o.endpoint, o.configKey(), o.serviceSettings["configPath"])
+			},
+		),
+	}
+
+	return pipes
+}
+
+func writeCollectorConfig(collector CollectorConfig, n naming.Naming, e endpoint.Endpoint, apikey, configPath string) error {
+	hostname, err := n.ResolveHostname()
+	if err != nil {
+		return err
+	}
+	if hostname == "" {
+		hostname, err = os.Hostname()
+		if err != nil {
+			fmt.Println("Error getting hostname:", err)
+		}
+	}
+
+	config, err := collector.Render(n.MetricPrefix(apikey, "opentel"), hostname, e.HostPort())
+	if err != nil {
+		return fmt.Errorf("error generating config: %v", err)
+	}
+
+	err = os.WriteFile(configPath, config, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	return nil
+}
+
+// collectorConfigScript writes the same config as writeCollectorConfig, with
+// the hostname resolved on the host running the script.
+func collectorConfigScript(shell string, collector CollectorConfig, n naming.Naming, e endpoint.Endpoint, apikey, configPath string) (string, error) {
+	hostname, resolve, err := n.ScriptHostname(shell, true)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := collector.Render(n.MetricPrefix(apikey, "opentel"), hostname, e.HostPort())
+	if err != nil {
+		return "", fmt.Errorf("error generating config: %v", err)
+	}
+
+	script := utils.WriteFileScript(shell, configPath, config, 0644)
+	if resolve != "" {
+		script += "\n" + utils.ReplaceScript(shell, configPath, naming.HostnamePlaceholder, resolve)
+	}
+	return script, nil
+}
+
+func (o *Otel) graphiteOutputUpdatePipe() []*pipeline.Pipe {
+	os := o.sysinfo.Os
+	var cmd *exec.Cmd
+	configPath := o.configPath()
+
+	if os == "windows" {
+		cmd = exec.Command("powershell", "-Command", "echo test")
 	} else {
-		configPath = o.serviceSettings["configPath"]
+		cmd = exec.Command("sleep", "1")
 	}
 
 	pipes := []*pipeline.Pipe{
 		pipeline.NewPipe("Updating Otel config.yaml", cmd).PostRun(
 			func(ctx context.Context) error {
-				return graphiteOutputUpdate(o.apikey, configPath)
+				return graphiteOutputUpdate(o.configKey(), configPath, o.naming, o.endpoint)
 			},
 		),
 	}
@@ -106,28 +252,83 @@ func (o *Otel) graphiteOutputUpdatePipe() []*pipeline.Pipe {
 
 }
 
-func graphiteOutputUpdate(apikey, configPath string) error {
-	configYaml := string(configYaml)
-	graphiteBlock := `(?m)^processors:\n(?:\s{2,}.*\n?)*`
+// configPath is the config passed to update-apikey, or the service's one.
+func (o *Otel) configPath() string {
+	if configPath, ok := o.options["config"].(string); ok && configPath != "" {
+		return configPath
+	}
+	return o.serviceSettings["configPath"]
+}
 
-	hostname, err := os.Hostname()
+// secretPipes keep the api key from other users of the host: in the
+// environment file when asked to, with the config only readable by the
+// collector.
+func (o *Otel) secretPipes(configPath string) ([]*pipeline.Pipe, error) {
+	var pipes []*pipeline.Pipe
+	if o.sysinfo.Os != "linux" {
+		return nil, nil
+	}
+
+	if o.keyEnvFile {
+		envPipes, err := otelPipes.LinuxEnvFilePipes(o.sysinfo, o.apikey)
+		if err != nil {
+			return nil, err
+		}
+		pipes = append(pipes, envPipes...)
+	}
+
+	return append(pipes, otelPipes.LinuxConfigPermsPipes(o.sysinfo, configPath)...), nil
+}
+
+var newNameRegex = regexp.MustCompile(`new_name:\s*"?(.*?)\.\$\$0"?\s*$`)
+
+// graphiteOutputUpdate swaps the api key in an existing config, leaving the
+// receivers and scrapers chosen at install time untouched.
+func graphiteOutputUpdate(apikey, configPath string, n naming.Naming, e endpoint.Endpoint) error {
+	fullConfig, err := os.ReadFile(configPath)
 	if err != nil {
-		fmt.Println("Error getting hostname:", err)
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	graphiteBlock := `(?m)^processors:\n(?:\s{2,}.*\n?)*`
+
+	prefix := n.MetricPrefix(apikey, "opentel")
+	if !n.HasPrefix() {
+		for _, line := range strings.Split(string(fullConfig), "\n") {
+			if match := newNameRegex.FindStringSubmatch(line); match != nil {
+				prefix = naming.ReplaceKey(match[1], apikey, "opentel")
+				break
+			}
+		}
 	}
 
 	updates := map[string]string{
-		`<HG-API-KEY>.*`: fmt.Sprintf(`"%s.opentel."`, apikey), // Works without regex issues
-		`<HOSTNAME>`:     hostname,
+		`new_name:.*`: fmt.Sprintf(`new_name: %s.$$0`, prefix),
+	}
+
+	if n.HasHostname() {
+		hostname, err := n.ResolveHostname()
+		if err != nil {
+			return err
+		}
+		updates[`new_value:.*`] = fmt.Sprintf(`new_value: %s`, hostname)
 	}
 
-	updatedConfig, err := utils.UpdateConfigBlock(configYaml, graphiteBlock, updates)
+	updatedConfig, err := utils.UpdateConfigBlock(string(fullConfig), graphiteBlock, updates)
 
 	if err != nil {
 		return fmt.Errorf("error during updating: %v", err)
 	}
 
-	// $$ causes issued with regex as it's seen as an escape character.
-	updatedConfig = strings.ReplaceAll(updatedConfig, "opentel.", "opentel.$$0")
+	if e.IsSet() {
+		exporterBlock := `(?m)^exporters:\n(?:\s{2,}.*\n?)*`
+		updatedConfig, err = utils.UpdateConfigBlock(updatedConfig, exporterBlock, map[string]string{
+			`endpoint:.*`: fmt.Sprintf(`endpoint: %s`, e.HostPort()),
+		})
+		if err != nil {
+			return fmt.Errorf("error during updating: %v", err)
+		}
+	}
 
 	err = os.WriteFile(configPath, []byte(updatedConfig), 0644)
 
@@ -171,6 +372,20 @@ func (o *Otel) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipe
 	var sysInfo = o.sysinfo
 	var pipes []*pipeline.Pipe
 
+	if err = o.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err = ValidateEndpoint(o.endpoint); err != nil {
+		return nil, err
+	}
+	if err = o.validateKeyEnvFile(); err != nil {
+		return nil, err
+	}
+	// Installs that keep the key in the environment file get the new key there.
+	if sysInfo.Os == "linux" && service.ReferencesApiKey(o.configPath()) {
+		o.keyEnvFile = true
+	}
+
 	switch sysInfo.Os {
 	case "linux", "darwin", "windows":
 		pipes = o.graphiteOutputUpdatePipe()
@@ -178,6 +393,12 @@ func (o *Otel) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipe
 		return nil, fmt.Errorf("unsupported operating system: %v", err)
 	}
 
+	secretPipes, err := o.secretPipes(o.configPath())
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
 	pipeline := pipeline.NewPipeline(fmt.Sprintf("Updating HostedGraphite Api Key (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
 
 	return &pipeline, err
@@ -0,0 +1,106 @@
+package otel
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+// runStaged runs the pipes that write into the install root, skipping the
+// release download, and checks none of them touch a path outside of it.
+func runStaged(t *testing.T, root string, pipes []*pipeline.Pipe) {
+	for _, pipe := range pipes {
+		name := filepath.Base(pipe.Cmd.Path)
+		require.NotContains(t, []string{"apt-get", "dpkg", "systemctl", "groupadd", "useradd", "restorecon"}, name, pipe.Name)
+
+		// Releases aren't downloaded, but what would be moved out of the
+		// download still has to land in the root.
+		if name == "mv" {
+			dest := pipe.Cmd.Args[len(pipe.Cmd.Args)-1]
+			require.True(t, strings.HasPrefix(dest, root), "%s moves to %s", pipe.Name, dest)
+		}
+		if slices.Contains([]string{"curl", "tar", "mv"}, name) || strings.Contains(strings.Join(pipe.Cmd.Args, " "), "/tmp/hg-cli") {
+			continue
+		}
+		for _, arg := range pipe.Cmd.Args[1:] {
+			if strings.HasPrefix(arg, "/") {
+				require.True(t, strings.HasPrefix(arg, root), "%s writes to %s", pipe.Name, arg)
+			}
+		}
+
+		_, err := pipe.Run()
+		require.NoError(t, err, pipe.Name)
+	}
+}
+
+func TestStagedInstallPipeline(t *testing.T) {
+	root := t.TempDir()
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64", PkgMngr: "apt", InitSystem: sysinfo.InitSysV}
+	options := map[string]interface{}{
+		"apikey":       "key",
+		"version":      "0.123.1",
+		"hostname":     "web-1",
+		"startService": true,
+	}
+
+	agent := NewOtelAgent(options, host.Staged(root))
+	configPath := filepath.Join(root, "etc/otelcol-contrib/config.yaml")
+	require.Equal(t, configPath, agent.serviceSettings["configPath"])
+
+	p, err := agent.InstallPipeline(nil)
+	require.NoError(t, err)
+	runStaged(t, root, p.Pipes)
+
+	config, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	require.Contains(t, string(config), "key.opentel")
+
+	unit, err := os.ReadFile(filepath.Join(root, "etc/systemd/system/otelcol-contrib.service"))
+	require.NoError(t, err)
+	require.Contains(t, string(unit), "ExecStart=/usr/bin/otelcol-contrib --config=/etc/otelcol-contrib/config.yaml")
+}
+
+func TestStagedUninstallPipeline(t *testing.T) {
+	root := t.TempDir()
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64"}.Staged(root)
+
+	files := []string{
+		"usr/bin/otelcol-contrib",
+		"etc/systemd/system/otelcol-contrib.service",
+		"etc/hg-cli/otelcol-contrib.env",
+		"etc/systemd/system/otelcol-contrib.service.d/hg-cli.conf",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	p, err := NewOtelAgent(map[string]interface{}{}, host).UninstallPipeline(nil)
+	require.NoError(t, err)
+	runStaged(t, root, p.Pipes)
+
+	for _, file := range files {
+		require.NoFileExists(t, filepath.Join(root, file))
+	}
+}
+
+func TestContainerPipelineEnvFile(t *testing.T) {
+	agent := NewOtelAgent(map[string]interface{}{"apikey": "key"}, sysinfo.SysInfo{Os: "linux", Arch: "amd64"})
+
+	p, err := agent.ContainerPipeline(nil)
+	require.NoError(t, err)
+	var names []string
+	for _, pipe := range p.Pipes {
+		names = append(names, pipe.Name)
+	}
+	require.Contains(t, names, "Writing /etc/hg-cli/otelcol-contrib/otelcol-contrib.env")
+	require.Equal(t, "${env:HG_API_KEY}", agent.configKey())
+	require.Contains(t, agent.container().RunArgs(), "--env-file")
+}
@@ -10,16 +10,13 @@ import (
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func DarwinInstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+func DarwinInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
 	arch := sysInfo.Arch
 
-	latest, err := utils.GetLatestReleaseTag("open-telemetry", "opentelemetry-collector-releases")
-	if err != nil {
-		latest = "v0.123.1" // Default
-	}
+	latest := utils.ReleaseTag("open-telemetry", "opentelemetry-collector-releases", version, "v0.123.1")
 	release := fmt.Sprintf("otelcol-contrib_%s_darwin_%s.tar.gz", latest[1:], arch)
-	url := "https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release
+	url := sysInfo.DownloadURL("https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release)
 	tmpDir := "/tmp/hg-cli"
 
 	pipes = []*pipeline.Pipe{
@@ -166,3 +163,25 @@ func DarwinUninstallPipes() []*pipeline.Pipe {
 
 	return pipes
 }
+
+func DarwinRestartPipes() []*pipeline.Pipe {
+	// Same as uninstalling, the launch agent belongs to the user
+	// that ran sudo rather than root.
+	origUser := os.Getenv("SUDO_USER")
+	plistPath := fmt.Sprintf("/Users/%s/Library/LaunchAgents/com.otelcol-contrib-agent.plist", origUser)
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Restarting Otel-Contrib Agent",
+			Cmd: exec.Command(
+				"sudo",
+				"-u",
+				origUser,
+				"sh",
+				"-c",
+				fmt.Sprintf("launchctl unload %s 2>/dev/null; launchctl load -w %s", plistPath, plistPath),
+			),
+		},
+	}
+	return pipes
+}
@@ -4,55 +4,106 @@ import (
 	"fmt"
 	"os/exec"
 
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
 	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/pipeline"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func LinuxInstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+// linuxService runs the collector under init systems the packages have no
+// service for. The packages create the otelcol-contrib user, manual installs
+// run as root.
+func linuxService(sysInfo sysinfo.SysInfo) service.Service {
+	svc := service.Service{
+		Name:        "otelcol-contrib",
+		DisplayName: "Otel-Contrib",
+		Description: "OpenTelemetry Collector Contrib",
+		Command:     "/usr/bin/otelcol-contrib",
+		Args:        []string{"--config=/etc/otelcol-contrib/config.yaml"},
+		Root:        sysInfo.Root,
+	}
+	if !ManualInstall(sysInfo.PkgMngr) {
+		svc.User = "otelcol-contrib"
+	}
+	return svc
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo) (string, string, string) {
+	return linuxService(sysInfo).Hints(service.Init(sysInfo))
+}
+
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
 	pkgMngr := sysInfo.PkgMngr
 	arch := sysInfo.Arch
+	init := service.Init(sysInfo)
 
-	latest, err := utils.GetLatestReleaseTag("open-telemetry", "opentelemetry-collector-releases")
-	if err != nil {
-		latest = "v0.123.1" // Default
-	}
+	latest := utils.ReleaseTag("open-telemetry", "opentelemetry-collector-releases", version, "v0.123.1")
 	release := fmt.Sprintf("otelcol-contrib_%s_linux_%s", latest[1:], arch)
-	packagePath := "https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release
+	packagePath := sysInfo.DownloadURL("https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release)
 
 	if pkgMngr == "apt" {
 		pipes = aptInstallPipes(packagePath, release)
 	} else if pkgMngr == "yum" || pkgMngr == "dnf" {
 		pipes = yumInstallPipes(packagePath, release)
+	} else if pkgMngr == "zypper" {
+		pipes = zypperInstallPipes(packagePath, release)
+	} else if pkgMngr == "apk" {
+		pipes = apkInstallPipes(packagePath, release)
 	} else {
-		pipes = manualInstallPipes(packagePath, release)
+		pipes = manualInstallPipes(sysInfo, packagePath, release)
+	}
+
+	// The packages only ship a systemd unit, manual installs write their
+	// service in LinuxManualConfigPipes.
+	if !ManualInstall(pkgMngr) && init != sysinfo.InitSystemd {
+		pipes = append(pipes, linuxService(sysInfo).InstallPipes(init)...)
 	}
 
 	return pipes
 }
 
-func LinuxManualConfigPipes(options map[string]interface{}, serviceSettings map[string]string, sytemdFile string) []*pipeline.Pipe {
+// ManualInstall reports whether the collector is installed from the release
+// archive, with hg-cli writing the service definition. There's no package
+// for pacman.
+func ManualInstall(pkgMngr string) bool {
+	return pkgMngr == "" || pkgMngr == "pacman"
+}
+
+func LinuxManualConfigPipes(sysInfo sysinfo.SysInfo, options map[string]interface{}, serviceSettings map[string]string, sytemdFile string) []*pipeline.Pipe {
+	init := service.Init(sysInfo)
+
 	pipes := []*pipeline.Pipe{
 		{
 			Name: "Creating Otel-Contrib Config Directory",
 			Cmd: exec.Command(
 				"mkdir",
-				"/etc/otelcol-contrib/",
+				"-p",
+				sysInfo.Path("/etc/otelcol-contrib/"),
 			),
 		},
 		{
 			Name: "Creating Otel-Contrib Config File",
 			Cmd: exec.Command(
 				"touch",
-				"/etc/otelcol-contrib/config.yaml",
+				sysInfo.Path("/etc/otelcol-contrib/config.yaml"),
 			),
 		},
+	}
+
+	if init != sysinfo.InitSystemd {
+		return append(pipes, linuxService(sysInfo).InstallPipes(init)...)
+	}
+
+	unitPath := sysInfo.Path("/etc/systemd/system/otelcol-contrib.service")
+	pipes = append(pipes, []*pipeline.Pipe{
 		{
 			Name: "Creating Otel-Contrib Systemd File",
 			Cmd: exec.Command(
 				"touch",
-				"/etc/systemd/system/otelcol-contrib.service",
+				unitPath,
 			),
 		},
 		{
@@ -60,37 +111,21 @@ func LinuxManualConfigPipes(options map[string]interface{}, serviceSettings map[
 			Cmd: exec.Command(
 				"bash",
 				"-c",
-				fmt.Sprintf("echo '%s' > /etc/systemd/system/otelcol-contrib.service", sytemdFile),
+				fmt.Sprintf("echo '%s' > %s", sytemdFile, pipeline.ShellQuote(unitPath)),
 			),
 		},
-	}
+	}...)
 	return pipes
 }
 
-func linuxManualUninstallPipes() []*pipeline.Pipe {
+func linuxManualUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
 	pipes := []*pipeline.Pipe{
-		{
-			Name: "Stopping Otel-Contrib Service",
-			Cmd: exec.Command(
-				"systemctl",
-				"stop",
-				"otelcol-contrib",
-			),
-		},
 		{
 			Name: "Uninstalling Otel-Contrib",
 			Cmd: exec.Command(
 				"rm",
 				"-rf",
-				"/usr/bin/otelcol-contrib",
-			),
-		},
-		{
-			Name: "Removing Otel-Contrib Service",
-			Cmd: exec.Command(
-				"rm",
-				"-rf",
-				"/etc/systemd/system/otelcol-contrib.service",
+				sysInfo.Path("/usr/bin/otelcol-contrib"),
 			),
 		},
 	}
@@ -118,11 +153,12 @@ func aptInstallPipes(packagePath, release string) []*pipeline.Pipe {
 	return pipes
 }
 
-func manualInstallPipes(packagePath, release string) []*pipeline.Pipe {
+func manualInstallPipes(sysInfo sysinfo.SysInfo, packagePath, release string) []*pipeline.Pipe {
 	tmpDir := "/tmp/hg-cli/"
 	packagePath = packagePath + ".tar.gz"
 	tarPath := tmpDir + release + ".tar.gz"
-	pipes := []*pipeline.Pipe{
+	pipes := utils.StagedDirsPipes(sysInfo, "/usr/bin", "/etc/systemd/system")
+	pipes = append(pipes, []*pipeline.Pipe{
 		{
 			Name: "Creating TMP Directory",
 			Cmd:  exec.Command("mkdir", "-p", tmpDir),
@@ -153,7 +189,7 @@ func manualInstallPipes(packagePath, release string) []*pipeline.Pipe {
 			Cmd: exec.Command(
 				"mv",
 				"/tmp/hg-cli/otelcol-contrib",
-				"/usr/bin/",
+				sysInfo.Path("/usr/bin")+"/",
 			),
 		},
 		{
@@ -164,7 +200,7 @@ func manualInstallPipes(packagePath, release string) []*pipeline.Pipe {
 				tmpDir,
 			),
 		},
-	}
+	}...)
 	return pipes
 }
 
@@ -189,19 +225,93 @@ func yumInstallPipes(packagePath, release string) []*pipeline.Pipe {
 	return pipes
 }
 
+func zypperInstallPipes(packagePath, release string) []*pipeline.Pipe {
+	tmpDir := "/tmp/hg-cli/"
+	packagePath = packagePath + ".rpm"
+	rpmPath := tmpDir + release + ".rpm"
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			Name: "Downloading Otel-Contrib Package",
+			Cmd:  exec.Command("curl", "--tlsv1.2", "-fL", "-o", rpmPath, packagePath),
+		},
+		{
+			// The release packages aren't signed.
+			Name: "Installing Otel-Contrib",
+			Cmd:  exec.Command("zypper", "--non-interactive", "install", "--allow-unsigned-rpm", rpmPath),
+		},
+		{
+			Name: "Cleaning up Temporary Directory",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}
+	return pipes
+}
+
+func apkInstallPipes(packagePath, release string) []*pipeline.Pipe {
+	tmpDir := "/tmp/hg-cli/"
+	packagePath = packagePath + ".apk"
+	apkPath := tmpDir + release + ".apk"
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			// busybox wget, Alpine doesn't ship curl by default.
+			Name: "Downloading Otel-Contrib Package",
+			Cmd:  exec.Command("wget", "-O", apkPath, packagePath),
+		},
+		{
+			Name: "Installing Otel-Contrib",
+			Cmd:  exec.Command("apk", "add", "--allow-untrusted", apkPath),
+		},
+		{
+			Name: "Cleaning up Temporary Directory",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}
+	return pipes
+}
+
 func LinxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
 	pkgMngr := sysInfo.PkgMngr
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo)
+
+	if ManualInstall(pkgMngr) {
+		if service.Managed(sysInfo) {
+			pipes = svc.StopPipes(init)
+		}
+		pipes = append(pipes, linuxManualUninstallPipes(sysInfo)...)
+		pipes = append(pipes, svc.RemovePipes(init)...)
+		return append(pipes, svc.EnvFileRemovePipes()...)
+	}
+
+	if init != sysinfo.InitSystemd {
+		pipes = svc.StopPipes(init)
+	}
 
 	if pkgMngr == "apt" {
-		pipes = linuxDebUninstall()
+		pipes = append(pipes, linuxDebUninstall()...)
 	} else if pkgMngr == "yum" || pkgMngr == "dnf" {
-		pipes = linuxRpmUninstall()
+		pipes = append(pipes, linuxRpmUninstall()...)
 	} else {
-		pipes = linuxManualUninstallPipes()
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Uninstalling Otel-Contrib",
+			Cmd:  utils.PackageRemoveCmd(pkgMngr, "otelcol-contrib"),
+		})
 	}
 
-	return pipes
+	if init != sysinfo.InitSystemd {
+		pipes = append(pipes, svc.RemovePipes(init)...)
+	}
+
+	return append(pipes, svc.EnvFileRemovePipes()...)
 }
 
 func linuxDebUninstall() []*pipeline.Pipe {
@@ -233,3 +343,34 @@ func linuxRpmUninstall() []*pipeline.Pipe {
 
 	return pipes
 }
+
+func LinuxRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if !service.Managed(sysInfo) {
+		return nil
+	}
+
+	return linuxService(sysInfo).RestartPipes(service.Init(sysInfo))
+}
+
+// LinuxEnvFilePipes keep the api key in the collector's root-only
+// environment file, the config references it as ${env:HG_API_KEY}.
+func LinuxEnvFilePipes(sysInfo sysinfo.SysInfo, apikey string) ([]*pipeline.Pipe, error) {
+	return linuxService(sysInfo).EnvFilePipes(sysInfo, map[string]string{service.ApiKeyVar: apikey})
+}
+
+// LinuxConfigPermsPipes stop the config, which has the api key unless it's
+// in the environment file, from being world readable.
+func LinuxConfigPermsPipes(sysInfo sysinfo.SysInfo, configPath string) []*pipeline.Pipe {
+	group := linuxService(sysInfo).User
+	if group == "" {
+		group = "root"
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Restricting Otel-Contrib Config Permissions",
+			Cmd:  exec.Command("sh", "-c", utils.RestrictScript(sysInfo.Root, group, configPath)),
+		},
+	}
+	return pipes
+}
@@ -0,0 +1,71 @@
+package pipes
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// UserService runs the collector as the current user.
+func UserService(dirs service.UserDirs, configPath string) service.Service {
+	return service.Service{
+		Name:        "otelcol-contrib",
+		DisplayName: "Otel-Contrib",
+		Description: "OpenTelemetry Collector Contrib",
+		Command:     filepath.Join(dirs.Bin, "otelcol-contrib"),
+		Args:        []string{"--config=" + configPath},
+	}
+}
+
+// UserInstallPipes install the collector release binary under the user's
+// home.
+func UserInstallPipes(sysInfo sysinfo.SysInfo, version, configPath string, dirs service.UserDirs) []*pipeline.Pipe {
+	latest := utils.ReleaseTag("open-telemetry", "opentelemetry-collector-releases", version, "v0.123.1")
+	release := fmt.Sprintf("otelcol-contrib_%s_%s_%s.tar.gz", latest[1:], sysInfo.Os, sysInfo.Arch)
+	url := sysInfo.DownloadURL("https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release)
+	tmpDir := filepath.Join(dirs.Cache, "hg-cli")
+	tarPath := filepath.Join(tmpDir, release)
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			Name: "Downloading OpenTelemetry to " + tmpDir,
+			Cmd:  exec.Command("curl", "--tlsv1.2", "-fsSL", "-o", tarPath, url),
+		},
+		{
+			Name: "Starting Extraction of Tar Files",
+			Cmd:  exec.Command("tar", "-xf", tarPath, "-C", tmpDir),
+		},
+		{
+			Name: "Creating Otel-Contrib Directories",
+			Cmd:  exec.Command("mkdir", "-p", dirs.Bin, filepath.Dir(configPath)),
+		},
+		{
+			Name: "Moving Exe File to " + dirs.Bin,
+			Cmd:  exec.Command("mv", filepath.Join(tmpDir, "otelcol-contrib"), filepath.Join(dirs.Bin, "otelcol-contrib")),
+		},
+		{
+			Name: "Cleaning up Temporary Directory",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}
+	return pipes
+}
+
+// UserUninstallPipes remove the binary and keep the config.
+func UserUninstallPipes(sysInfo sysinfo.SysInfo, dirs service.UserDirs) []*pipeline.Pipe {
+	pipes := UserService(dirs, "").UserRemovePipes(sysInfo.Os, dirs)
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Uninstalling Otel-Contrib",
+		Cmd:  exec.Command("rm", "-f", filepath.Join(dirs.Bin, "otelcol-contrib")),
+	})
+	return pipes
+}
@@ -10,20 +10,17 @@ import (
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func WindowsInstallPipes(sysInfo sysinfo.SysInfo) ([]*pipeline.Pipe, error) {
-	if isInstalledWindows() {
+func WindowsInstallPipes(sysInfo sysinfo.SysInfo, version string) ([]*pipeline.Pipe, error) {
+	if IsInstalledWindows() {
 		return nil, fmt.Errorf("otelcontribcol is already installed. Please check C:\\Program Files\\OpenTelemetry Collector Contrib")
 	}
 
-	latest, err := utils.GetLatestReleaseTag("open-telemetry", "opentelemetry-collector-releases")
-	if err != nil {
-		latest = "v0.123.1" // Default
-	}
+	latest := utils.ReleaseTag("open-telemetry", "opentelemetry-collector-releases", version, "v0.123.1")
 
 	arch := sysInfo.Arch
 	release := fmt.Sprintf("otelcol-contrib_%s_windows_%s.tar.gz", latest[1:], arch)
 	shell := determineShell()
-	uri := "https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release
+	uri := sysInfo.DownloadURL("https://github.com/open-telemetry/opentelemetry-collector-releases/releases/download/" + latest + "/" + release)
 
 	pipes := []*pipeline.Pipe{
 		{
@@ -89,7 +86,7 @@ func checkFileExists(filepath string) bool {
 	return !info.IsDir()
 }
 
-func isInstalledWindows() bool {
+func IsInstalledWindows() bool {
 	filesToCheck := []string{
 		"C:\\Program Files\\OpenTelemetry Collector Contrib\\otelcontribcol.exe",
 		"C:\\Program Files\\OpenTelemetry Collector Contrib\\config.yaml",
@@ -106,7 +103,7 @@ func isInstalledWindows() bool {
 
 func WindowsUninstallPipes(sysInfo sysinfo.SysInfo) ([]*pipeline.Pipe, error) {
 	shell := determineShell()
-	if !isInstalledWindows() {
+	if !IsInstalledWindows() {
 		return nil, fmt.Errorf("no exe found - Unable to remove service")
 	}
 
@@ -134,3 +131,15 @@ func WindowsUninstallPipes(sysInfo sysinfo.SysInfo) ([]*pipeline.Pipe, error) {
 	}
 	return pipes, nil
 }
+
+func WindowsRestartPipes() []*pipeline.Pipe {
+	shell := determineShell()
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Restarting otelcontribcol service",
+			Cmd:  exec.Command(shell, "-Command", "Restart-Service otelcol-contrib"),
+		},
+	}
+	return pipes
+}
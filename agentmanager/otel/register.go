@@ -0,0 +1,36 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:        "otel",
+		Aliases:     []string{"opentelemetry"},
+		DisplayName: "OpenTelemetry",
+		Flags:       []string{"scrapers", "collection-interval", "receivers", "receiver-endpoint", "user", "api-key-env-file"},
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewOtelAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			return &formatters.OtelContribSummary{
+				ActionSummary: base,
+				Receiver:      strings.Join(NewCollectorConfig(options).ReceiverNames(), ", "),
+				Exporter:      "carbon",
+			}
+		},
+		Validate: validate,
+		Spec: func() agentmanager.Spec {
+			return &Spec{}
+		},
+		AddFlags:    addFlags,
+		FlagOptions: flagOptions,
+		Fields:      newViews,
+	})
+}
@@ -0,0 +1,87 @@
+package otel
+
+import (
+	"fmt"
+	"path/filepath"
+
+	otelPipes "github.com/hostedgraphite/hg-cli/agentmanager/otel/pipes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// userSettings are the service settings of a user scope install.
+func (o *Otel) userSettings(dirs service.UserDirs) map[string]string {
+	configPath := filepath.Join(dirs.Config, "otelcol-contrib", "config.yaml")
+	startHint, restartHint := otelPipes.UserService(dirs, configPath).UserHints(o.sysinfo.Os)
+
+	return map[string]string{
+		"configPath":  configPath,
+		"startHint":   startHint,
+		"restartHint": restartHint,
+		"receiver":    "hostmetrics",
+		"exporter":    "carbon",
+	}
+}
+
+func (o *Otel) UserSettings() map[string]string {
+	dirs, _ := service.CurrentUserDirs()
+	return o.userSettings(dirs)
+}
+
+// UserInstallPipeline installs the collector for the current user, without
+// root, under the XDG directories with a systemd user unit or launchd agent.
+func (o *Otel) UserInstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = o.sysinfo
+
+	if err := o.collector.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateEndpoint(o.endpoint); err != nil {
+		return nil, err
+	}
+	if sysInfo.Os != "linux" && sysInfo.Os != "darwin" {
+		return nil, fmt.Errorf("user installs are only supported on linux and darwin")
+	}
+	if o.keyEnvFile {
+		return nil, fmt.Errorf("the api key environment file needs a root install, user installs keep the key in their own config")
+	}
+
+	dirs, err := service.CurrentUserDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	// collectorConfigPipe writes to the configPath in the service settings.
+	o.serviceSettings = o.userSettings(dirs)
+	configPath := o.serviceSettings["configPath"]
+	version, _ := o.options["version"].(string)
+
+	pipes := otelPipes.UserInstallPipes(sysInfo, version, configPath, dirs)
+	pipes = append(pipes, o.collectorConfigPipe()...)
+
+	svc := otelPipes.UserService(dirs, configPath)
+	pipes = append(pipes, svc.UserConfigPermsPipes(configPath)...)
+	pipes = append(pipes, svc.UserInstallPipes(sysInfo.Os, dirs)...)
+	if start, _ := o.options["startService"].(bool); start {
+		pipes = append(pipes, svc.UserRestartPipes(sysInfo.Os, dirs)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Otel Agent (%s-user)", sysInfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (o *Otel) UserUninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	dirs, err := service.CurrentUserDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	pipes := otelPipes.UserUninstallPipes(o.sysinfo, dirs)
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Otel Agent (%s-user)", o.sysinfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
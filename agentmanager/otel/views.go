@@ -0,0 +1,202 @@
+package otel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	header           string
+	path             string
+	confirmUninstall bool
+	scrapers         []string
+	interval         string
+	receivers        []string
+	endpoints        string
+	output           forms.Output
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header: styles.MfAndOpentelemetryTitle,
+		apikey: forms.DefaultApiKey,
+		output: forms.ProfileOutput(),
+	}
+}
+
+func (o *views) InstallView() (*huh.Group, error) {
+	scraperOptions := huh.NewOptions(HostmetricsScrapers...)
+	for i := range scraperOptions {
+		scraperOptions[i] = scraperOptions[i].Selected(true)
+	}
+
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(o.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				err := utils.ValidateAPIKey(o.apikey)
+				if err != nil {
+					return err
+				}
+				return nil
+			}).
+			Value(&o.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewMultiSelect[string]().
+			Key("scrapers").
+			Title("Select hostmetrics Scrapers").
+			Options(scraperOptions...).
+			Value(&o.scrapers).
+			Validate(func(scrapers []string) error {
+				if len(scrapers) == 0 {
+					return fmt.Errorf("select at least one scraper")
+				}
+				return nil
+			}),
+
+		huh.NewInput().
+			Key("interval").
+			Title("Collection Interval").
+			Prompt("Interval: ").
+			Placeholder(DefaultCollectionInterval).
+			Value(&o.interval).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				_, err := time.ParseDuration(s)
+				return err
+			}),
+
+		huh.NewMultiSelect[string]().
+			Key("receivers").
+			Title("Select Additional Receivers").
+			Description("Optional, hostmetrics is always included.").
+			Options(huh.NewOptions(OptionalReceiverNames()...)...).
+			Value(&o.receivers),
+
+		huh.NewInput().
+			Key("endpoints").
+			Title("Receiver Endpoints").
+			Description("Optional, comma separated overrides e.g. nginx=http://localhost:8080/status").
+			Prompt("Endpoints: ").
+			Value(&o.endpoints).
+			Validate(func(s string) error {
+				endpoints, err := ParseEndpoints(s)
+				if err != nil {
+					return err
+				}
+				for name := range endpoints {
+					if !slices.Contains(o.receivers, name) {
+						return fmt.Errorf("receiver %s is not selected", name)
+					}
+				}
+				return nil
+			}),
+	)
+	return installGroup, nil
+}
+
+// ParseEndpoints turns "name=endpoint,name=endpoint" into a map.
+func ParseEndpoints(s string) (map[string]string, error) {
+	endpoints := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, endpoint, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || endpoint == "" {
+			return nil, fmt.Errorf("invalid endpoint %q, expected name=endpoint", pair)
+		}
+		endpoints[name] = endpoint
+	}
+	return endpoints, nil
+}
+func (o *views) InstallOptions(form *huh.Form, options map[string]interface{}) {
+	if scrapers, ok := form.Get("scrapers").([]string); ok {
+		options["scrapers"] = scrapers
+	}
+	if receivers, ok := form.Get("receivers").([]string); ok {
+		options["receivers"] = receivers
+	}
+	options["interval"] = form.GetString("interval")
+	endpoints, _ := ParseEndpoints(form.GetString("endpoints"))
+	options["endpoints"] = endpoints
+}
+
+func (o *views) OutputView() (*huh.Group, error) {
+	return forms.OutputGroup(&o.output, false, endpoint.Plaintext), nil
+}
+
+func (o *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(o.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall OpenTelemetry?").
+			Description("This will remove the agent, but not the configuration files").
+			Value(&o.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+
+func (o *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(o.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				err := utils.ValidateAPIKey(o.apikey)
+				if err != nil {
+					return err
+				}
+				return nil
+			}).
+			Value(&o.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the OpenTelemetry yaml file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&o.path).
+			Validate(func(s string) error {
+				if s == "" {
+					s = defaultPath
+				}
+				err := ValidateFilePath(s)
+				if err != nil {
+					return err
+				}
+				return nil
+			}),
+	)
+
+	return updateGroup, nil
+}
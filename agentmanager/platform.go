@@ -0,0 +1,43 @@
+package agentmanager
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// PlatformWarning explains when an agent installed on this host won't report
+// what the user expects, empty when there's nothing to warn about.
+func PlatformWarning(sysInfo sysinfo.SysInfo) string {
+	switch {
+	case sysInfo.Containerized():
+		return fmt.Sprintf("hg-cli is running inside a %s container: host metrics agents will only report the container's cpu, memory, disk and processes. Install on the host to monitor it.", sysInfo.Container)
+	case sysInfo.Virtualization == sysinfo.VirtualizationWSL:
+		return "hg-cli is running under WSL: metrics are for the WSL virtual machine, not the Windows host. Install on Windows to monitor it."
+	case sysInfo.InitSystem == sysinfo.InitNone:
+		return "no init system was detected: the agent is installed but has to be started by hand."
+	}
+	return ""
+}
+
+// PlatformDefaults picks a hostname when the detected one wouldn't identify
+// the host, unless one was chosen. Pods report their node, named through
+// the downward API as NODE_NAME, and other containers on a cloud VM report
+// the instance id rather than the container id.
+func PlatformDefaults(options map[string]interface{}, sysInfo sysinfo.SysInfo) {
+	if naming.New(options).HasHostname() || !sysInfo.Containerized() {
+		return
+	}
+
+	if node := os.Getenv("NODE_NAME"); sysInfo.Container == sysinfo.ContainerKubernetes && node != "" {
+		options["hostname"] = node
+		return
+	}
+
+	switch sysInfo.Virtualization {
+	case sysinfo.VirtualizationAWS, sysinfo.VirtualizationGCP, sysinfo.VirtualizationAzure:
+		options["hostnameMode"] = "cloud"
+	}
+}
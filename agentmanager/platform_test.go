@@ -0,0 +1,31 @@
+package agentmanager
+
+import (
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformDefaults(t *testing.T) {
+	t.Setenv("NODE_NAME", "node-1")
+
+	pod := sysinfo.SysInfo{Os: "linux", Container: sysinfo.ContainerKubernetes, Virtualization: sysinfo.VirtualizationAWS}
+	options := map[string]interface{}{}
+	PlatformDefaults(options, pod)
+	require.Equal(t, map[string]interface{}{"hostname": "node-1"}, options)
+
+	docker := sysinfo.SysInfo{Os: "linux", Container: sysinfo.ContainerDocker, Virtualization: sysinfo.VirtualizationGCP}
+	options = map[string]interface{}{}
+	PlatformDefaults(options, docker)
+	require.Equal(t, map[string]interface{}{"hostnameMode": "cloud"}, options)
+
+	// A chosen hostname is kept, and hosts keep their own.
+	options = map[string]interface{}{"hostname": "web-1"}
+	PlatformDefaults(options, docker)
+	require.Equal(t, map[string]interface{}{"hostname": "web-1"}, options)
+
+	options = map[string]interface{}{}
+	PlatformDefaults(options, sysinfo.SysInfo{Os: "linux", Virtualization: sysinfo.VirtualizationAWS})
+	require.Empty(t, options)
+}
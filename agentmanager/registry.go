@@ -0,0 +1,145 @@
+package agentmanager
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/spf13/pflag"
+)
+
+// Definition describes an agent to the commands and views, each agent
+// package registers its own from init.
+type Definition struct {
+	// Name is used on the command line, e.g. `hg-cli agent install telegraf`.
+	Name    string
+	Aliases []string
+	// DisplayName is shown in the TUI and summaries.
+	DisplayName string
+	// Flags lists the install flags that only apply to this agent.
+	Flags []string
+	// DefaultPlugins are the telegraf style input plugins installed when none
+	// are selected, empty for agents without plugins.
+	DefaultPlugins []string
+
+	New             func(options map[string]interface{}, sysInfo sysinfo.SysInfo) Agent
+	ServiceSettings func(sysInfo sysinfo.SysInfo) map[string]string
+	// Summary adds the agent specific details to an action summary.
+	Summary func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent
+
+	// Validate checks the agent specific options before anything is installed.
+	Validate func(options map[string]interface{}, os string) error
+	// Spec returns the struct the agent's own fields of an `hg-cli apply`
+	// spec are decoded into, nil for agents without any.
+	Spec func() Spec
+	// AddFlags adds the agent's install flags, FlagOptions copies their
+	// values into the options.
+	AddFlags    func(flags *pflag.FlagSet)
+	FlagOptions func(flags *pflag.FlagSet, options map[string]interface{})
+	// Fields builds the TUI forms, agents without them aren't offered in the TUI.
+	Fields func(sysInfo sysinfo.SysInfo) FieldViews
+}
+
+// Spec holds the agent specific fields of an `hg-cli apply` spec.
+type Spec interface {
+	// Options adds the fields to the agent options.
+	Options(options map[string]interface{})
+}
+
+// FieldViews are the TUI form groups of an agent.
+type FieldViews interface {
+	InstallView() (*huh.Group, error)
+	OutputView() (*huh.Group, error)
+	UninstallView() (*huh.Group, error)
+	UpdateApiKeyView(defaultPath string) (*huh.Group, error)
+	// InstallOptions copies the agent specific install answers into options.
+	InstallOptions(form *huh.Form, options map[string]interface{})
+}
+
+var registry []Definition
+
+func Register(def Definition) {
+	if _, ok := Lookup(def.Name); ok {
+		panic(fmt.Sprintf("agent %s registered twice", def.Name))
+	}
+	registry = append(registry, def)
+}
+
+// Lookup finds an agent by name, alias or display name, ignoring case.
+func Lookup(name string) (Definition, bool) {
+	name = strings.ToLower(name)
+	for _, def := range registry {
+		if strings.ToLower(def.Name) == name || strings.ToLower(def.DisplayName) == name || slices.Contains(def.Aliases, name) {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Definitions returns the registered agents in registration order.
+func Definitions() []Definition {
+	return slices.Clone(registry)
+}
+
+func Names() []string {
+	var names []string
+	for _, def := range registry {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// AddFlags adds the install flags of every agent. Agents sharing a flag, e.g.
+// collection-interval, use the first agent's registration.
+func AddFlags(flags *pflag.FlagSet) {
+	for _, def := range registry {
+		if def.AddFlags == nil {
+			continue
+		}
+		agentFlags := pflag.NewFlagSet(def.Name, pflag.ContinueOnError)
+		def.AddFlags(agentFlags)
+		agentFlags.VisitAll(func(flag *pflag.Flag) {
+			if flags.Lookup(flag.Name) == nil {
+				flags.AddFlag(flag)
+			}
+		})
+	}
+}
+
+// FlagOptions copies the flags added by AddFlags into the options.
+func FlagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	for _, def := range registry {
+		if def.FlagOptions != nil {
+			def.FlagOptions(flags, options)
+		}
+	}
+}
+
+func ValidateAgent(agent string) bool {
+	_, ok := Lookup(agent)
+	return ok
+}
+
+func ShowAvailableAgents() {
+	fmt.Println("Available agent: ")
+	for _, def := range registry {
+		fmt.Println("- " + def.Name)
+	}
+}
+
+// NewSummary builds the summary for an action, letting the agent fill in its
+// own details.
+func NewSummary(agent string, base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+	def, ok := Lookup(agent)
+	if !ok {
+		return &base
+	}
+	base.Agent = def.DisplayName
+	if def.Summary == nil {
+		return &base
+	}
+	return def.Summary(base, options)
+}
@@ -0,0 +1,25 @@
+package agentmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	defer func(saved []Definition) { registry = saved }(registry)
+	registry = nil
+
+	Register(Definition{Name: "otel", Aliases: []string{"opentelemetry"}, DisplayName: "OpenTelemetry"})
+
+	for _, name := range []string{"otel", "OTEL", "opentelemetry", "OpenTelemetry"} {
+		def, ok := Lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, "otel", def.Name)
+	}
+
+	_, ok := Lookup("collectd")
+	require.False(t, ok)
+	require.Equal(t, []string{"otel"}, Names())
+	require.Panics(t, func() { Register(Definition{Name: "OpenTelemetry"}) })
+}
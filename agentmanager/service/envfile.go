@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// ApiKeyVar is the environment variable agent configs reference the api key
+// by when it's kept in an environment file.
+const ApiKeyVar = "HG_API_KEY"
+
+// EnvDir holds the environment files, only root can read them.
+const EnvDir = "/etc/hg-cli"
+
+// EnvFilePath is the environment file of the service.
+func (s Service) EnvFilePath() string {
+	return path.Join(s.Root, EnvDir, s.Name+".env")
+}
+
+// DropInPath is the systemd drop-in loading the environment file, it
+// applies to whichever unit the package or hg-cli installed.
+func (s Service) DropInPath() string {
+	return path.Join(s.Root, "/etc/systemd/system", s.Name+".service.d", "hg-cli.conf")
+}
+
+func envFile(env map[string]string) []byte {
+	var b strings.Builder
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", key, env[key])
+	}
+	return []byte(b.String())
+}
+
+// EnvFilePipes write env to the service's environment file and load it with a
+// systemd drop-in. systemd reads the file as root before switching to the
+// service user, so the agent never needs access to it.
+func (s Service) EnvFilePipes(sysInfo sysinfo.SysInfo, env map[string]string) ([]*pipeline.Pipe, error) {
+	if init := Init(sysInfo); init != sysinfo.InitSystemd {
+		return nil, fmt.Errorf("the api key environment file is loaded by systemd, %s can't use it", initName(init))
+	}
+
+	envPath := s.EnvFilePath()
+	dropInPath := s.DropInPath()
+	dropIn := []byte(fmt.Sprintf("[Service]\nEnvironmentFile=%s\n", path.Join(EnvDir, s.Name+".env")))
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe(fmt.Sprintf("Writing %s Environment File", s.DisplayName), exec.Command("mkdir", "-p", "-m", "0700", path.Dir(envPath))).PostRun(
+			func(ctx context.Context) error {
+				if err := os.WriteFile(envPath, envFile(env), 0600); err != nil {
+					return err
+				}
+				// WriteFile keeps the mode of an existing file.
+				return os.Chmod(envPath, 0600)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				return fmt.Sprintf("mkdir -p -m 0700 %s\n(umask 077 && %s)", path.Dir(envPath), utils.WriteFileScript(shell, envPath, envFile(env), 0600)), nil
+			},
+		),
+		pipeline.NewPipe(fmt.Sprintf("Loading Environment File in %s Service", s.DisplayName), exec.Command("mkdir", "-p", path.Dir(dropInPath))).PostRun(
+			func(ctx context.Context) error {
+				return os.WriteFile(dropInPath, dropIn, 0644)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				return fmt.Sprintf("mkdir -p %s\n%s", path.Dir(dropInPath), utils.WriteFileScript(shell, dropInPath, dropIn, 0644)), nil
+			},
+		),
+	}
+
+	if Managed(sysInfo) {
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Reloading Systemd Units",
+			Cmd:  exec.Command("systemctl", "daemon-reload"),
+		})
+	}
+	return pipes, nil
+}
+
+// EnvFileRemovePipes delete the files written by EnvFilePipes, if any.
+func (s Service) EnvFileRemovePipes() []*pipeline.Pipe {
+	pipes := []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Removing %s Environment File", s.DisplayName),
+			Cmd:  exec.Command("rm", "-f", s.EnvFilePath(), s.DropInPath()),
+		},
+	}
+	return pipes
+}
+
+// ReferencesApiKey reports whether the config at configPath reads the api key
+// from the environment rather than containing it.
+func ReferencesApiKey(configPath string) bool {
+	config, err := os.ReadFile(configPath)
+	return err == nil && strings.Contains(string(config), ApiKeyVar+"}")
+}
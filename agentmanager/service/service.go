@@ -0,0 +1,358 @@
+// Package service writes and manages the service definitions agents run
+// under, for whichever init system the host uses.
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// Service is a long running agent process.
+type Service struct {
+	// Name is the service name, e.g. telegraf or otelcol-contrib.
+	Name string
+	// DisplayName is used in the pipe names.
+	DisplayName string
+	Description string
+	Command     string
+	Args        []string
+	// User runs the agent, root when empty.
+	User string
+	// Root is the staged install root the definition is written under, the
+	// command and args are paths inside it.
+	Root string
+}
+
+// Init returns the init system to manage services with. Hosts that weren't
+// detected, such as script targets, are assumed to run their distro's
+// default: OpenRC on Alpine and systemd everywhere else.
+func Init(sysInfo sysinfo.SysInfo) string {
+	if sysInfo.InitSystem != "" {
+		return sysInfo.InitSystem
+	}
+	if sysInfo.PkgMngr == "apk" {
+		return sysinfo.InitOpenRC
+	}
+	return sysinfo.InitSystemd
+}
+
+// Path is where the service definition lives for the init system.
+func (s Service) Path(init string) string {
+	var definitionPath string
+	switch init {
+	case sysinfo.InitSystemd:
+		definitionPath = "/etc/systemd/system/" + s.Name + ".service"
+	case sysinfo.InitOpenRC, sysinfo.InitSysV:
+		definitionPath = "/etc/init.d/" + s.Name
+	case sysinfo.InitRunit:
+		definitionPath = "/etc/sv/" + s.Name + "/run"
+	default:
+		return ""
+	}
+	return path.Join(s.Root, definitionPath)
+}
+
+var templates = map[string]string{
+	sysinfo.InitSystemd: `[Unit]
+Description={{.Description}}
+After=network.target
+
+[Service]
+ExecStart={{.CommandLine}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+KillMode=mixed
+Restart=on-failure
+Type=simple
+
+[Install]
+WantedBy=multi-user.target
+`,
+	sysinfo.InitOpenRC: `#!/sbin/openrc-run
+# Generated by hg-cli.
+
+description="{{.Description}}"
+command="{{.Command}}"
+command_args="{{.ArgLine}}"
+{{- if .User}}
+command_user="{{.User}}"
+{{- end}}
+command_background=true
+pidfile="/run/${RC_SVCNAME}.pid"
+output_log="/var/log/{{.Name}}.log"
+error_log="/var/log/{{.Name}}.log"
+
+depend() {
+	need net
+	after firewall
+}
+
+start_pre() {
+	checkpath --file --owner {{if .User}}{{.User}}{{else}}root{{end}} /var/log/{{.Name}}.log
+}
+`,
+	// start-stop-daemon is on every Debian derived sysv host, Devuan included.
+	sysinfo.InitSysV: `#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          {{.Name}}
+# Required-Start:    $network $remote_fs
+# Required-Stop:     $network $remote_fs
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: {{.Description}}
+### END INIT INFO
+# Generated by hg-cli.
+
+NAME={{.Name}}
+DAEMON={{.Command}}
+DAEMON_ARGS="{{.ArgLine}}"
+USER={{if .User}}{{.User}}{{else}}root{{end}}
+PIDFILE=/var/run/$NAME.pid
+LOGFILE=/var/log/$NAME.log
+
+case "$1" in
+  start)
+    touch "$LOGFILE" && chown "$USER" "$LOGFILE"
+    start-stop-daemon --start --quiet --background --make-pidfile --pidfile "$PIDFILE" \
+      --chuid "$USER" --startas /bin/sh -- -c "exec $DAEMON $DAEMON_ARGS >> $LOGFILE 2>&1"
+    ;;
+  stop)
+    start-stop-daemon --stop --quiet --retry 10 --pidfile "$PIDFILE" --remove-pidfile
+    ;;
+  restart)
+    "$0" stop
+    "$0" start
+    ;;
+  status)
+    start-stop-daemon --status --pidfile "$PIDFILE" && echo "$NAME is running" || { echo "$NAME is not running"; exit 3; }
+    ;;
+  *)
+    echo "Usage: $0 {start|stop|restart|status}"
+    exit 2
+    ;;
+esac
+`,
+	sysinfo.InitRunit: `#!/bin/sh
+# Generated by hg-cli.
+exec 2>&1
+exec {{if .User}}chpst -u {{.User}} {{end}}{{.CommandLine}}
+`,
+}
+
+// ArgLine is the arguments as a single shell line.
+func (s Service) ArgLine() string {
+	return strings.Join(s.Args, " ")
+}
+
+// CommandLine is the command with its arguments.
+func (s Service) CommandLine() string {
+	return strings.TrimSpace(s.Command + " " + s.ArgLine())
+}
+
+// Definition renders the service definition for the init system.
+func (s Service) Definition(init string) ([]byte, error) {
+	text, ok := templates[init]
+	if !ok {
+		return nil, fmt.Errorf("no service definition for init system %q", init)
+	}
+
+	var buf bytes.Buffer
+	if err := template.Must(template.New(init).Parse(text)).Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// InstallPipes write the service definition. Nothing is written without an
+// init system, the agent has to be started by hand.
+func (s Service) InstallPipes(init string) []*pipeline.Pipe {
+	if init == sysinfo.InitNone {
+		return nil
+	}
+
+	definitionPath := s.Path(init)
+	perm := os.FileMode(0755)
+	if init == sysinfo.InitSystemd {
+		perm = 0644
+	}
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe(fmt.Sprintf("Writing %s %s Service", s.DisplayName, initName(init)), exec.Command("mkdir", "-p", path.Dir(definitionPath))).PostRun(
+			func(ctx context.Context) error {
+				definition, err := s.Definition(init)
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(definitionPath, definition, perm)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				definition, err := s.Definition(init)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("mkdir -p %s\n%s", path.Dir(definitionPath), utils.WriteFileScript(shell, definitionPath, definition, perm)), nil
+			},
+		),
+	}
+	return pipes
+}
+
+// RemovePipes delete a definition written by InstallPipes.
+func (s Service) RemovePipes(init string) []*pipeline.Pipe {
+	if init == sysinfo.InitNone {
+		return nil
+	}
+
+	target := s.Path(init)
+	if init == sysinfo.InitRunit {
+		target = path.Dir(target)
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Removing %s Service", s.DisplayName),
+			Cmd:  exec.Command("rm", "-rf", target),
+		},
+	}
+	return pipes
+}
+
+// runitServiceDir is where runit looks for enabled services, /var/service on
+// Void and /etc/service elsewhere.
+const runitServiceDir = `$(test -d /var/service && echo /var/service || echo /etc/service)`
+
+// enableCommand enables the service at boot, empty without an init system.
+func (s Service) enableCommand(init string) string {
+	switch init {
+	case sysinfo.InitSystemd:
+		return "systemctl enable " + s.Name
+	case sysinfo.InitOpenRC:
+		return fmt.Sprintf("rc-update add %s default", s.Name)
+	case sysinfo.InitSysV:
+		return fmt.Sprintf("if command -v update-rc.d >/dev/null; then update-rc.d %s defaults; else chkconfig --add %s; fi", s.Name, s.Name)
+	case sysinfo.InitRunit:
+		return fmt.Sprintf("ln -sfn /etc/sv/%s %s/", s.Name, runitServiceDir)
+	}
+	return ""
+}
+
+// RestartPipes enable the service at boot and (re)start it.
+func (s Service) RestartPipes(init string) []*pipeline.Pipe {
+	var enable, restart *exec.Cmd
+
+	switch init {
+	case sysinfo.InitSystemd:
+		return []*pipeline.Pipe{
+			{
+				Name: "Reloading Systemd Units",
+				Cmd:  exec.Command("systemctl", "daemon-reload"),
+			},
+			{
+				Name: fmt.Sprintf("Enabling %s Service", s.DisplayName),
+				Cmd:  exec.Command("systemctl", "enable", s.Name),
+			},
+			{
+				Name: fmt.Sprintf("Restarting %s Service", s.DisplayName),
+				Cmd:  exec.Command("systemctl", "restart", s.Name),
+			},
+		}
+	case sysinfo.InitOpenRC:
+		enable = exec.Command("rc-update", "add", s.Name, "default")
+		restart = exec.Command("rc-service", s.Name, "restart")
+	case sysinfo.InitSysV:
+		enable = exec.Command("sh", "-c", s.enableCommand(init))
+		restart = exec.Command("/etc/init.d/"+s.Name, "restart")
+	case sysinfo.InitRunit:
+		// runsvdir picks up new services every five seconds.
+		enable = exec.Command("sh", "-c", s.enableCommand(init)+" && sleep 6")
+		restart = exec.Command("sv", "restart", s.Name)
+	default:
+		return nil
+	}
+
+	return []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Enabling %s Service", s.DisplayName),
+			Cmd:  enable,
+		},
+		{
+			Name: fmt.Sprintf("Restarting %s Service", s.DisplayName),
+			Cmd:  restart,
+		},
+	}
+}
+
+// StopPipes stop the service and disable it at boot.
+func (s Service) StopPipes(init string) []*pipeline.Pipe {
+	var stop *exec.Cmd
+
+	switch init {
+	case sysinfo.InitSystemd:
+		stop = exec.Command("systemctl", "stop", s.Name)
+	case sysinfo.InitOpenRC:
+		stop = exec.Command("sh", "-c", fmt.Sprintf("rc-service %s stop; rc-update del %s default", s.Name, s.Name))
+	case sysinfo.InitSysV:
+		stop = exec.Command("sh", "-c", fmt.Sprintf("/etc/init.d/%s stop; if command -v update-rc.d >/dev/null; then update-rc.d -f %s remove; else chkconfig --del %s; fi", s.Name, s.Name, s.Name))
+	case sysinfo.InitRunit:
+		stop = exec.Command("sh", "-c", fmt.Sprintf("sv stop %s; rm -f %s/%s", s.Name, runitServiceDir, s.Name))
+	default:
+		return nil
+	}
+
+	return []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Stopping %s Service", s.DisplayName),
+			Cmd:  stop,
+		},
+	}
+}
+
+// Hints are the start, restart and enable at boot commands shown in
+// summaries and exports. Without an init system the agent is run by hand,
+// start and restart are its command line and there's nothing to enable.
+func (s Service) Hints(init string) (string, string, string) {
+	switch init {
+	case sysinfo.InitSystemd:
+		return "sudo systemctl start " + s.Name, "sudo systemctl restart " + s.Name, "sudo " + s.enableCommand(init)
+	case sysinfo.InitOpenRC:
+		return "sudo rc-service " + s.Name + " start", "sudo rc-service " + s.Name + " restart", "sudo " + s.enableCommand(init)
+	case sysinfo.InitSysV:
+		return "sudo /etc/init.d/" + s.Name + " start", "sudo /etc/init.d/" + s.Name + " restart", "sudo sh -c '" + s.enableCommand(init) + "'"
+	case sysinfo.InitRunit:
+		return "sudo sv start " + s.Name, "sudo sv restart " + s.Name, "sudo sh -c '" + s.enableCommand(init) + "'"
+	}
+	return s.CommandLine(), s.CommandLine(), ""
+}
+
+func initName(init string) string {
+	switch init {
+	case sysinfo.InitSystemd:
+		return "Systemd"
+	case sysinfo.InitOpenRC:
+		return "OpenRC"
+	case sysinfo.InitSysV:
+		return "SysV"
+	case sysinfo.InitRunit:
+		return "Runit"
+	}
+	return init
+}
+
+// Managed reports whether the host has an init system to run services under.
+// Without one, e.g. in a container, service steps are skipped. Staged installs
+// only write definitions, the services start when the image boots.
+func Managed(sysInfo sysinfo.SysInfo) bool {
+	return sysInfo.Root == "" && Init(sysInfo) != sysinfo.InitNone
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHints(t *testing.T) {
+	svc := Service{Name: "telegraf", Command: "/usr/bin/telegraf", Args: []string{"--config", "/etc/telegraf/telegraf.conf"}}
+
+	tests := []struct {
+		init    string
+		start   string
+		restart string
+		enable  string
+	}{
+		{sysinfo.InitSystemd, "sudo systemctl start telegraf", "sudo systemctl restart telegraf", "sudo systemctl enable telegraf"},
+		{sysinfo.InitOpenRC, "sudo rc-service telegraf start", "sudo rc-service telegraf restart", "sudo rc-update add telegraf default"},
+		{sysinfo.InitSysV, "sudo /etc/init.d/telegraf start", "sudo /etc/init.d/telegraf restart", "sudo sh -c 'if command -v update-rc.d >/dev/null; then update-rc.d telegraf defaults; else chkconfig --add telegraf; fi'"},
+		{sysinfo.InitRunit, "sudo sv start telegraf", "sudo sv restart telegraf", "sudo sh -c 'ln -sfn /etc/sv/telegraf $(test -d /var/service && echo /var/service || echo /etc/service)/'"},
+		{sysinfo.InitNone, "/usr/bin/telegraf --config /etc/telegraf/telegraf.conf", "/usr/bin/telegraf --config /etc/telegraf/telegraf.conf", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.init, func(t *testing.T) {
+			start, restart, enable := svc.Hints(test.init)
+			require.Equal(t, test.start, start)
+			require.Equal(t, test.restart, restart)
+			require.Equal(t, test.enable, enable)
+		})
+	}
+}
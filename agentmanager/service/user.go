@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// UserDirs are where a user scope install puts its files, following the XDG
+// base directory spec so nothing outside the home directory is touched.
+type UserDirs struct {
+	Home string
+	// Bin holds the agent binaries, ~/.local/bin by default.
+	Bin string
+	// Config holds the agent configs and systemd user units, ~/.config by
+	// default.
+	Config string
+	// Cache is used for downloads, /tmp/hg-cli may belong to another user on
+	// a shared machine.
+	Cache string
+}
+
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return fallback
+}
+
+// CurrentUserDirs resolves the XDG directories of the user running hg-cli.
+func CurrentUserDirs() (UserDirs, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return UserDirs{}, fmt.Errorf("error finding the home directory: %v", err)
+	}
+
+	dirs := UserDirs{
+		Home:   home,
+		Bin:    xdgDir("XDG_BIN_HOME", filepath.Join(home, ".local", "bin")),
+		Config: xdgDir("XDG_CONFIG_HOME", filepath.Join(home, ".config")),
+		Cache:  xdgDir("XDG_CACHE_HOME", filepath.Join(home, ".cache")),
+	}
+	return dirs, nil
+}
+
+// Label names the launchd agent.
+func (s Service) Label() string {
+	return "com.hostedgraphite." + s.Name
+}
+
+// UserPath is where the systemd user unit or launchd agent lives.
+func (s Service) UserPath(goos string, dirs UserDirs) string {
+	if goos == "darwin" {
+		return filepath.Join(dirs.Home, "Library", "LaunchAgents", s.Label()+".plist")
+	}
+	return filepath.Join(dirs.Config, "systemd", "user", s.Name+".service")
+}
+
+var userTemplates = map[string]string{
+	"linux": `[Unit]
+Description={{.Description}}
+After=network-online.target
+
+[Service]
+ExecStart={{.CommandLine}}
+Restart=on-failure
+Type=simple
+
+[Install]
+WantedBy=default.target
+`,
+	"darwin": `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{.Command}}</string>
+{{- range .Args}}
+		<string>{{.}}</string>
+{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>{{.Log}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{.Log}}</string>
+</dict>
+</plist>
+`,
+}
+
+// UserDefinition renders the systemd user unit or launchd agent.
+func (s Service) UserDefinition(goos string, dirs UserDirs) ([]byte, error) {
+	text, ok := userTemplates[goos]
+	if !ok {
+		return nil, fmt.Errorf("user installs aren't supported on %s", goos)
+	}
+
+	data := struct {
+		Service
+		Label string
+		Log   string
+	}{s, s.Label(), filepath.Join(dirs.Home, "Library", "Logs", s.Name+".log")}
+
+	var buf bytes.Buffer
+	if err := template.Must(template.New(goos).Parse(text)).Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UserInstallPipes write the systemd user unit or launchd agent.
+func (s Service) UserInstallPipes(goos string, dirs UserDirs) []*pipeline.Pipe {
+	definitionPath := s.UserPath(goos, dirs)
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe(fmt.Sprintf("Writing %s User Service", s.DisplayName), exec.Command("mkdir", "-p", filepath.Dir(definitionPath))).PostRun(
+			func(ctx context.Context) error {
+				definition, err := s.UserDefinition(goos, dirs)
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(definitionPath, definition, 0644)
+			},
+		),
+	}
+	return pipes
+}
+
+// UserConfigPermsPipes make the config, which has the api key, only readable
+// by the user.
+func (s Service) UserConfigPermsPipes(configPath string) []*pipeline.Pipe {
+	pipes := []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Restricting %s Config Permissions", s.DisplayName),
+			Cmd:  exec.Command("chmod", "0600", configPath),
+		},
+	}
+	return pipes
+}
+
+// launchctlDomain is the current user's gui domain, e.g. gui/501.
+const launchctlDomain = "gui/$(id -u)"
+
+// UserRestartPipes enable the user service and (re)start it.
+func (s Service) UserRestartPipes(goos string, dirs UserDirs) []*pipeline.Pipe {
+	if goos == "darwin" {
+		return []*pipeline.Pipe{
+			{
+				Name: fmt.Sprintf("Restarting %s User Service", s.DisplayName),
+				Cmd:  exec.Command("sh", "-c", fmt.Sprintf("launchctl bootout %s/%s 2>/dev/null; launchctl bootstrap %s %s", launchctlDomain, s.Label(), launchctlDomain, s.UserPath(goos, dirs))),
+			},
+		}
+	}
+
+	return []*pipeline.Pipe{
+		{
+			Name: "Reloading Systemd User Units",
+			Cmd:  exec.Command("systemctl", "--user", "daemon-reload"),
+		},
+		{
+			Name: fmt.Sprintf("Enabling %s User Service", s.DisplayName),
+			Cmd:  exec.Command("systemctl", "--user", "enable", s.Name),
+		},
+		{
+			Name: fmt.Sprintf("Restarting %s User Service", s.DisplayName),
+			Cmd:  exec.Command("systemctl", "--user", "restart", s.Name),
+		},
+	}
+}
+
+// UserRemovePipes stop the user service and delete its definition.
+func (s Service) UserRemovePipes(goos string, dirs UserDirs) []*pipeline.Pipe {
+	stop := exec.Command("sh", "-c", fmt.Sprintf("systemctl --user disable --now %s || true", s.Name))
+	if goos == "darwin" {
+		stop = exec.Command("sh", "-c", fmt.Sprintf("launchctl bootout %s/%s || true", launchctlDomain, s.Label()))
+	}
+
+	return []*pipeline.Pipe{
+		{
+			Name: fmt.Sprintf("Stopping %s User Service", s.DisplayName),
+			Cmd:  stop,
+		},
+		{
+			Name: fmt.Sprintf("Removing %s User Service", s.DisplayName),
+			Cmd:  exec.Command("rm", "-f", s.UserPath(goos, dirs)),
+		},
+	}
+}
+
+// UserHints are the start and restart commands shown in summaries. systemd
+// stops user services at logout unless lingering is enabled.
+func (s Service) UserHints(goos string) (string, string) {
+	if goos == "darwin" {
+		return "launchctl kickstart " + launchctlDomain + "/" + s.Label(),
+			"launchctl kickstart -k " + launchctlDomain + "/" + s.Label()
+	}
+	return "systemctl --user start " + s.Name + " (and loginctl enable-linger to keep it running after logout)",
+		"systemctl --user restart " + s.Name
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentUserDirs(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	t.Setenv("XDG_CONFIG_HOME", "/home/dev/conf")
+	t.Setenv("XDG_BIN_HOME", "relative/bin")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	dirs, err := CurrentUserDirs()
+	require.NoError(t, err)
+	// Relative XDG paths are invalid by the spec and ignored.
+	require.Equal(t, UserDirs{Home: "/home/dev", Bin: "/home/dev/.local/bin", Config: "/home/dev/conf", Cache: "/home/dev/.cache"}, dirs)
+}
+
+func TestUserDefinition(t *testing.T) {
+	dirs := UserDirs{Home: "/Users/dev", Bin: "/Users/dev/.local/bin", Config: "/Users/dev/.config"}
+	svc := Service{Name: "otelcol-contrib", Description: "OpenTelemetry Collector Contrib", Command: "/Users/dev/.local/bin/otelcol-contrib", Args: []string{"--config=/Users/dev/.config/otelcol-contrib/config.yaml"}}
+
+	unit, err := svc.UserDefinition("linux", dirs)
+	require.NoError(t, err)
+	require.Contains(t, string(unit), "ExecStart=/Users/dev/.local/bin/otelcol-contrib --config=/Users/dev/.config/otelcol-contrib/config.yaml\n")
+	require.Contains(t, string(unit), "WantedBy=default.target")
+	require.NotContains(t, string(unit), "User=")
+	require.Equal(t, "/Users/dev/.config/systemd/user/otelcol-contrib.service", svc.UserPath("linux", dirs))
+
+	plist, err := svc.UserDefinition("darwin", dirs)
+	require.NoError(t, err)
+	require.Contains(t, string(plist), "<string>com.hostedgraphite.otelcol-contrib</string>")
+	require.Contains(t, string(plist), "\t\t<string>/Users/dev/.local/bin/otelcol-contrib</string>\n\t\t<string>--config=/Users/dev/.config/otelcol-contrib/config.yaml</string>\n\t</array>")
+	require.Contains(t, string(plist), "<string>/Users/dev/Library/Logs/otelcol-contrib.log</string>")
+	require.Equal(t, "/Users/dev/Library/LaunchAgents/com.hostedgraphite.otelcol-contrib.plist", svc.UserPath("darwin", dirs))
+
+	_, err = svc.UserDefinition("windows", dirs)
+	require.Error(t, err)
+}
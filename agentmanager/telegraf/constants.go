@@ -20,12 +20,17 @@ var ServiceDetails = map[string]map[string]map[string]string{
 			"restartHint": `& "C:\Program Files\InfluxData\telegraf\telegraf.exe" --service-name telegraf service stop (then start)`,
 		},
 	},
+	// The linux start and restart hints depend on the init system, see
+	// GetServiceSettings.
 	"linux": {
 		"default": {
-			"configPath":  "/etc/telegraf/telegraf.conf",
-			"serviceCmd":  "telegraf",
-			"startHint":   "sudo service telegraf start",
-			"restartHint": "sudo service telegraf restart",
+			"configPath": "/etc/telegraf/telegraf.conf",
+			"serviceCmd": "telegraf",
+		},
+		// Alpine's package keeps the config at the top of /etc.
+		"apk": {
+			"configPath": "/etc/telegraf.conf",
+			"serviceCmd": "telegraf",
 		},
 		"brew": {
 			"configPath":  "/home/linuxbrew/.linuxbrew/etc/telegraf.conf",
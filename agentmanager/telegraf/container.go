@@ -0,0 +1,105 @@
+package telegraf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/container"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+func (t *Telegraf) container() container.Container {
+	version, _ := t.options["version"].(string)
+	tag := "latest"
+	if version != "" {
+		tag = strings.TrimPrefix(version, "v")
+	}
+
+	root := container.HostRoot
+	return container.Container{
+		Name:  "hg-cli-telegraf",
+		Image: "telegraf:" + tag,
+		// The inputs read the host through these instead of the container.
+		Env: map[string]string{
+			"HOST_ETC":          root + "/etc",
+			"HOST_PROC":         root + "/proc",
+			"HOST_SYS":          root + "/sys",
+			"HOST_VAR":          root + "/var",
+			"HOST_RUN":          root + "/run",
+			"HOST_MOUNT_PREFIX": root,
+		},
+		EnvFile: path.Join(container.ConfigDir("telegraf"), "telegraf.env"),
+		Mounts: append([]container.Mount{
+			{Source: t.containerConfigPath(), Target: "/etc/telegraf/telegraf.conf", ReadOnly: true},
+		}, container.HostMounts()...),
+	}
+}
+
+func (t *Telegraf) containerConfigPath() string {
+	return path.Join(container.ConfigDir("telegraf"), "telegraf.conf")
+}
+
+func (t *Telegraf) ContainerSettings() map[string]string {
+	composeFile, _ := t.options["composeFile"].(string)
+	return container.Settings(t.container(), t.containerConfigPath(), composeFile)
+}
+
+// ContainerPipeline generates the config with the telegraf image, so nothing
+// is installed on the host, then starts the container.
+func (t *Telegraf) ContainerPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = t.sysinfo
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if err := container.ValidateMode(container.ModeDocker, sysInfo.Os); err != nil {
+		return nil, err
+	}
+	// The container gets the api key from its environment file, the config
+	// references it.
+	t.keyEnvFile = true
+
+	c := t.container()
+	configPath := t.containerConfigPath()
+	inputs := strings.Join(t.options["plugins"].([]string), ":")
+
+	// The config pipes below read the path from the service settings, point
+	// them at the container config without touching the shared host ones.
+	t.serviceSettings = map[string]string{"configPath": configPath}
+
+	pipes := []*pipeline.Pipe{
+		container.PullPipe(c.Image),
+		container.EnvFilePipe(c, map[string]string{service.ApiKeyVar: t.apikey}),
+		{
+			Name: "Creating " + container.ConfigDir("telegraf"),
+			Cmd:  exec.Command("mkdir", "-p", container.ConfigDir("telegraf")),
+		},
+		pipeline.NewPipe("Configuring Telegraf Plugins", exec.Command("docker", "run", "--rm", c.Image, "telegraf", "--input-filter", inputs, "--output-filter", "graphite", "config")).PostRun(
+			func(ctx context.Context) error {
+				// The image runs telegraf as its own user, the key isn't in
+				// the config so it can stay world readable.
+				config, _ := ctx.Value("output").(string)
+				if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+					return fmt.Errorf("error writing file: %v", err)
+				}
+				return nil
+			},
+		),
+	}
+	pipes = append(pipes, t.graphiteOutputUpdatePipe(false)...)
+	if statsd, enabled := NewStatsD(t.options); enabled {
+		pipes = append(pipes, t.statsdPipe(statsd)...)
+	}
+
+	composeFile, _ := t.options["composeFile"].(string)
+	pipes = append(pipes, container.Pipes(c, composeFile)...)
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Deploying Telegraf Container (%s-docker)", sysInfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
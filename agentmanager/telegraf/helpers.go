@@ -2,21 +2,30 @@ package telegraf
 
 import (
 	"fmt"
+	"maps"
 	"os"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/telegraf/pipes"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func GetServiceSettings(os, arch, pkgmngr string) map[string]string {
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
 	var settings map[string]string
+	os, arch, pkgmngr := sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr
 
 	switch os {
 	case "windows":
 		settings = ServiceDetails[os]["default"]
 	case "linux":
 		if pkgmngr == "brew" {
-			settings = ServiceDetails[os][pkgmngr]
+			return ServiceDetails[os][pkgmngr]
+		}
+		if pkgmngr == "apk" {
+			settings = maps.Clone(ServiceDetails[os][pkgmngr])
 		} else {
-			settings = ServiceDetails[os]["default"]
+			settings = maps.Clone(ServiceDetails[os]["default"])
 		}
+		settings["startHint"], settings["restartHint"], settings["enableHint"] = pipes.LinuxHints(sysInfo, settings["configPath"])
 	case "darwin":
 		switch arch {
 		case "amd64":
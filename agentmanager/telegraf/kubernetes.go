@@ -0,0 +1,156 @@
+package telegraf
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/kubernetes"
+)
+
+// The config is rendered here rather than with `telegraf config`, the
+// manifests are generated on machines without telegraf. Only the default
+// plugins are offered since they work without any settings.
+var kubernetesTemplate = template.Must(template.New("telegraf.conf").Parse(`# Generated by hg-cli.
+[agent]
+  interval = "{{.Interval}}"
+  flush_interval = "{{.Interval}}"
+  hostname = "{{.NodeName}}"
+  omit_hostname = false
+
+{{.Output}}
+  prefix = "{{.Prefix}}"
+{{- if .Template}}
+  template = "{{.Template}}"
+{{- end}}
+{{- if .TagSupport}}
+  graphite_tag_support = true
+{{- end}}
+{{- range .Plugins}}
+
+[[inputs.{{.}}]]
+{{- end}}
+{{- if .Kubelet}}
+
+[[inputs.kubernetes]]
+  url = "https://{{.NodeIP}}:10250"
+  bearer_token = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+  insecure_skip_verify = true
+{{- end}}
+{{- if .Inventory}}
+
+[[inputs.kube_inventory]]
+  url = "https://kubernetes.default.svc"
+  bearer_token = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+  tls_ca = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+  namespace = ""
+{{- end}}
+`))
+
+type kubernetesConfig struct {
+	Interval   string
+	NodeName   string
+	NodeIP     string
+	Output     string
+	Prefix     string
+	Template   string
+	TagSupport bool
+	Plugins    []string
+	Kubelet    bool
+	Inventory  bool
+}
+
+func (t *Telegraf) kubernetesConfig(plugins []string, kubelet, inventory bool) ([]byte, error) {
+	header, target := outputTarget(t.endpoint)
+
+	config := kubernetesConfig{
+		Interval:   "10s",
+		NodeName:   "${" + kubernetes.NodeNameEnv + "}",
+		NodeIP:     "${" + kubernetes.NodeIPEnv + "}",
+		Output:     header + "\n" + target,
+		Prefix:     t.naming.MetricPrefix("${"+kubernetes.ApiKeyEnv+"}", "telegraf"),
+		Template:   t.naming.Template,
+		TagSupport: t.naming.TagSupport,
+		Plugins:    plugins,
+		Kubelet:    kubelet,
+		Inventory:  inventory,
+	}
+
+	var buf bytes.Buffer
+	if err := kubernetesTemplate.Execute(&buf, config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// KubernetesWorkloads runs telegraf on each node with the chosen plugins,
+// and kube_inventory once for the cluster when it was asked for.
+func (t *Telegraf) KubernetesWorkloads() (kubernetes.Workload, *kubernetes.Workload, error) {
+	var node kubernetes.Workload
+
+	if err := t.validate(); err != nil {
+		return node, nil, err
+	}
+
+	plugins, _ := t.options["plugins"].([]string)
+	if len(plugins) == 0 {
+		plugins = DefaultTelegrafPlugins
+	}
+	for _, plugin := range plugins {
+		if !slices.Contains(DefaultTelegrafPlugins, plugin) {
+			return node, nil, fmt.Errorf("plugin %s isn't supported in kubernetes (available: %s)", plugin, strings.Join(DefaultTelegrafPlugins, ", "))
+		}
+	}
+	if _, enabled := NewStatsD(t.options); enabled {
+		return node, nil, fmt.Errorf("the statsd listener isn't supported in kubernetes")
+	}
+
+	version, _ := t.options["version"].(string)
+	image := "telegraf:latest"
+	if version != "" {
+		image = "telegraf:" + strings.TrimPrefix(version, "v")
+	}
+
+	kubelet, _ := t.options["kubeletstats"].(bool)
+	config, err := t.kubernetesConfig(plugins, kubelet, false)
+	if err != nil {
+		return node, nil, err
+	}
+
+	root := kubernetes.HostRoot
+	node = kubernetes.Workload{
+		Name:       "hg-cli-telegraf",
+		Image:      image,
+		ConfigDir:  "/etc/telegraf",
+		ConfigFile: "telegraf.conf",
+		Config:     config,
+		Env: map[string]string{
+			"HOST_ETC":          root + "/etc",
+			"HOST_PROC":         root + "/proc",
+			"HOST_SYS":          root + "/sys",
+			"HOST_VAR":          root + "/var",
+			"HOST_RUN":          root + "/run",
+			"HOST_MOUNT_PREFIX": root,
+		},
+	}
+
+	if inventory, _ := t.options["kubeInventory"].(bool); !inventory {
+		return node, nil, nil
+	}
+
+	clusterConfig, err := t.kubernetesConfig(nil, false, true)
+	if err != nil {
+		return node, nil, err
+	}
+	cluster := &kubernetes.Workload{
+		Name:       "hg-cli-telegraf-cluster",
+		Image:      image,
+		ConfigDir:  "/etc/telegraf",
+		ConfigFile: "telegraf.conf",
+		Config:     clusterConfig,
+	}
+
+	return node, cluster, nil
+}
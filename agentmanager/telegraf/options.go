@@ -0,0 +1,72 @@
+package telegraf
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/tui/views/config"
+	"github.com/spf13/pflag"
+)
+
+// Spec are the telegraf fields of an `hg-cli apply` spec.
+type Spec struct {
+	Plugins            []string   `yaml:"plugins"`
+	Template           string     `yaml:"template"`
+	GraphiteTagSupport bool       `yaml:"graphiteTagSupport"`
+	StatsD             StatsDSpec `yaml:"statsd"`
+}
+
+// StatsDSpec adds a local StatsD listener.
+type StatsDSpec struct {
+	Enabled     bool     `yaml:"enabled"`
+	Port        int      `yaml:"port"`
+	Percentiles []int    `yaml:"percentiles"`
+	Templates   []string `yaml:"templates"`
+}
+
+func (s *Spec) Options(options map[string]interface{}) {
+	plugins := s.Plugins
+	if plugins == nil {
+		plugins = []string{}
+	}
+
+	options["plugins"] = plugins
+	options["template"] = s.Template
+	options["tagSupport"] = s.GraphiteTagSupport
+	options["statsd"] = s.StatsD.Enabled
+	options["statsdPort"] = s.StatsD.Port
+	options["statsdPercentiles"] = s.StatsD.Percentiles
+	options["statsdTemplates"] = s.StatsD.Templates
+}
+
+func addFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("plugins", []string{}, "List of plugins to include during install (comma separated)")
+	flags.Bool("statsd", false, "Add a local StatsD listener (telegraf only)")
+	flags.Int("statsd-port", DefaultStatsDPort, "StatsD listener udp port, used with --statsd")
+	flags.IntSlice("statsd-percentiles", DefaultStatsDPercentiles, "Percentiles calculated for StatsD timings, used with --statsd")
+	flags.StringSlice("statsd-templates", []string{}, "StatsD templates, e.g. service.measurement.field, used with --statsd")
+}
+
+func flagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	options["plugins"], _ = flags.GetStringSlice("plugins")
+	options["statsd"], _ = flags.GetBool("statsd")
+	options["statsdPort"], _ = flags.GetInt("statsd-port")
+	options["statsdPercentiles"], _ = flags.GetIntSlice("statsd-percentiles")
+	options["statsdTemplates"], _ = flags.GetStringSlice("statsd-templates")
+}
+
+func validate(options map[string]interface{}, os string) error {
+	if plugins, _ := options["plugins"].([]string); len(plugins) > 0 {
+		catalog, err := config.LoadPlugins()
+		if err != nil {
+			return err
+		}
+		if err := catalog.ValidateSelection(os, plugins); err != nil {
+			return err
+		}
+	}
+	if statsd, enabled := NewStatsD(options); enabled {
+		if err := statsd.Validate(); err != nil {
+			return err
+		}
+	}
+	return endpoint.New(options).Validate()
+}
@@ -27,3 +27,12 @@ func BrewUninstallPipes() []*pipeline.Pipe {
 		},
 	}
 }
+
+func BrewRestartPipes() []*pipeline.Pipe {
+	return []*pipeline.Pipe{
+		{
+			Name: "Restarting Telegraf Brew Service",
+			Cmd:  exec.Command("brew", "services", "restart", "telegraf"),
+		},
+	}
+}
@@ -8,35 +8,31 @@ import (
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func DarwinInstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+func DarwinInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
 	pkgMngr := sysInfo.PkgMngr
-	arch := sysInfo.Arch
 
 	if pkgMngr == "brew" {
 		pipes = BrewInstallPipes()
 	} else {
-		pipes = macDmgInstallPipes(arch)
+		pipes = macDmgInstallPipes(sysInfo, version)
 	}
 
 	return pipes
 }
 
-func macDmgInstallPipes(arch string) []*pipeline.Pipe {
+func macDmgInstallPipes(sysInfo sysinfo.SysInfo, version string) []*pipeline.Pipe {
 	var dmgURL, dmgFileName string
 
-	latest, err := utils.GetLatestReleaseTag("influxdata", "telegraf")
-	if err != nil {
-		latest = "v1.33.1" // Default
-	}
+	latest := utils.ReleaseTag("influxdata", "telegraf", version, "v1.33.1")
 	latest = latest[1:]
 
 	// Set the download URL and file name based on architecture
-	if arch == "arm64" {
-		dmgURL = "https://dl.influxdata.com/telegraf/releases/telegraf-" + latest + "_darwin_arm64.dmg"
+	if sysInfo.Arch == "arm64" {
+		dmgURL = sysInfo.DownloadURL("https://dl.influxdata.com/telegraf/releases/telegraf-" + latest + "_darwin_arm64.dmg")
 		dmgFileName = "telegraf-" + latest + "_darwin_arm64.dmg"
 	} else {
-		dmgURL = "https://dl.influxdata.com/telegraf/releases/telegraf-" + latest + "_darwin_amd64.dmg"
+		dmgURL = sysInfo.DownloadURL("https://dl.influxdata.com/telegraf/releases/telegraf-" + latest + "_darwin_amd64.dmg")
 		dmgFileName = "telegraf-" + latest + "_darwin_amd64.dmg"
 	}
 
@@ -98,3 +94,12 @@ func macDmgUninstallPipes() []*pipeline.Pipe {
 
 	return pipes
 }
+
+// DarwinRestartPipes restarts the brew service, the dmg install doesn't set
+// up a service so there is nothing to restart.
+func DarwinRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if sysInfo.PkgMngr == "brew" {
+		return BrewRestartPipes()
+	}
+	return nil
+}
@@ -5,25 +5,73 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
 	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/pipeline"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func LinuxInstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+// linuxService runs telegraf under the init system when the package doesn't
+// ship a service for it.
+func linuxService(root, configPath string) service.Service {
+	return service.Service{
+		Name:        "telegraf",
+		DisplayName: "Telegraf",
+		Description: "Telegraf metrics agent",
+		Command:     "/usr/bin/telegraf",
+		Args:        []string{"--config", configPath},
+		User:        "telegraf",
+		Root:        root,
+	}
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo, configPath string) (string, string, string) {
+	return linuxService("", configPath).Hints(service.Init(sysInfo))
+}
+
+// packagedService reports whether the install already provides a service for
+// the init system. The deb and rpm packages fall back to an init.d script
+// without systemd, Alpine's package ships an OpenRC one and the release
+// archive only has a systemd unit.
+func packagedService(pkgMngr, init string) bool {
+	switch pkgMngr {
+	case "apt", "yum", "dnf", "zypper":
+		return init == sysinfo.InitSystemd || init == sysinfo.InitSysV
+	case "apk":
+		return init == sysinfo.InitOpenRC
+	case "brew":
+		return true
+	}
+	return init == sysinfo.InitSystemd
+}
+
+// LinuxInstallPipes installs telegraf, an empty version installs the latest
+// release. The config path is the one the service reads, inside any install
+// root.
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, version, configPath string) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
-	arch := sysInfo.Arch
-	distro := sysInfo.Distro
 	pkgMngr := sysInfo.PkgMngr
+	init := service.Init(sysInfo)
 
 	if pkgMngr == "brew" {
 		pipes = BrewInstallPipes()
 	} else if pkgMngr == "apt" {
-		pipes = aptInstallPipes()
+		pipes = aptInstallPipes(version)
 	} else if pkgMngr == "yum" || pkgMngr == "dnf" {
-		pipes = yumInstallPipes()
+		pipes = yumInstallPipes(version)
+	} else if pkgMngr == "zypper" {
+		pipes = zypperInstallPipes(version)
+	} else if pkgMngr == "apk" {
+		pipes = apkInstallPipes(version)
 	} else {
-		pipes = linuxBinInstallPipes(arch, distro)
+		// Arch only has telegraf in the AUR, so pacman uses the release archive too.
+		pipes = linuxBinInstallPipes(sysInfo, version, init)
+	}
+
+	if !packagedService(pkgMngr, init) {
+		pipes = append(pipes, linuxService(sysInfo.Root, configPath).InstallPipes(init)...)
 	}
 
 	return pipes
@@ -37,13 +85,17 @@ func LinuxConfigPipes(options map[string]interface{}, serviceSettings map[string
 	pipes := []*pipeline.Pipe{
 		{
 			Name: "Configuring Telegraf Plugins",
-			Cmd:  exec.Command("sh", "-c", telegrafCmd+" --input-filter "+inputs+" --output-filter graphite config > "+configpath),
+			Cmd:  exec.Command("sh", "-c", pipeline.ShellQuote(telegrafCmd)+" --input-filter "+inputs+" --output-filter graphite config > "+pipeline.ShellQuote(configpath)),
 		},
 	}
 	return pipes
 }
 
-func aptInstallPipes() []*pipeline.Pipe {
+func aptInstallPipes(version string) []*pipeline.Pipe {
+	pkg := "telegraf"
+	if version != "" {
+		pkg = "telegraf=" + strings.TrimPrefix(version, "v") + "-1"
+	}
 
 	tmpDir := "/tmp/hg-cli"
 	keyPath := "/tmp/hg-cli/influxdata-archive.key"
@@ -71,7 +123,7 @@ func aptInstallPipes() []*pipeline.Pipe {
 		},
 		{
 			Name: "Installing Telegraf",
-			Cmd:  exec.Command("apt-get", "install", "-y", "telegraf"),
+			Cmd:  exec.Command("apt-get", "install", "-y", pkg),
 		},
 		{
 			Name: "Deleting TMP Directory",
@@ -89,7 +141,11 @@ enabled = 1
 gpgcheck = 1
 gpgkey = https://repos.influxdata.com/influxdata-archive_compat.key`
 
-func yumInstallPipes() []*pipeline.Pipe {
+func yumInstallPipes(version string) []*pipeline.Pipe {
+	pkg := "telegraf"
+	if version != "" {
+		pkg = "telegraf-" + strings.TrimPrefix(version, "v")
+	}
 
 	pipes := []*pipeline.Pipe{
 		{
@@ -98,7 +154,53 @@ func yumInstallPipes() []*pipeline.Pipe {
 		},
 		{
 			Name: "Installing Telegraf Agent",
-			Cmd:  exec.Command("yum", "install", "-y", "telegraf"),
+			Cmd:  exec.Command("yum", "install", "-y", pkg),
+		},
+	}
+
+	return pipes
+}
+
+// zypper reads the same repo file format as yum.
+func zypperInstallPipes(version string) []*pipeline.Pipe {
+	pkg := "telegraf"
+	if version != "" {
+		pkg = "telegraf=" + strings.TrimPrefix(version, "v")
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Importing InfluxData Key",
+			Cmd:  exec.Command("rpm", "--import", "https://repos.influxdata.com/influxdata-archive_compat.key"),
+		},
+		{
+			Name: "Adding InfluxData zypper Repository",
+			Cmd:  exec.Command("sh", "-c", "echo '"+yumRepo+"' > /etc/zypp/repos.d/influxdata.repo"),
+		},
+		{
+			Name: "Refreshing Repositories",
+			Cmd:  exec.Command("zypper", "--non-interactive", "refresh", "influxdata"),
+		},
+		{
+			Name: "Installing Telegraf Agent",
+			Cmd:  utils.PackageInstallCmd("zypper", pkg),
+		},
+	}
+
+	return pipes
+}
+
+// Telegraf ships in Alpine's community repository.
+func apkInstallPipes(version string) []*pipeline.Pipe {
+	pkg := "telegraf"
+	if version != "" {
+		pkg = "telegraf~" + strings.TrimPrefix(version, "v")
+	}
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Installing Telegraf Agent",
+			Cmd:  utils.PackageInstallCmd("apk", pkg),
 		},
 	}
 
@@ -112,21 +214,19 @@ var linuxArchFile = map[string]string{
 	"armv7l": "_linux_armhf.tar.gz",
 }
 
-func linuxBinInstallPipes(arch, distro string) []*pipeline.Pipe {
+func linuxBinInstallPipes(sysInfo sysinfo.SysInfo, version, init string) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
+	arch := sysInfo.Arch
+	distro := sysInfo.Distro
 
-	latest, err := utils.GetLatestReleaseTag("influxdata", "telegraf")
-	if err != nil {
-		latest = "v1.33.1" // Default
-	}
+	latest := utils.ReleaseTag("influxdata", "telegraf", version, "v1.33.1")
 	latest = latest[1:]
 
 	file := "telegraf-" + latest + linuxArchFile[arch]
-	url := "https://dl.influxdata.com/telegraf/releases/" + file
-	version := "telegraf-" + latest + "/"
+	url := sysInfo.DownloadURL("https://dl.influxdata.com/telegraf/releases/" + file)
 	tmpDir := "/tmp/hg-cli/"
 	tmpPath := "/tmp/hg-cli/" + file
-	telegrafPath := tmpDir + version
+	telegrafPath := tmpDir + "telegraf-" + latest + "/"
 	telegrafConf := telegrafPath + "etc/telegraf/telegraf.conf"
 	telegrafBin := telegrafPath + "usr/bin/telegraf"
 	telegrafService := telegrafPath + "usr/lib/telegraf/scripts/telegraf.service"
@@ -146,43 +246,45 @@ func linuxBinInstallPipes(arch, distro string) []*pipeline.Pipe {
 		},
 		{
 			Name: "Creating Telegraf Config Directory",
-			Cmd:  exec.Command("mkdir", "-p", "/etc/telegraf"),
+			Cmd:  exec.Command("mkdir", "-p", sysInfo.Path("/etc/telegraf")),
 		},
 		{
 			Name: "Moving Telegraf Conf File",
-			Cmd:  exec.Command("mv", telegrafConf, "/etc/telegraf/"),
+			Cmd:  exec.Command("mv", telegrafConf, sysInfo.Path("/etc/telegraf")+"/"),
 		},
 		{
 			Name: "Placing bin file in /usr/bin",
-			Cmd:  exec.Command("mv", telegrafBin, "/usr/bin/"),
+			Cmd:  exec.Command("mv", telegrafBin, sysInfo.Path("/usr/bin")+"/"),
 		},
-		{
+	}
+
+	pipes = append(utils.StagedDirsPipes(sysInfo, "/usr/bin", "/etc/systemd/system"), pipes...)
+
+	// Other init systems get a service written by LinuxInstallPipes.
+	if init == sysinfo.InitSystemd {
+		pipes = append(pipes, &pipeline.Pipe{
 			Name: "Adding service file to systemd",
-			Cmd:  exec.Command("mv", telegrafService, "/etc/systemd/system/telegraf.service"),
-		},
-		// TODO: Check if the users exists before creating, pkgmgrs don't remove users.
-		{
-			Name: "Creating telegraf service group",
-			Cmd:  exec.Command("groupadd", "-g", "988", "telegraf"),
-		},
-		{
-			Name: "Creating telegraf user",
-			Cmd:  exec.Command("useradd", "-r", "-u", "989", "-g", "988", "-d", "/etc/telegraf", "-s", "/bin/false", "telegraf"),
-		},
+			Cmd:  exec.Command("mv", telegrafService, sysInfo.Path("/etc/systemd/system/telegraf.service")),
+		})
 	}
 
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Creating telegraf user",
+		Cmd:  utils.UserAddCmd(sysInfo.Root, "telegraf", "-r", "-U", "-d", "/etc/telegraf", "-s", "/bin/false"),
+	})
+
 	if distro == "fedora" || distro == "centos" || distro == "rhel" {
 		// For Fedora/CentOs SELinux permissions
-		pipes = append(pipes, []*pipeline.Pipe{
-			{
-				Name: "Setting SELinux permissions",
-				Cmd:  exec.Command("restorecon", "-Rv", "/usr/bin/telegraf"),
-			},
-			{
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Setting SELinux permissions",
+			Cmd:  exec.Command("restorecon", "-Rv", "/usr/bin/telegraf"),
+		})
+		if init == sysinfo.InitSystemd {
+			pipes = append(pipes, &pipeline.Pipe{
 				Name: "Setting SELinux permissions",
 				Cmd:  exec.Command("restorecon", "-Rv", "/etc/systemd/system/telegraf.service"),
-			},
-		}...)
+			})
+		}
 	}
 
 	pipes = append(pipes, []*pipeline.Pipe{
@@ -199,52 +301,73 @@ func linuxBinInstallPipes(arch, distro string) []*pipeline.Pipe {
 func LinuxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
 	var pipes []*pipeline.Pipe
 	pkgMngr := sysInfo.PkgMngr
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo.Root, "")
 
 	if pkgMngr == "brew" {
-		pipes = BrewUninstallPipes()
-	} else if pkgMngr == "" {
-		pipes = linuxUninstallerPipes()
+		return BrewUninstallPipes()
+	}
+
+	if service.Managed(sysInfo) {
+		pipes = svc.StopPipes(init)
+	}
+	if pkgMngr == "" || pkgMngr == "pacman" {
+		pipes = append(pipes, linuxUninstallerPipes(sysInfo)...)
 	} else {
-		pipes = linuxPkgMngrUninstallPipes(pkgMngr)
+		pipes = append(pipes, &pipeline.Pipe{
+			Name: "Uninstalling Telegraf Agent",
+			Cmd:  utils.PackageRemoveCmd(pkgMngr, "telegraf"),
+		})
 	}
 
-	return pipes
+	if !packagedService(pkgMngr, init) || pkgMngr == "" || pkgMngr == "pacman" {
+		pipes = append(pipes, svc.RemovePipes(init)...)
+	}
+
+	return append(pipes, svc.EnvFileRemovePipes()...)
 }
 
-func linuxPkgMngrUninstallPipes(pkgMngr string) []*pipeline.Pipe {
+func linuxUninstallerPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
 	pipes := []*pipeline.Pipe{
 		{
-			Name: "Stopping Telegraf Service",
-			Cmd:  exec.Command("systemctl", "stop", "telegraf"),
+			Name: "Removing Telegraf Binary",
+			Cmd:  exec.Command("rm", sysInfo.Path("/usr/bin/telegraf")),
 		},
 		{
-			Name: "Uninstalling Telegraf Agent",
-			Cmd:  exec.Command(pkgMngr, "remove", "telegraf", "-y"),
+			Name: "Removing Telegraf User",
+			Cmd:  utils.UserDelCmd(sysInfo.Root, "telegraf"),
 		},
 	}
 
 	return pipes
 }
 
-func linuxUninstallerPipes() []*pipeline.Pipe {
+func LinuxRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if sysInfo.PkgMngr == "brew" {
+		return BrewRestartPipes()
+	}
+
+	if !service.Managed(sysInfo) {
+		return nil
+	}
+
+	return linuxService(sysInfo.Root, "").RestartPipes(service.Init(sysInfo))
+}
+
+// LinuxEnvFilePipes keep the api key in telegraf's root-only environment
+// file, the config references it as ${HG_API_KEY}.
+func LinuxEnvFilePipes(sysInfo sysinfo.SysInfo, apikey string) ([]*pipeline.Pipe, error) {
+	return linuxService(sysInfo.Root, "").EnvFilePipes(sysInfo, map[string]string{service.ApiKeyVar: apikey})
+}
+
+// LinuxConfigPermsPipes stop the config, which has the api key unless it's
+// in the environment file, from being world readable.
+func LinuxConfigPermsPipes(sysInfo sysinfo.SysInfo, configPath string) []*pipeline.Pipe {
 	pipes := []*pipeline.Pipe{
 		{
-			Name: "Stopping Telegraf Service",
-			Cmd:  exec.Command("systemctl", "stop", "telegraf"),
-		},
-		{
-			Name: "Removing Telegraf Binary",
-			Cmd:  exec.Command("rm", "/usr/bin/telegraf"),
-		},
-		{
-			Name: "Removing Telegraf Service",
-			Cmd:  exec.Command("rm", "-rf", "/etc/systemd/system/telegraf.service"),
-		},
-		{
-			Name: "Removing Telegraf User",
-			Cmd:  exec.Command("userdel", "telegraf"),
+			Name: "Restricting Telegraf Config Permissions",
+			Cmd:  exec.Command("sh", "-c", utils.RestrictScript(sysInfo.Root, "telegraf", configPath)),
 		},
 	}
-
 	return pipes
 }
@@ -0,0 +1,79 @@
+package pipes
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// UserService runs telegraf as the current user.
+func UserService(dirs service.UserDirs, configPath string) service.Service {
+	return service.Service{
+		Name:        "telegraf",
+		DisplayName: "Telegraf",
+		Description: "Telegraf metrics agent",
+		Command:     filepath.Join(dirs.Bin, "telegraf"),
+		Args:        []string{"--config", configPath},
+	}
+}
+
+// UserInstallPipes install the telegraf release binary under the user's
+// home, no package manager can be used without root.
+func UserInstallPipes(sysInfo sysinfo.SysInfo, version, configPath string, dirs service.UserDirs) []*pipeline.Pipe {
+	latest := utils.ReleaseTag("influxdata", "telegraf", version, "v1.33.1")
+	latest = latest[1:]
+
+	file := "telegraf-" + latest + linuxArchFile[sysInfo.Arch]
+	if sysInfo.Os == "darwin" {
+		file = "telegraf-" + latest + "_darwin_" + sysInfo.Arch + ".tar.gz"
+	}
+	url := sysInfo.DownloadURL("https://dl.influxdata.com/telegraf/releases/" + file)
+	tmpDir := filepath.Join(dirs.Cache, "hg-cli")
+	tmpPath := filepath.Join(tmpDir, file)
+	telegrafBin := filepath.Join(tmpDir, "telegraf-"+latest, "usr", "bin", "telegraf")
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Creating TMP Directory",
+			Cmd:  exec.Command("mkdir", "-p", tmpDir),
+		},
+		{
+			Name: "Downloading Telegraf archive file",
+			Cmd:  exec.Command("curl", "--tlsv1.2", "-fsSL", "-o", tmpPath, url),
+		},
+		{
+			Name: "Extracting Telegraf archive file",
+			Cmd:  exec.Command("tar", "xf", tmpPath, "-C", tmpDir),
+		},
+		{
+			Name: "Creating Telegraf Directories",
+			Cmd:  exec.Command("mkdir", "-p", dirs.Bin, filepath.Dir(configPath)),
+		},
+		{
+			Name: "Placing bin file in " + dirs.Bin,
+			Cmd:  exec.Command("mv", telegrafBin, filepath.Join(dirs.Bin, "telegraf")),
+		},
+		{
+			Name: "Cleaning up temp dir",
+			Cmd:  exec.Command("rm", "-rf", tmpDir),
+		},
+	}
+
+	return pipes
+}
+
+// UserUninstallPipes remove the binary, the config is kept like the host
+// uninstalls do.
+func UserUninstallPipes(sysInfo sysinfo.SysInfo, dirs service.UserDirs) []*pipeline.Pipe {
+	pipes := UserService(dirs, "").UserRemovePipes(sysInfo.Os, dirs)
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Removing Telegraf Binary",
+		Cmd:  exec.Command("rm", "-f", filepath.Join(dirs.Bin, "telegraf")),
+	})
+
+	return pipes
+}
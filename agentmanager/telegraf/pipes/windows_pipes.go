@@ -12,26 +12,23 @@ import (
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
-func WindowsInstallPipes(sysInfo sysinfo.SysInfo) ([]*pipeline.Pipe, error) {
+func WindowsInstallPipes(sysInfo sysinfo.SysInfo, version string) ([]*pipeline.Pipe, error) {
 
 	if IsInstalledWindows() {
 		return nil, fmt.Errorf("telegraf is already installed. Please check C:\\Program Files\\InfluxData\\telegraf")
 	}
 
-	latest, err := utils.GetLatestReleaseTag("influxdata", "telegraf")
-	if err != nil {
-		latest = "v1.33.1" // Default
-	}
-
+	latest := utils.ReleaseTag("influxdata", "telegraf", version, "v1.33.1")
 	latest = latest[1:]
 	arch := sysInfo.Arch
 	release := fmt.Sprintf("telegraf-%s_windows_%s.zip", latest, arch)
+	uri := sysInfo.DownloadURL("https://dl.influxdata.com/telegraf/releases/" + release)
 	shell := determineShell()
 
 	pipes := []*pipeline.Pipe{
 		{
 			Name: "Downloading telegraf to ~\\Downloads",
-			Cmd:  exec.Command(shell, "-Command", `$ProgressPreference='SilentlyContinue';Invoke-WebRequest -Uri https://dl.influxdata.com/telegraf/releases/`+release+` -OutFile ~\Downloads\`+release+`;`),
+			Cmd:  exec.Command(shell, "-Command", `$ProgressPreference='SilentlyContinue';Invoke-WebRequest -Uri `+uri+` -OutFile ~\Downloads\`+release+`;`),
 		},
 		{
 			Name: "Expanding telegraf archive to C:\\Program Files",
@@ -62,6 +59,10 @@ func WindowsConfigPipes(options map[string]interface{}, serviceSettings map[stri
 				err := os.WriteFile(configpath, []byte(output), 0644)
 				return err
 			},
+		).Script(
+			func(shell string) (string, error) {
+				return fmt.Sprintf("[IO.File]::WriteAllText('%s', ((& '%s' --input-filter %s --output-filter graphite config) -join \"`n\"))", configpath, telegrafCmd, inputs), nil
+			},
 		),
 	}
 
@@ -124,3 +125,15 @@ func WindowsUninstallPipes() ([]*pipeline.Pipe, error) {
 	}
 	return pipes, nil
 }
+
+func WindowsRestartPipes() []*pipeline.Pipe {
+	shell := determineShell()
+
+	pipes := []*pipeline.Pipe{
+		{
+			Name: "Restarting telegraf service",
+			Cmd:  exec.Command(shell, "-Command", "Restart-Service telegraf"),
+		},
+	}
+	return pipes
+}
@@ -0,0 +1,39 @@
+package telegraf
+
+import (
+	"slices"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:           "telegraf",
+		DisplayName:    "Telegraf",
+		Flags:          []string{"plugins", "template", "graphite-tag-support", "statsd", "statsd-port", "statsd-percentiles", "statsd-templates", "user", "api-key-env-file"},
+		DefaultPlugins: DefaultTelegrafPlugins,
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewTelegrafAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			plugins, _ := options["plugins"].([]string)
+			if _, enabled := NewStatsD(options); enabled {
+				plugins = append(slices.Clone(plugins), "statsd")
+			}
+			return &formatters.TelegrafSummary{
+				ActionSummary: base,
+				Plugins:       plugins,
+			}
+		},
+		Validate: validate,
+		Spec: func() agentmanager.Spec {
+			return &Spec{}
+		},
+		AddFlags:    addFlags,
+		FlagOptions: flagOptions,
+		Fields:      newViews,
+	})
+}
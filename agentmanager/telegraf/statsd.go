@@ -0,0 +1,115 @@
+package telegraf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	DefaultStatsDPort = 8125
+)
+
+var DefaultStatsDPercentiles = []int{50, 90, 99}
+
+var statsdTemplate = template.Must(template.New("statsd").Funcs(template.FuncMap{
+	"quote": func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = fmt.Sprintf("%q", item)
+		}
+		return strings.Join(quoted, ", ")
+	},
+	"floats": func(items []int) string {
+		floats := make([]string, len(items))
+		for i, item := range items {
+			floats[i] = fmt.Sprintf("%d.0", item)
+		}
+		return strings.Join(floats, ", ")
+	},
+}).Parse(`
+# Local StatsD listener, added by hg-cli.
+[[inputs.statsd]]
+  protocol = "udp"
+  service_address = "127.0.0.1:{{.Port}}"
+  percentiles = [{{floats .Percentiles}}]
+  metric_separator = "."
+  delete_gauges = true
+  delete_counters = true
+  delete_sets = true
+  delete_timings = true
+{{- if .Templates}}
+  templates = [{{quote .Templates}}]
+{{- end}}
+`))
+
+// StatsD is the telegraf statsd input, a listener for applications on the
+// same host.
+type StatsD struct {
+	Port        int
+	Percentiles []int
+	Templates   []string
+}
+
+// NewStatsD reads the statsd options, the listener is only added when the
+// statsd option is set.
+func NewStatsD(options map[string]interface{}) (StatsD, bool) {
+	s := StatsD{Port: DefaultStatsDPort, Percentiles: DefaultStatsDPercentiles}
+
+	enabled, _ := options["statsd"].(bool)
+	if port, ok := options["statsdPort"].(int); ok && port != 0 {
+		s.Port = port
+	}
+	if percentiles, ok := options["statsdPercentiles"].([]int); ok && len(percentiles) > 0 {
+		s.Percentiles = percentiles
+	}
+	s.Templates, _ = options["statsdTemplates"].([]string)
+
+	return s, enabled
+}
+
+func (s StatsD) Validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return fmt.Errorf("invalid statsd port %d", s.Port)
+	}
+	for _, percentile := range s.Percentiles {
+		if percentile < 1 || percentile > 100 {
+			return fmt.Errorf("invalid statsd percentile %d: must be between 1 and 100", percentile)
+		}
+	}
+	for _, t := range s.Templates {
+		if strings.TrimSpace(t) == "" || strings.Contains(t, `"`) {
+			return fmt.Errorf("invalid statsd template %q", t)
+		}
+	}
+	return nil
+}
+
+func (s StatsD) Render() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := statsdTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// appendStatsD adds the statsd input to the end of a generated config.
+func appendStatsD(configPath string, s StatsD) error {
+	block, err := s.Render()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(block); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
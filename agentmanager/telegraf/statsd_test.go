@@ -0,0 +1,43 @@
+package telegraf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStatsD(t *testing.T) {
+	statsd, enabled := NewStatsD(map[string]interface{}{})
+	require.False(t, enabled)
+	require.Equal(t, DefaultStatsDPort, statsd.Port)
+
+	statsd, enabled = NewStatsD(map[string]interface{}{
+		"statsd":            true,
+		"statsdPort":        9125,
+		"statsdPercentiles": []int{95},
+		"statsdTemplates":   []string{"service.measurement.field"},
+	})
+	require.True(t, enabled)
+	require.Equal(t, StatsD{Port: 9125, Percentiles: []int{95}, Templates: []string{"service.measurement.field"}}, statsd)
+}
+
+func TestStatsDValidate(t *testing.T) {
+	require.NoError(t, StatsD{Port: 8125, Percentiles: []int{50, 99}}.Validate())
+	require.Error(t, StatsD{Port: 70000}.Validate())
+	require.Error(t, StatsD{Port: 8125, Percentiles: []int{0}}.Validate())
+	require.Error(t, StatsD{Port: 8125, Templates: []string{`bad"template`}}.Validate())
+}
+
+func TestAppendStatsD(t *testing.T) {
+	path := writeSampleConfig(t)
+
+	statsd := StatsD{Port: 8125, Percentiles: []int{50, 90}, Templates: []string{"service.measurement.field"}}
+	require.NoError(t, appendStatsD(path, statsd))
+
+	config := readConfig(t, path)
+	require.Contains(t, config, sampleConfig)
+	require.Contains(t, config, `[[inputs.statsd]]`)
+	require.Contains(t, config, `service_address = "127.0.0.1:8125"`)
+	require.Contains(t, config, `percentiles = [50.0, 90.0]`)
+	require.Contains(t, config, `templates = ["service.measurement.field"]`)
+}
@@ -1,6 +1,9 @@
 package telegraf
 
 import (
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 )
 
@@ -9,6 +12,9 @@ type Telegraf struct {
 	sysinfo         sysinfo.SysInfo
 	options         map[string]interface{}
 	serviceSettings map[string]string
+	naming          naming.Naming
+	endpoint        endpoint.Endpoint
+	keyEnvFile      bool
 	updates         chan<- string
 }
 
@@ -27,7 +33,10 @@ func NewTelegrafAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *
 		apikey:          apikey,
 		sysinfo:         sysInfo,
 		options:         options,
-		serviceSettings: GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr),
+		serviceSettings: utils.StagedSettings(sysInfo, GetServiceSettings(sysInfo), "/usr/bin/telegraf"),
+		naming:          naming.New(options),
+		endpoint:        endpoint.New(options),
 	}
+	agent.keyEnvFile, _ = options["apiKeyEnvFile"].(bool)
 	return agent
 }
@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"strings"
 
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
 	telegrafPipes "github.com/hostedgraphite/hg-cli/agentmanager/telegraf/pipes"
 	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/pipeline"
@@ -16,13 +21,21 @@ func (t *Telegraf) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipel
 	var sysInfo = t.sysinfo
 	var pipes []*pipeline.Pipe
 
+	if err = t.validate(); err != nil {
+		return nil, err
+	}
+
+	version, _ := t.options["version"].(string)
+
 	switch sysInfo.Os {
 	case "linux":
-		pipes = telegrafPipes.LinuxInstallPipes(sysInfo)
+		// The service reads its config from inside any install root.
+		configPath := GetServiceSettings(sysInfo)["configPath"]
+		pipes = telegrafPipes.LinuxInstallPipes(sysInfo, version, configPath)
 	case "darwin":
-		pipes = telegrafPipes.DarwinInstallPipes(sysInfo)
+		pipes = telegrafPipes.DarwinInstallPipes(sysInfo, version)
 	case "windows":
-		pipes, err = telegrafPipes.WindowsInstallPipes(sysInfo)
+		pipes, err = telegrafPipes.WindowsInstallPipes(sysInfo, version)
 		if err != nil {
 			return nil, err
 		}
@@ -36,6 +49,16 @@ func (t *Telegraf) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipel
 	}
 	pipes = append(pipes, configPipes...)
 
+	secretPipes, err := t.secretPipes()
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
+	if start, _ := t.options["startService"].(bool); start {
+		pipes = append(pipes, t.restartPipes()...)
+	}
+
 	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Telegraf Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
 
 	return &pipeline, err
@@ -57,59 +80,219 @@ func (t *Telegraf) configPipeline() ([]*pipeline.Pipe, error) {
 		pipes = telegrafPipes.LinuxConfigPipes(options, serviceSettings)
 	}
 
-	updatePipe := t.graphiteOutputUpdatePipe()
+	updatePipe := t.graphiteOutputUpdatePipe(false)
 
 	pipes = append(pipes, updatePipe...)
 
+	if statsd, enabled := NewStatsD(options); enabled {
+		pipes = append(pipes, t.statsdPipe(statsd)...)
+	}
+
 	return pipes, err
 }
 
-func (t *Telegraf) graphiteOutputUpdatePipe() []*pipeline.Pipe {
-	os := t.sysinfo.Os
+func (t *Telegraf) statsdPipe(statsd StatsD) []*pipeline.Pipe {
 	var cmd *exec.Cmd
-	var configPath string
-
-	if os == "windows" {
+	if t.sysinfo.Os == "windows" {
 		cmd = exec.Command("powershell", "-Command", "echo test")
 	} else {
 		cmd = exec.Command("sleep", "1")
 	}
 
-	if t.options["config"] != nil {
-		configPath = t.options["config"].(string)
+	configPath := t.serviceSettings["configPath"]
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe(fmt.Sprintf("Adding StatsD Listener on port %d", statsd.Port), cmd).PostRun(
+			func(ctx context.Context) error {
+				return appendStatsD(configPath, statsd)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				block, err := statsd.Render()
+				if err != nil {
+					return "", err
+				}
+				return utils.AppendFileScript(shell, configPath, block), nil
+			},
+		),
+	}
+	return pipes
+}
+
+func (t *Telegraf) validate() error {
+	if err := t.naming.Validate(); err != nil {
+		return err
+	}
+	if err := t.endpoint.Validate(); err != nil {
+		return err
+	}
+	if err := t.validateKeyEnvFile(); err != nil {
+		return err
+	}
+	if statsd, enabled := NewStatsD(t.options); enabled {
+		return statsd.Validate()
+	}
+	return nil
+}
+
+func (t *Telegraf) validateKeyEnvFile() error {
+	if t.keyEnvFile && (t.sysinfo.Os != "linux" || t.sysinfo.PkgMngr == "brew") {
+		return fmt.Errorf("the api key environment file is only supported for linux services")
+	}
+	return nil
+}
+
+// configKey is the api key as written to the config, a reference to the
+// environment variable when the key is kept in the environment file.
+func (t *Telegraf) configKey() string {
+	if t.keyEnvFile {
+		return "${" + service.ApiKeyVar + "}"
+	}
+	return t.apikey
+}
+
+// secretPipes keep the api key from other users of the host: in the
+// environment file when asked to, with the config only readable by telegraf.
+func (t *Telegraf) secretPipes() ([]*pipeline.Pipe, error) {
+	var pipes []*pipeline.Pipe
+	if t.sysinfo.Os != "linux" || t.sysinfo.PkgMngr == "brew" {
+		return nil, nil
+	}
+
+	if t.keyEnvFile {
+		envPipes, err := telegrafPipes.LinuxEnvFilePipes(t.sysinfo, t.apikey)
+		if err != nil {
+			return nil, err
+		}
+		pipes = append(pipes, envPipes...)
+	}
+
+	return append(pipes, telegrafPipes.LinuxConfigPermsPipes(t.sysinfo, t.configPath())...), nil
+}
+
+// ConfigurePipeline rewrites the config of an existing install, used when
+// applying a spec to a host that already has telegraf.
+func (t *Telegraf) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = t.sysinfo
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	pipes, err := t.configPipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	secretPipes, err := t.secretPipes()
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
+	if start, _ := t.options["startService"].(bool); start {
+		pipes = append(pipes, t.restartPipes()...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Configuring Telegraf Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, err
+}
+
+// IsInstalled reports whether telegraf already has a config on this host.
+func (t *Telegraf) IsInstalled() bool {
+	return ValidateFilePath(t.serviceSettings["configPath"]) == nil
+}
+
+func (t *Telegraf) restartPipes() []*pipeline.Pipe {
+	switch t.sysinfo.Os {
+	case "linux":
+		return telegrafPipes.LinuxRestartPipes(t.sysinfo)
+	case "darwin":
+		return telegrafPipes.DarwinRestartPipes(t.sysinfo)
+	case "windows":
+		return telegrafPipes.WindowsRestartPipes()
+	}
+	return nil
+}
+
+// graphiteOutputUpdatePipe points the graphite output at Hosted Graphite. When
+// keepPrefix is set only the api key in the existing prefix is swapped, unless
+// a new prefix was given.
+func (t *Telegraf) graphiteOutputUpdatePipe(keepPrefix bool) []*pipeline.Pipe {
+	os := t.sysinfo.Os
+	var cmd *exec.Cmd
+	configPath := t.configPath()
+
+	if os == "windows" {
+		cmd = exec.Command("powershell", "-Command", "echo test")
 	} else {
-		configPath = t.serviceSettings["configPath"]
+		cmd = exec.Command("sleep", "1")
 	}
 
 	pipes := []*pipeline.Pipe{
 		pipeline.NewPipe("Updating Telegraf Graphite Output Config", cmd).PostRun(
 			func(ctx context.Context) error {
-				return graphiteOutputUpdate(t.apikey, configPath)
+				return graphiteOutputUpdate(t.configKey(), configPath, t.naming, t.endpoint, keepPrefix)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				if keepPrefix {
+					return "", fmt.Errorf("updating the api key can't be exported as a script")
+				}
+				return graphiteOutputScript(shell, t.configKey(), configPath, t.naming, t.endpoint)
 			},
 		),
 	}
 	return pipes
 }
 
-func graphiteOutputUpdate(apikey, configPath string) error {
+// configPath is the config passed to update-apikey, or the service's one.
+func (t *Telegraf) configPath() string {
+	if configPath, ok := t.options["config"].(string); ok && configPath != "" {
+		return configPath
+	}
+	return t.serviceSettings["configPath"]
+}
+
+var (
+	graphiteBlock = `\[\[outputs\.(?:graphite|socket_writer)\]\](?:.|\s)*?\[\[`
+	agentBlock    = `\[agent\](?:.|\s)*?\[\[`
+	prefixRegex   = regexp.MustCompile(`prefix\s*=\s*"(.*?)"`)
+)
+
+func graphiteOutputUpdate(apikey, configPath string, n naming.Naming, e endpoint.Endpoint, keepPrefix bool) error {
 	fullConfig, err := os.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("error reading file: %v", err)
 	}
 
-	graphiteBlock := `\[\[outputs\.graphite\]\](?:.|\s)*?\[\[`
-
-	updates := map[string]string{
-		`prefix\s*=\s*".*?"`:    fmt.Sprintf(`prefix = "%s.telegraf"`, apikey),
-		`servers\s*=\s*\[.*?\]`: `servers = ["carbon.hostedgraphite.com:2003"]`,
-		`template\s*=\s*".*?"`:  `## template = "host.tags.measurement.field"`,
+	prefix := n.MetricPrefix(apikey, "telegraf")
+	if keepPrefix && !n.HasPrefix() {
+		block := regexp.MustCompile(graphiteBlock).FindString(string(fullConfig))
+		if match := prefixRegex.FindStringSubmatch(block); match != nil {
+			prefix = naming.ReplaceKey(match[1], apikey, "telegraf")
+		}
 	}
-	updatedConfig, err := utils.UpdateConfigBlock(string(fullConfig), graphiteBlock, updates)
+
+	updatedConfig, err := utils.UpdateConfigBlock(string(fullConfig), graphiteBlock, graphiteOutputUpdates(prefix, n, e, keepPrefix))
 
 	if err != nil {
 		return fmt.Errorf("error during updating: %v", err)
 	}
 
+	if n.HasHostname() {
+		hostname, err := n.ResolveHostname()
+		if err != nil {
+			return err
+		}
+
+		updatedConfig, err = utils.UpdateConfigBlock(updatedConfig, agentBlock, hostnameUpdates(hostname))
+		if err != nil {
+			return fmt.Errorf("error during updating: %v", err)
+		}
+	}
+
 	err = os.WriteFile(configPath, []byte(updatedConfig), 0644)
 
 	if err != nil {
@@ -119,6 +302,88 @@ func graphiteOutputUpdate(apikey, configPath string) error {
 	return nil
 }
 
+// graphiteOutputScript is graphiteOutputUpdate for exported scripts, the
+// config only exists on the target so the prefix can't be kept.
+func graphiteOutputScript(shell, apikey, configPath string, n naming.Naming, e endpoint.Endpoint) (string, error) {
+	script := utils.UpdateConfigBlockScript(shell, configPath, graphiteBlock, graphiteOutputUpdates(n.MetricPrefix(apikey, "telegraf"), n, e, false))
+
+	if n.HasHostname() {
+		hostname, resolve, err := n.ScriptHostname(shell, false)
+		if err != nil {
+			return "", err
+		}
+
+		script += "\n" + utils.UpdateConfigBlockScript(shell, configPath, agentBlock, hostnameUpdates(hostname))
+		if resolve != "" {
+			script += "\n" + utils.ReplaceScript(shell, configPath, naming.HostnamePlaceholder, resolve)
+		}
+	}
+
+	return script, nil
+}
+
+func graphiteOutputUpdates(prefix string, n naming.Naming, e endpoint.Endpoint, keepPrefix bool) map[string]string {
+	updates := map[string]string{
+		`prefix\s*=\s*".*?"`: fmt.Sprintf(`prefix = "%s"`, prefix),
+	}
+
+	if e.IsSet() || !keepPrefix {
+		header, target := outputTarget(e)
+		updates[`\[\[outputs\.(?:graphite|socket_writer)\]\]`] = header
+		updates[outputTargetLines] = target
+	}
+
+	templateLine := `(?m)^[ \t]*#*[ \t]*template[ \t]*=[ \t]*".*?"`
+	if n.Template != "" {
+		updates[templateLine] = fmt.Sprintf(`  template = "%s"`, n.Template)
+	} else if !keepPrefix {
+		updates[templateLine] = `  ## template = "host.tags.measurement.field"`
+	}
+
+	tagSupportLine := `(?m)^[ \t]*#*[ \t]*graphite_tag_support[ \t]*=[ \t]*\w+`
+	if n.TagSupport {
+		updates[tagSupportLine] = `  graphite_tag_support = true`
+	}
+
+	return updates
+}
+
+func hostnameUpdates(hostname string) map[string]string {
+	return map[string]string{
+		`hostname\s*=\s*".*?"`: fmt.Sprintf(`hostname = "%s"`, hostname),
+	}
+}
+
+// outputTargetLines matches the servers/address line along with any of the
+// endpoint settings written straight after it on a previous run.
+var outputTargetLines = `(?m)^[ \t]*(?:servers|address)[ \t]*=.*(?:\n[ \t]*(?:data_format|tls_enable|tls_ca|insecure_skip_verify)[ \t]*=.*)*`
+
+// outputTarget returns the output header and the lines pointing it at the
+// endpoint. The graphite output only speaks tcp, so udp is sent through the
+// socket_writer output using the graphite data format.
+func outputTarget(e endpoint.Endpoint) (string, string) {
+	if e.Transport() == "udp" {
+		lines := []string{
+			fmt.Sprintf(`  address = "udp://%s"`, e.HostPort()),
+			`  data_format = "graphite"`,
+		}
+		return "[[outputs.socket_writer]]", strings.Join(lines, "\n")
+	}
+
+	lines := []string{fmt.Sprintf(`  servers = ["%s"]`, e.HostPort())}
+	if e.UseTLS() {
+		lines = append(lines, `  tls_enable = true`)
+		if e.TLSCA != "" {
+			lines = append(lines, fmt.Sprintf(`  tls_ca = "%s"`, e.TLSCA))
+		}
+		if e.InsecureSkipVerify {
+			lines = append(lines, `  insecure_skip_verify = true`)
+		}
+	}
+
+	return "[[outputs.graphite]]", strings.Join(lines, "\n")
+}
+
 func (t *Telegraf) UninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
 	var err error
 	var sysInfo = t.sysinfo
@@ -148,13 +413,33 @@ func (t *Telegraf) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.
 	var sysInfo = t.sysinfo
 	var pipes []*pipeline.Pipe
 
+	if err = t.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if err = t.endpoint.Validate(); err != nil {
+		return nil, err
+	}
+	if err = t.validateKeyEnvFile(); err != nil {
+		return nil, err
+	}
+	// Installs that keep the key in the environment file get the new key there.
+	if sysInfo.Os == "linux" && service.ReferencesApiKey(t.configPath()) {
+		t.keyEnvFile = true
+	}
+
 	switch sysInfo.Os {
 	case "linux", "darwin", "windows":
-		pipes = t.graphiteOutputUpdatePipe()
+		pipes = t.graphiteOutputUpdatePipe(true)
 	default:
 		return nil, fmt.Errorf("unsupported operating system: %v", err)
 	}
 
+	secretPipes, err := t.secretPipes()
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, secretPipes...)
+
 	pipeline := pipeline.NewPipeline(fmt.Sprintf("Updating HostedGraphite Api Key (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
 
 	return &pipeline, err
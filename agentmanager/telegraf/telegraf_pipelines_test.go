@@ -0,0 +1,252 @@
+package telegraf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleConfig = `[agent]
+  interval = "10s"
+  ## Override default hostname, if empty use os.Hostname()
+  hostname = ""
+  omit_hostname = false
+
+[[outputs.graphite]]
+  servers = ["localhost:2003"]
+
+  ## Prefix metrics name
+  prefix = ""
+
+  ## Graphite output template
+  template = "host.tags.measurement.field"
+
+  ## Enable Graphite tags support
+  # graphite_tag_support = false
+
+[[inputs.cpu]]
+  percpu = true
+`
+
+func writeSampleConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(path, []byte(sampleConfig), 0644))
+	return path
+}
+
+func readConfig(t *testing.T, path string) string {
+	config, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(config)
+}
+
+func TestGraphiteOutputUpdateDefaults(t *testing.T) {
+	path := writeSampleConfig(t)
+
+	require.NoError(t, graphiteOutputUpdate("key", path, naming.Naming{}, endpoint.Endpoint{}, false))
+
+	config := readConfig(t, path)
+	require.Contains(t, config, `prefix = "key.telegraf"`)
+	require.Contains(t, config, `servers = ["carbon.hostedgraphite.com:2003"]`)
+	require.Contains(t, config, `  ## template = "host.tags.measurement.field"`)
+	require.Contains(t, config, `hostname = ""`)
+}
+
+func TestGraphiteOutputUpdateNaming(t *testing.T) {
+	path := writeSampleConfig(t)
+	n := naming.Naming{
+		Segments:   []string{"prod", "core"},
+		Hostname:   "web-1",
+		Template:   "host.measurement.field",
+		TagSupport: true,
+	}
+
+	require.NoError(t, graphiteOutputUpdate("key", path, n, endpoint.Endpoint{}, false))
+
+	config := readConfig(t, path)
+	require.Contains(t, config, `prefix = "key.prod.core.telegraf"`)
+	require.Contains(t, config, `  template = "host.measurement.field"`)
+	require.Contains(t, config, `  graphite_tag_support = true`)
+	require.Contains(t, config, `hostname = "web-1"`)
+}
+
+func TestGraphiteOutputUpdateKeepsPrefix(t *testing.T) {
+	path := writeSampleConfig(t)
+
+	require.NoError(t, graphiteOutputUpdate("old", path, naming.Naming{Segments: []string{"prod"}}, endpoint.Endpoint{}, false))
+	require.NoError(t, graphiteOutputUpdate("new", path, naming.Naming{}, endpoint.Endpoint{}, true))
+
+	config := readConfig(t, path)
+	require.Contains(t, config, `prefix = "new.prod.telegraf"`)
+	require.Contains(t, config, `  ## template = "host.tags.measurement.field"`)
+	require.NotContains(t, config, `## ## template`)
+}
+
+func TestGraphiteOutputUpdateEndpoint(t *testing.T) {
+	path := writeSampleConfig(t)
+
+	tls := endpoint.Endpoint{Mode: endpoint.TLS, TLSCA: "/etc/ssl/certs/ca.pem"}
+	require.NoError(t, graphiteOutputUpdate("key", path, naming.Naming{}, tls, false))
+
+	config := readConfig(t, path)
+	require.Contains(t, config, "[[outputs.graphite]]\n  servers = [\"carbon.hostedgraphite.com:20030\"]\n  tls_enable = true\n  tls_ca = \"/etc/ssl/certs/ca.pem\"\n")
+
+	udp := endpoint.Endpoint{Mode: endpoint.UDP}
+	require.NoError(t, graphiteOutputUpdate("key", path, naming.Naming{}, udp, true))
+
+	config = readConfig(t, path)
+	require.Contains(t, config, "[[outputs.socket_writer]]\n  address = \"udp://carbon.hostedgraphite.com:2003\"\n  data_format = \"graphite\"\n")
+	require.NotContains(t, config, "tls_enable")
+	require.Contains(t, config, `prefix = "key.telegraf"`)
+
+	// Updating the key alone leaves the endpoint as it was.
+	require.NoError(t, graphiteOutputUpdate("new", path, naming.Naming{}, endpoint.Endpoint{}, true))
+	config = readConfig(t, path)
+	require.Contains(t, config, "[[outputs.socket_writer]]")
+	require.Contains(t, config, `prefix = "new.telegraf"`)
+}
+
+// runStaged runs the pipes that write into the install root, skipping the
+// release download, and checks none of them touch a path outside of it.
+func runStaged(t *testing.T, root string, pipes []*pipeline.Pipe) {
+	for _, pipe := range pipes {
+		name := filepath.Base(pipe.Cmd.Path)
+		require.NotContains(t, []string{"apt-get", "systemctl", "groupadd", "useradd", "restorecon"}, name, pipe.Name)
+
+		// Releases aren't downloaded, but what would be moved out of the
+		// download still has to land in the root.
+		if name == "mv" {
+			dest := pipe.Cmd.Args[len(pipe.Cmd.Args)-1]
+			require.True(t, strings.HasPrefix(dest, root), "%s moves to %s", pipe.Name, dest)
+		}
+		if slices.Contains([]string{"wget", "tar", "mv"}, name) || strings.Contains(strings.Join(pipe.Cmd.Args, " "), "/tmp/hg-cli") {
+			continue
+		}
+		for _, arg := range pipe.Cmd.Args[1:] {
+			if strings.HasPrefix(arg, "/") {
+				require.True(t, strings.HasPrefix(arg, root), "%s writes to %s", pipe.Name, arg)
+			}
+		}
+
+		_, err := pipe.Run()
+		require.NoError(t, err, pipe.Name)
+	}
+}
+
+func TestStagedInstallPipeline(t *testing.T) {
+	root := t.TempDir()
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64", PkgMngr: "apt", InitSystem: sysinfo.InitSysV}
+	options := map[string]interface{}{
+		"apikey":       "key",
+		"plugins":      []string{"cpu"},
+		"version":      "1.33.1",
+		"startService": true,
+	}
+
+	agent := NewTelegrafAgent(options, host.Staged(root))
+	configPath := filepath.Join(root, "etc/telegraf/telegraf.conf")
+	require.Equal(t, configPath, agent.serviceSettings["configPath"])
+
+	// Stands in for the downloaded binary generating the config.
+	bin := filepath.Join(root, "usr/bin/telegraf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(bin), 0755))
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\ncat <<'EOF'\n"+sampleConfig+"EOF\n"), 0755))
+
+	p, err := agent.InstallPipeline(nil)
+	require.NoError(t, err)
+	runStaged(t, root, p.Pipes)
+
+	config := readConfig(t, configPath)
+	require.Contains(t, config, `prefix = "key.telegraf"`)
+	require.Contains(t, config, `servers = ["carbon.hostedgraphite.com:2003"]`)
+}
+
+func TestStagedUninstallPipeline(t *testing.T) {
+	root := t.TempDir()
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64"}.Staged(root)
+
+	files := []string{
+		"usr/bin/telegraf",
+		"etc/systemd/system/telegraf.service",
+		"etc/hg-cli/telegraf.env",
+		"etc/systemd/system/telegraf.service.d/hg-cli.conf",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	p, err := NewTelegrafAgent(map[string]interface{}{}, host).UninstallPipeline(nil)
+	require.NoError(t, err)
+	runStaged(t, root, p.Pipes)
+
+	for _, file := range files {
+		require.NoFileExists(t, filepath.Join(root, file))
+	}
+}
+
+func TestUpdateApiKeyEnvFile(t *testing.T) {
+	path := writeSampleConfig(t)
+	require.NoError(t, graphiteOutputUpdate("${HG_API_KEY}", path, naming.Naming{Segments: []string{"prod"}}, endpoint.Endpoint{}, false))
+
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64", PkgMngr: "apt", InitSystem: sysinfo.InitSystemd}
+	agent := NewTelegrafAgent(map[string]interface{}{"apikey": "new", "config": path}, host)
+
+	p, err := agent.UpdateApiKeyPipeline(nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, pipe := range p.Pipes {
+		names = append(names, pipe.Name)
+	}
+	require.Contains(t, names, "Writing Telegraf Environment File")
+	require.Equal(t, "${HG_API_KEY}", agent.configKey())
+
+	require.NoError(t, graphiteOutputUpdate(agent.configKey(), path, naming.Naming{}, endpoint.Endpoint{}, true))
+	require.Contains(t, readConfig(t, path), `prefix = "${HG_API_KEY}.prod.telegraf"`)
+
+	host.InitSystem = sysinfo.InitOpenRC
+	_, err = NewTelegrafAgent(map[string]interface{}{"apikey": "new", "config": path}, host).UpdateApiKeyPipeline(nil)
+	require.Error(t, err)
+}
+
+func pipeNames(p *pipeline.Pipeline) []string {
+	var names []string
+	for _, pipe := range p.Pipes {
+		names = append(names, pipe.Name)
+	}
+	return names
+}
+
+func TestContainerPipelineEnvFile(t *testing.T) {
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64"}
+	agent := NewTelegrafAgent(map[string]interface{}{"apikey": "key", "plugins": []string{"cpu"}}, host)
+
+	p, err := agent.ContainerPipeline(nil)
+	require.NoError(t, err)
+	require.Contains(t, pipeNames(p), "Writing /etc/hg-cli/telegraf/telegraf.env")
+	require.Equal(t, "${HG_API_KEY}", agent.configKey())
+	require.Contains(t, agent.container().RunArgs(), "--env-file")
+}
+
+func TestUserInstallPipelineConfigPerms(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	host := sysinfo.SysInfo{Os: "linux", Arch: "amd64"}
+	agent := NewTelegrafAgent(map[string]interface{}{"apikey": "key", "plugins": []string{"cpu"}, "version": "1.33.1"}, host)
+
+	p, err := agent.UserInstallPipeline(nil)
+	require.NoError(t, err)
+	require.Contains(t, pipeNames(p), "Restricting Telegraf Config Permissions")
+}
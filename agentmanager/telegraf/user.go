@@ -0,0 +1,85 @@
+package telegraf
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	telegrafPipes "github.com/hostedgraphite/hg-cli/agentmanager/telegraf/pipes"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// userSettings are the service settings of a user scope install.
+func (t *Telegraf) userSettings(dirs service.UserDirs) map[string]string {
+	configPath := filepath.Join(dirs.Config, "telegraf", "telegraf.conf")
+	startHint, restartHint := telegrafPipes.UserService(dirs, configPath).UserHints(t.sysinfo.Os)
+
+	return map[string]string{
+		"configPath":  configPath,
+		"serviceCmd":  filepath.Join(dirs.Bin, "telegraf"),
+		"startHint":   startHint,
+		"restartHint": restartHint,
+	}
+}
+
+func (t *Telegraf) UserSettings() map[string]string {
+	dirs, _ := service.CurrentUserDirs()
+	return t.userSettings(dirs)
+}
+
+// UserInstallPipeline installs telegraf for the current user, without root,
+// under the XDG directories with a systemd user unit or launchd agent.
+func (t *Telegraf) UserInstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = t.sysinfo
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	if sysInfo.Os != "linux" && sysInfo.Os != "darwin" {
+		return nil, fmt.Errorf("user installs are only supported on linux and darwin")
+	}
+	if t.keyEnvFile {
+		return nil, fmt.Errorf("the api key environment file needs a root install, user installs keep the key in their own config")
+	}
+
+	dirs, err := service.CurrentUserDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	// The config pipes read the paths from the service settings.
+	t.serviceSettings = t.userSettings(dirs)
+	configPath := t.serviceSettings["configPath"]
+	version, _ := t.options["version"].(string)
+
+	pipes := telegrafPipes.UserInstallPipes(sysInfo, version, configPath, dirs)
+
+	configPipes, err := t.configPipeline()
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, configPipes...)
+
+	svc := telegrafPipes.UserService(dirs, configPath)
+	pipes = append(pipes, svc.UserConfigPermsPipes(configPath)...)
+	pipes = append(pipes, svc.UserInstallPipes(sysInfo.Os, dirs)...)
+	if start, _ := t.options["startService"].(bool); start {
+		pipes = append(pipes, svc.UserRestartPipes(sysInfo.Os, dirs)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Telegraf Agent (%s-user)", sysInfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (t *Telegraf) UserUninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	dirs, err := service.CurrentUserDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	pipes := telegrafPipes.UserUninstallPipes(t.sysinfo, dirs)
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Telegraf Agent (%s-user)", t.sysinfo.Os), pipes, updates)
+
+	return &pipeline, nil
+}
@@ -0,0 +1,195 @@
+package telegraf
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/tui/views/config"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	selectedInstall  string
+	selectedPlugins  []string
+	confirmUninstall bool
+	path             string
+	header           string
+	sysInfo          sysinfo.SysInfo
+	output           forms.Output
+	statsd           bool
+	statsdPort       string
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header:  styles.MfAndTelegrafTitle,
+		apikey:  forms.DefaultApiKey,
+		sysInfo: sysInfo,
+		output:  forms.ProfileOutput(),
+	}
+}
+
+func (t *views) InstallView() (*huh.Group, error) {
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(t.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				err := utils.ValidateAPIKey(t.apikey)
+				if err != nil {
+					return err
+				}
+				return nil
+			}).
+			Value(&t.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewSelect[string]().
+			Key("installType").
+			Title("Select Install Type").
+			Options(huh.NewOptions("Default", "Custom")...).
+			Value(&t.selectedInstall),
+
+		huh.NewMultiSelect[string]().
+			Key("plugins").
+			Title("Select Plugins").
+			DescriptionFunc(func() string {
+				if t.selectedInstall == "Custom" {
+					return "Plugins are grouped by category, press / to search."
+				}
+				return ""
+			}, &t.selectedInstall).
+			Value(&t.selectedPlugins).
+			OptionsFunc(func() []huh.Option[string] {
+				catalog, err := config.LoadPlugins()
+				if err != nil {
+					return nil
+				}
+				switch t.selectedInstall {
+				case "Custom":
+					var options []huh.Option[string]
+					for _, plugin := range catalog.ForOs(t.sysInfo.Os) {
+						options = append(options, huh.NewOption(plugin.Label(), plugin.Name))
+					}
+					return options
+				default:
+					defaultPlugins := catalog.SupportedOn(t.sysInfo.Os, DefaultTelegrafPlugins)
+					options := huh.NewOptions(defaultPlugins...)
+					for i := range options {
+						options[i] = options[i].Selected(true)
+					}
+					return options
+				}
+			}, &t.selectedInstall).
+			Validate(func(plugins []string) error {
+				catalog, err := config.LoadPlugins()
+				if err != nil {
+					return err
+				}
+				return catalog.ValidateSelection(t.sysInfo.Os, plugins)
+			}),
+
+		huh.NewConfirm().
+			Key("statsd").
+			Title("Add a local StatsD listener?").
+			Description("Lets applications on this host send StatsD metrics through telegraf").
+			Value(&t.statsd),
+
+		huh.NewInput().
+			Key("statsdPort").
+			Title("StatsD Port").
+			Prompt("Port: ").
+			Placeholder(strconv.Itoa(DefaultStatsDPort)).
+			Value(&t.statsdPort).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				port, err := strconv.Atoi(s)
+				if err != nil {
+					return fmt.Errorf("invalid port %q", s)
+				}
+				return StatsD{Port: port}.Validate()
+			}),
+	)
+
+	return installGroup, nil
+}
+func (t *views) InstallOptions(form *huh.Form, options map[string]interface{}) {
+	plugins, _ := form.Get("plugins").([]string)
+	options["plugins"] = plugins
+	options["statsd"] = t.statsd
+	if port, err := strconv.Atoi(t.statsdPort); err == nil {
+		options["statsdPort"] = port
+	}
+}
+
+func (t *views) OutputView() (*huh.Group, error) {
+	return forms.OutputGroup(&t.output, true, endpoint.Modes...), nil
+}
+
+func (t *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(t.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall Telegraf?").
+			Description("This will remove the agent, but not the configuration files").
+			Value(&t.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+func (t *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(t.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				err := utils.ValidateAPIKey(t.apikey)
+				if err != nil {
+					return err
+				}
+				return nil
+			}).
+			Value(&t.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the Telegraf configuration file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&t.path).
+			Validate(func(s string) error {
+				if s == "" {
+					s = defaultPath
+				}
+				err := ValidateFilePath(s)
+				if err != nil {
+					return err
+				}
+				return nil
+			}),
+	)
+
+	return updateGroup, nil
+}
@@ -1,6 +1,7 @@
 package agentmanager
 
 import (
+	"github.com/hostedgraphite/hg-cli/agentmanager/kubernetes"
 	"github.com/hostedgraphite/hg-cli/pipeline"
 )
 
@@ -8,4 +9,32 @@ type Agent interface {
 	InstallPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
 	UninstallPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
 	UpdateApiKeyPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
+	ConfigurePipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
+	IsInstalled() bool
+}
+
+// ContainerAgent is implemented by agents that can run as a container
+// rather than be installed on the host.
+type ContainerAgent interface {
+	ContainerPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
+	// ContainerSettings are the service settings for the container, used in
+	// summaries in place of the host ones.
+	ContainerSettings() map[string]string
+}
+
+// UserAgent is implemented by agents that can be installed for the current
+// user, without root.
+type UserAgent interface {
+	UserInstallPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
+	UserUninstallPipeline(chan *pipeline.Pipe) (*pipeline.Pipeline, error)
+	// UserSettings are the service settings for the user install, used in
+	// summaries in place of the host ones.
+	UserSettings() map[string]string
+}
+
+// KubernetesAgent is implemented by agents that can be deployed to a cluster
+// with `hg-cli k8s manifest`. The cluster workload is nil unless cluster wide
+// metrics were asked for.
+type KubernetesAgent interface {
+	KubernetesWorkloads() (kubernetes.Workload, *kubernetes.Workload, error)
 }
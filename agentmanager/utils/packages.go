@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"os/exec"
+)
+
+// PackageInstallCmd installs packages without prompting.
+func PackageInstallCmd(pkgMngr string, packages ...string) *exec.Cmd {
+	switch pkgMngr {
+	case "apt":
+		return exec.Command("apt-get", append([]string{"install", "-y"}, packages...)...)
+	case "zypper":
+		return exec.Command("zypper", append([]string{"--non-interactive", "install"}, packages...)...)
+	case "apk":
+		return exec.Command("apk", append([]string{"add", "--no-cache"}, packages...)...)
+	case "pacman":
+		return exec.Command("pacman", append([]string{"-S", "--noconfirm", "--needed"}, packages...)...)
+	}
+	return exec.Command(pkgMngr, append([]string{"install", "-y"}, packages...)...)
+}
+
+// PackageRemoveCmd removes packages without prompting.
+func PackageRemoveCmd(pkgMngr string, packages ...string) *exec.Cmd {
+	switch pkgMngr {
+	case "apt":
+		return exec.Command("apt-get", append([]string{"remove", "-y"}, packages...)...)
+	case "zypper":
+		return exec.Command("zypper", append([]string{"--non-interactive", "remove"}, packages...)...)
+	case "apk":
+		return exec.Command("apk", append([]string{"del"}, packages...)...)
+	case "pacman":
+		return exec.Command("pacman", append([]string{"-R", "--noconfirm"}, packages...)...)
+	}
+	return exec.Command(pkgMngr, append([]string{"remove", "-y"}, packages...)...)
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// WriteFileScript is the exported script equivalent of os.WriteFile.
+func WriteFileScript(shell, path string, content []byte, perm os.FileMode) string {
+	return fileScript(shell, path, content, perm, false)
+}
+
+// AppendFileScript appends content to an existing file in an exported script.
+func AppendFileScript(shell, path string, content []byte) string {
+	return fileScript(shell, path, content, 0, true)
+}
+
+func fileScript(shell, path string, content []byte, perm os.FileMode, appendTo bool) string {
+	text := string(content)
+
+	if shell == pipeline.PowerShell {
+		method := "WriteAllText"
+		if appendTo {
+			method = "AppendAllText"
+		}
+		// Here-strings drop the line break before the closing '@.
+		return fmt.Sprintf("[IO.File]::%s(%s, @'\n%s\n'@)", method, pipeline.PowerShellQuote(path), text)
+	}
+
+	redirect := ">"
+	if appendTo {
+		redirect = ">>"
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	script := fmt.Sprintf("cat %s %s <<'HG_EOF'\n%sHG_EOF", redirect, pipeline.ShellQuote(path), text)
+	if !appendTo {
+		script += fmt.Sprintf("\nchmod %o %s", perm, pipeline.ShellQuote(path))
+	}
+	return script
+}
+
+// UpdateConfigBlockScript is the exported script equivalent of reading path,
+// applying UpdateConfigBlock and writing it back. Patterns are passed through
+// the environment so they don't need escaping for perl.
+func UpdateConfigBlockScript(shell, path, confBlock string, updates map[string]string) string {
+	patterns := make([]string, 0, len(updates))
+	for pattern := range updates {
+		patterns = append(patterns, pattern)
+	}
+	slices.Sort(patterns)
+
+	if shell == pipeline.PowerShell {
+		lines := []string{
+			fmt.Sprintf("$config = [IO.File]::ReadAllText(%s)", pipeline.PowerShellQuote(path)),
+			fmt.Sprintf("$match = [regex]::Match($config, %s)", pipeline.PowerShellQuote(confBlock)),
+			`if (-not $match.Success) { throw "no matching configuration block found" }`,
+			"$block = $match.Value",
+		}
+		for _, pattern := range patterns {
+			// $ starts a substitution in .NET replacements.
+			replacement := strings.ReplaceAll(updates[pattern], "$", "$$")
+			lines = append(lines, fmt.Sprintf("$block = [regex]::Replace($block, %s, %s)", pipeline.PowerShellQuote(pattern), pipeline.PowerShellQuote(replacement)))
+		}
+		lines = append(lines,
+			"$config = $config.Replace($match.Value, $block)",
+			fmt.Sprintf("[IO.File]::WriteAllText(%s, $config)", pipeline.PowerShellQuote(path)),
+		)
+		return strings.Join(lines, "\n")
+	}
+
+	env := []string{"HG_BLOCK=" + pipeline.ShellQuote(confBlock)}
+	code := []string{"my ($b, $u) = ($&, $&);"}
+	for i, pattern := range patterns {
+		env = append(env,
+			fmt.Sprintf("HG_PATTERN_%d=%s", i, pipeline.ShellQuote(pattern)),
+			fmt.Sprintf("HG_REPLACE_%d=%s", i, pipeline.ShellQuote(updates[pattern])),
+		)
+		code = append(code, fmt.Sprintf("$u =~ s/$ENV{HG_PATTERN_%d}/$ENV{HG_REPLACE_%d}/g;", i, i))
+	}
+	code = append(code, `s/\Q$b\E/$u/g;`)
+
+	perl := fmt.Sprintf(`if (/$ENV{HG_BLOCK}/) { %s } else { die "no matching configuration block found\n" }`, strings.Join(code, " "))
+
+	return fmt.Sprintf("%s \\\n  perl -0pi -e %s %s", strings.Join(env, " \\\n  "), pipeline.ShellQuote(perl), pipeline.ShellQuote(path))
+}
+
+// ReplaceScript replaces placeholder in path with the output of a shell
+// expression evaluated on the target host.
+func ReplaceScript(shell, path, placeholder, expression string) string {
+	if shell == pipeline.PowerShell {
+		return fmt.Sprintf("$value = %s\n[IO.File]::WriteAllText(%s, [IO.File]::ReadAllText(%s).Replace(%s, $value))",
+			expression, pipeline.PowerShellQuote(path), pipeline.PowerShellQuote(path), pipeline.PowerShellQuote(placeholder))
+	}
+
+	return fmt.Sprintf("HG_VALUE=\"$(%s)\" perl -pi -e %s %s",
+		expression, pipeline.ShellQuote(fmt.Sprintf("s/%s/$ENV{HG_VALUE}/g", placeholder)), pipeline.ShellQuote(path))
+}
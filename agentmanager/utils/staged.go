@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// StagedSettings points the config path of the service settings, and the
+// serviceCmd when given, inside the install root.
+func StagedSettings(sysInfo sysinfo.SysInfo, settings map[string]string, serviceCmd string) map[string]string {
+	if sysInfo.Root == "" {
+		return settings
+	}
+
+	staged := maps.Clone(settings)
+	staged["configPath"] = sysInfo.Path(settings["configPath"])
+	if serviceCmd != "" {
+		staged["serviceCmd"] = sysInfo.Path(serviceCmd)
+	}
+	return staged
+}
+
+// StagedDirsPipes create the directories an install moves files into, a
+// fresh root doesn't have them yet.
+func StagedDirsPipes(sysInfo sysinfo.SysInfo, dirs ...string) []*pipeline.Pipe {
+	if sysInfo.Root == "" {
+		return nil
+	}
+
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = sysInfo.Path(dir)
+	}
+	return []*pipeline.Pipe{
+		{
+			Name: "Creating Directories in " + sysInfo.Root,
+			Cmd:  exec.Command("mkdir", append([]string{"-p"}, paths...)...),
+		},
+	}
+}
+
+// Staged installs add agent users to the root's passwd and group files with
+// --prefix. Roots without an /etc/passwd, such as a bare test directory, get
+// no users and keep the files owned by whoever ran the install.
+
+func stagedPasswd(root string) string {
+	return pipeline.ShellQuote(path.Join(root, "/etc/passwd"))
+}
+
+// UserAddCmd creates a system user unless it already exists, args are passed
+// on to useradd.
+func UserAddCmd(root, name string, args ...string) *exec.Cmd {
+	useradd := strings.Join(append(args, name), " ")
+	if root == "" {
+		return exec.Command("sh", "-c", fmt.Sprintf("id %s >/dev/null 2>&1 || useradd %s", name, useradd))
+	}
+
+	passwd := stagedPasswd(root)
+	return exec.Command("sh", "-c", fmt.Sprintf("test ! -f %s || grep -q '^%s:' %s || useradd --prefix %s %s", passwd, name, passwd, pipeline.ShellQuote(root), useradd))
+}
+
+// UserDelCmd removes a user created by UserAddCmd.
+func UserDelCmd(root, name string) *exec.Cmd {
+	if root == "" {
+		return exec.Command("userdel", name)
+	}
+	return exec.Command("sh", "-c", fmt.Sprintf("test ! -f %s || userdel --prefix %s %s", stagedPasswd(root), pipeline.ShellQuote(root), name))
+}
+
+// ChownScript recursively hands paths to an owner, user:group or :group.
+// Staged installs look the ids up in the root, the names may not exist on
+// this host.
+func ChownScript(root, owner string, paths ...string) string {
+	if root == "" {
+		return fmt.Sprintf("chown -R %s %s", owner, strings.Join(paths, " "))
+	}
+
+	id := func(name, file string) string {
+		if name == "" {
+			return ""
+		}
+		return fmt.Sprintf(`$(awk -F: '$1=="%s"{print $3}' %s)`, name, pipeline.ShellQuote(path.Join(root, file)))
+	}
+	user, group, _ := strings.Cut(owner, ":")
+
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = pipeline.ShellQuote(path.Join(root, p))
+	}
+	return fmt.Sprintf("if [ -f %s ]; then chown -R %s:%s %s; fi", stagedPasswd(root), id(user, "/etc/passwd"), id(group, "/etc/group"), strings.Join(quoted, " "))
+}
+
+// RestrictScript makes a config readable by root and the group the agent
+// runs as only. The file is left as it is when the group doesn't exist,
+// rather than locking the agent out of its config. Staged installs look the
+// group up in the root.
+func RestrictScript(root, group, configPath string) string {
+	quoted := pipeline.ShellQuote(configPath)
+	if root == "" {
+		return fmt.Sprintf("if chgrp %s %s 2>/dev/null; then chmod 0640 %s; fi", group, quoted, quoted)
+	}
+
+	etcGroup := pipeline.ShellQuote(path.Join(root, "/etc/group"))
+	return fmt.Sprintf(`gid=$(awk -F: '$1=="%s"{print $3}' %s 2>/dev/null || true); if [ -n "$gid" ]; then chgrp "$gid" %s && chmod 0640 %s; fi`, group, etcGroup, quoted, quoted)
+}
@@ -6,22 +6,9 @@ import (
 	"io"
 	"net/http"
 	"regexp"
-	"slices"
+	"strings"
 )
 
-var agents = []string{"telegraf", "otel"}
-
-func ShowAvailableAgents() {
-	fmt.Println("Available agent: ")
-	for _, agent := range agents {
-		fmt.Println("- " + agent)
-	}
-}
-
-func ValidateAgent(agent string) bool {
-	return slices.Contains(agents, agent)
-}
-
 func UpdateConfigBlock(fullConfig, confBlock string, updates map[string]string) (string, error) {
 	configRegex := regexp.MustCompile(confBlock)
 	configBlock := configRegex.FindString(fullConfig)
@@ -32,16 +19,33 @@ func UpdateConfigBlock(fullConfig, confBlock string, updates map[string]string)
 		return "", fmt.Errorf("error: no matching graphite configuration found")
 	}
 
+	// Replacements are literal so values containing "$" (such as the
+	// collector's "$$0" rename) are written out as-is.
 	for regexPattern, replacement := range updates {
 		re := regexp.MustCompile(regexPattern)
-		updatedBlock = re.ReplaceAllString(updatedBlock, replacement)
+		updatedBlock = re.ReplaceAllLiteralString(updatedBlock, replacement)
 	}
 
-	updatedConfig := configRegex.ReplaceAllString(fullConfig, updatedBlock)
+	updatedConfig := configRegex.ReplaceAllLiteralString(fullConfig, updatedBlock)
 
 	return updatedConfig, nil
 }
 
+// ReleaseTag returns the requested version as a release tag, looking up the
+// latest release when no version was requested.
+func ReleaseTag(repo_org, repo_name, version, fallback string) string {
+	if version != "" {
+		return "v" + strings.TrimPrefix(version, "v")
+	}
+
+	latest, err := GetLatestReleaseTag(repo_org, repo_name)
+	if err != nil {
+		return fallback
+	}
+
+	return latest
+}
+
 func GetLatestReleaseTag(repo_org string, repo_name string) (string, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", repo_org, repo_name)
 	resp, err := http.Get(url)
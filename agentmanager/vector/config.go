@@ -0,0 +1,192 @@
+package vector
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var configTemplate = template.Must(template.New("vector.yaml").Parse(`# Generated by hg-cli.
+sources:
+  hg_cli_logs:
+    type: file
+    include:
+{{- range .Files}}
+      - "{{.}}"
+{{- end}}
+
+transforms:
+  hg_cli_parse:
+    type: remap
+    inputs: [hg_cli_logs]
+    source: |
+{{- if .Nginx}}
+      parsed, err = parse_nginx_log(.message, "combined")
+      if err == null {
+        .request = 1
+        .status_class = slice!(to_string(parsed.status), 0, 1) + "xx"
+      }
+{{- end}}
+{{- range .Matches}}
+      if match(string!(.message), r'{{.Pattern}}') {
+        .match_{{.Name}} = 1
+      }
+{{- end}}
+
+  hg_cli_counters:
+    type: log_to_metric
+    inputs: [hg_cli_parse]
+    metrics:
+{{- if .Nginx}}
+      - type: counter
+        field: request
+        namespace: nginx
+        name: requests
+        tags:
+          status: "{{"{{"}} status_class {{"}}"}}"
+{{- end}}
+{{- range .Matches}}
+      - type: counter
+        field: match_{{.Name}}
+        namespace: logs
+        name: {{.Name}}
+{{- end}}
+
+  hg_cli_aggregate:
+    type: aggregate
+    inputs: [hg_cli_counters]
+    interval_ms: {{.IntervalMs}}
+
+  hg_cli_to_log:
+    type: metric_to_log
+    inputs: [hg_cli_aggregate]
+
+  hg_cli_graphite:
+    type: remap
+    inputs: [hg_cli_to_log]
+    source: |
+      prefix = "{{.Prefix}}"
+      path = [prefix, .namespace, .name]
+      if exists(.tags.status) {
+        path = push(path, .tags.status)
+      }
+      .message = join!(compact(path), ".") + " " + to_string!(.counter.value) + " " + to_string(to_unix_timestamp(now()))
+
+sinks:
+  hg_cli_carbon:
+    type: socket
+    inputs: [hg_cli_graphite]
+    mode: {{.Protocol}}
+    address: "{{.Address}}"
+    encoding:
+      codec: text
+{{- if eq .Protocol "tcp"}}
+    framing:
+      method: newline_delimited
+{{- end}}
+{{- if .TLS}}
+    tls:
+      enabled: true
+{{- if .TLSCA}}
+      ca_file: "{{.TLSCA}}"
+{{- end}}
+{{- if .InsecureSkipVerify}}
+      verify_certificate: false
+{{- end}}
+{{- end}}
+`))
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Match counts the log lines matching Pattern as the logs.<Name> metric.
+type Match struct {
+	Name    string
+	Pattern string
+}
+
+// Config is the vector.yaml hg-cli writes: tail the log files, turn matching
+// lines into counters and send them to the carbon endpoint.
+type Config struct {
+	Files    []string
+	Format   string
+	Matches  []Match
+	Interval string
+	// Prefix is the full metric prefix, api key included.
+	Prefix             string
+	Address            string
+	Protocol           string
+	TLS                bool
+	TLSCA              string
+	InsecureSkipVerify bool
+}
+
+// NewMatches turns the name=pattern options into matches, sorted by name so
+// the config is stable.
+func NewMatches(matches map[string]string) []Match {
+	var result []Match
+	for name, pattern := range matches {
+		result = append(result, Match{Name: name, Pattern: pattern})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (c Config) Validate() error {
+	if len(c.Files) == 0 {
+		return fmt.Errorf("at least one log file is required")
+	}
+	for _, file := range c.Files {
+		if strings.ContainsAny(file, `"`) {
+			return fmt.Errorf("invalid log file %q", file)
+		}
+	}
+
+	if !slices.Contains(Formats, c.Format) {
+		return fmt.Errorf("invalid log format %q (available: %s)", c.Format, strings.Join(Formats, ", "))
+	}
+	if c.Format == FormatPlain && len(c.Matches) == 0 {
+		return fmt.Errorf("the plain log format needs at least one log match")
+	}
+
+	for _, match := range c.Matches {
+		if !nameRegex.MatchString(match.Name) {
+			return fmt.Errorf("invalid log match name %q: only letters, numbers and '_' are allowed", match.Name)
+		}
+		if strings.Contains(match.Pattern, "'") {
+			return fmt.Errorf("invalid log match pattern %q: single quotes aren't supported", match.Pattern)
+		}
+		if _, err := regexp.Compile(match.Pattern); err != nil {
+			return fmt.Errorf("invalid log match pattern %q: %v", match.Pattern, err)
+		}
+	}
+
+	if _, err := time.ParseDuration(c.Interval); err != nil {
+		return fmt.Errorf("invalid interval %q: %v", c.Interval, err)
+	}
+
+	return nil
+}
+
+func (c Config) Render() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	interval, _ := time.ParseDuration(c.Interval)
+	data := struct {
+		Config
+		Nginx      bool
+		IntervalMs int64
+	}{c, c.Format == FormatNginx, interval.Milliseconds()}
+
+	var buf bytes.Buffer
+	if err := configTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package vector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		Files:    []string{DefaultLogFile},
+		Format:   FormatNginx,
+		Matches:  NewMatches(map[string]string{"timeouts": "upstream timed out", "errors": `\[error\]`}),
+		Interval: "10s",
+		Prefix:   "key.vector",
+		Address:  "carbon.hostedgraphite.com:2003",
+		Protocol: "tcp",
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	rendered, err := testConfig().Render()
+	require.NoError(t, err)
+
+	config := string(rendered)
+	require.Contains(t, config, `      - "/var/log/nginx/access.log"`)
+	require.Contains(t, config, `parse_nginx_log(.message, "combined")`)
+	require.Contains(t, config, `status: "{{ status_class }}"`)
+	require.Contains(t, config, `if match(string!(.message), r'\[error\]') {`)
+	require.Contains(t, config, `interval_ms: 10000`)
+	require.Contains(t, config, `prefix = "key.vector"`)
+	require.Contains(t, config, `address: "carbon.hostedgraphite.com:2003"`)
+	require.Contains(t, config, `method: newline_delimited`)
+	require.NotContains(t, config, "tls:")
+
+	// Matches are sorted so the config is stable.
+	require.Less(t, strings.Index(config, "match_errors"), strings.Index(config, "match_timeouts"))
+}
+
+func TestRenderConfigTLS(t *testing.T) {
+	config := testConfig()
+	config.TLS = true
+	config.TLSCA = "/etc/ssl/ca.pem"
+
+	rendered, err := config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), `ca_file: "/etc/ssl/ca.pem"`)
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(*Config)
+	}{
+		{"No Files", func(c *Config) { c.Files = nil }},
+		{"Unknown Format", func(c *Config) { c.Format = "apache" }},
+		{"Plain Without Matches", func(c *Config) { c.Format, c.Matches = FormatPlain, nil }},
+		{"Bad Match Name", func(c *Config) { c.Matches = []Match{{Name: "5xx.errors", Pattern: "x"}} }},
+		{"Quoted Pattern", func(c *Config) { c.Matches = []Match{{Name: "errors", Pattern: "it's"}} }},
+		{"Bad Pattern", func(c *Config) { c.Matches = []Match{{Name: "errors", Pattern: "("}} }},
+		{"Bad Interval", func(c *Config) { c.Interval = "soon" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig()
+			test.update(&config)
+			require.Error(t, config.Validate())
+		})
+	}
+}
+
+func TestUpdateConfigKeepsSegments(t *testing.T) {
+	agent := NewVectorAgent(map[string]interface{}{"apikey": "key", "segments": []string{"prod"}, "hostname": "web01"}, sysinfo.SysInfo{Os: "linux"})
+	rendered, err := agent.config("web01").Render()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "vector.yaml")
+	require.NoError(t, os.WriteFile(path, rendered, 0640))
+
+	updater := NewVectorAgent(map[string]interface{}{"apikey": "new-key"}, sysinfo.SysInfo{Os: "linux"})
+	require.NoError(t, updater.updateConfig(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `prefix = "new-key.prod.vector.web01"`)
+}
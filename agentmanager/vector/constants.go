@@ -0,0 +1,40 @@
+package vector
+
+import (
+	"maps"
+
+	vectorPipes "github.com/hostedgraphite/hg-cli/agentmanager/vector/pipes"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+const (
+	// FormatNginx parses nginx combined logs into request counters by status
+	// class, FormatPlain only counts the --log-match lines.
+	FormatNginx = "nginx"
+	FormatPlain = "plain"
+
+	DefaultLogFile  = "/var/log/nginx/access.log"
+	DefaultInterval = "30s"
+)
+
+var Formats = []string{FormatNginx, FormatPlain}
+
+// The linux start and restart hints depend on the init system, see
+// GetServiceSettings.
+var ServiceDetails = map[string]map[string]string{
+	"linux": {
+		"configPath": "/etc/vector/vector.yaml",
+		"receiver":   "file",
+		"exporter":   "socket (carbon)",
+	},
+}
+
+func GetServiceSettings(sysInfo sysinfo.SysInfo) map[string]string {
+	if sysInfo.Os != "linux" {
+		return nil
+	}
+
+	settings := maps.Clone(ServiceDetails[sysInfo.Os])
+	settings["startHint"], settings["restartHint"], settings["enableHint"] = vectorPipes.LinuxHints(sysInfo)
+	return settings
+}
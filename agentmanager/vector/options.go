@@ -0,0 +1,46 @@
+package vector
+
+import (
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/spf13/pflag"
+)
+
+// Spec are the vector fields of an `hg-cli apply` spec.
+type Spec struct {
+	Interval string   `yaml:"interval"`
+	Logs     LogsSpec `yaml:"logs"`
+}
+
+// LogsSpec are the log files vector tails and the counters derived from them.
+type LogsSpec struct {
+	Files   []string          `yaml:"files"`
+	Format  string            `yaml:"format"`
+	Matches map[string]string `yaml:"matches"`
+}
+
+func (s *Spec) Options(options map[string]interface{}) {
+	options["interval"] = s.Interval
+	options["logFiles"] = s.Logs.Files
+	options["logFormat"] = s.Logs.Format
+	options["logMatches"] = s.Logs.Matches
+}
+
+func addFlags(flags *pflag.FlagSet) {
+	flags.String("collection-interval", DefaultInterval, "Collection interval, e.g. 30s or 1m")
+	flags.StringSlice("log-files", []string{DefaultLogFile}, "Log files vector tails (comma separated)")
+	flags.String("log-format", FormatNginx, "Log format: "+strings.Join(Formats, ", ")+", nginx counts requests by status class")
+	flags.StringToString("log-match", map[string]string{}, "Count lines matching a regex, e.g. errors=\\[error\\]")
+}
+
+func flagOptions(flags *pflag.FlagSet, options map[string]interface{}) {
+	options["interval"], _ = flags.GetString("collection-interval")
+	options["logFiles"], _ = flags.GetStringSlice("log-files")
+	options["logFormat"], _ = flags.GetString("log-format")
+	options["logMatches"], _ = flags.GetStringToString("log-match")
+}
+
+func validate(options map[string]interface{}, os string) error {
+	return NewVectorAgent(options, sysinfo.SysInfo{}).Validate()
+}
@@ -0,0 +1,195 @@
+package pipes
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+// The vector repositories are signed with Datadog's keys, apt only trusts
+// them for the vector repository.
+const (
+	aptKeyring = "/etc/apt/keyrings/vector.gpg"
+	aptRepo    = "deb [signed-by=" + aptKeyring + "] https://apt.vector.dev/ stable vector-0"
+	rpmKeyDir  = "/etc/pki/rpm-gpg"
+	tmpDir     = "/tmp/hg-cli"
+)
+
+var aptKeys = []string{
+	"https://keys.datadoghq.com/DATADOG_APT_KEY_CURRENT.public",
+	"https://keys.datadoghq.com/DATADOG_APT_KEY_C0962C7D.public",
+}
+
+var rpmKeys = []string{
+	"https://keys.datadoghq.com/DATADOG_RPM_KEY_CURRENT.public",
+	"https://keys.datadoghq.com/DATADOG_RPM_KEY_B01082D3.public",
+}
+
+// Fingerprints of the keys Datadog publishes for its repositories, the
+// CURRENT files hold whichever of them is in use. A fetched key with any
+// other primary key is refused.
+var (
+	aptFingerprints = []string{
+		"D18886567EABAD8B2D2526900D826EB906462314",
+		"5F1E256061D813B125E156E8E6266D4AC0962C7D",
+		"D75CEA17048B9ACBF186794B32637D44F14F620E",
+	}
+	rpmFingerprints = []string{
+		"2416A37757B1BB0268B3634B52AFC5994F09D16B",
+		"7408BFD56BC5BF0C361AAAE85D88EEA3B01082D3",
+		"C6559B690CA882F023BDF3F63F4EF9D1FD4BF915",
+	}
+)
+
+// pinnedKeyScript downloads a key to dest and removes it again unless every
+// primary key in it has one of the pinned fingerprints.
+func pinnedKeyScript(url, dest string, fingerprints []string) string {
+	listFingerprints := fmt.Sprintf(`gpg --show-keys --with-colons %s | awk -F: '$1=="pub"{p=1} $1=="fpr"&&p{print $10;p=0}'`, dest)
+	return fmt.Sprintf(`curl --tlsv1.2 -fsSL %s -o %s && fprs=$(%s) && test -n "$fprs" && for fpr in $fprs; do case "$fpr" in %s) ;; *) echo "unpinned key $fpr in %s" >&2; rm -f %s; exit 1;; esac; done`,
+		url, dest, listFingerprints, strings.Join(fingerprints, "|"), url, dest)
+}
+
+// yumRepo is the repository definition, checked against the verified keys in
+// rpmKeyDir.
+func yumRepo() string {
+	gpgKeys := make([]string, len(rpmKeys))
+	for i, key := range rpmKeys {
+		gpgKeys[i] = "file://" + path.Join(rpmKeyDir, path.Base(key))
+	}
+	return `[vector]
+name=Vector
+baseurl=https://yum.vector.dev/stable/vector-0/$basearch/
+enabled=1
+gpgcheck=1
+repo_gpgcheck=1
+gpgkey=` + strings.Join(gpgKeys, "\n       ")
+}
+
+// linuxService runs vector under the init system, the packages only ship a
+// systemd unit.
+func linuxService(root string) service.Service {
+	return service.Service{
+		Name:        "vector",
+		DisplayName: "Vector",
+		Description: "Vector observability data pipeline",
+		Command:     "/usr/bin/vector",
+		Args:        []string{"--config", "/etc/vector/vector.yaml"},
+		User:        "vector",
+		Root:        root,
+	}
+}
+
+// LinuxHints are the start, restart and enable commands for the host's init
+// system.
+func LinuxHints(sysInfo sysinfo.SysInfo) (string, string, string) {
+	return linuxService("").Hints(service.Init(sysInfo))
+}
+
+// LinuxInstallPipes installs vector from its apt or yum repository, an empty
+// version installs the latest release.
+func LinuxInstallPipes(sysInfo sysinfo.SysInfo, version string) ([]*pipeline.Pipe, error) {
+	var pipes []*pipeline.Pipe
+	version = strings.TrimPrefix(version, "v")
+
+	switch sysInfo.PkgMngr {
+	case "apt":
+		pkg := "vector"
+		if version != "" {
+			pkg = "vector=" + version + "-1"
+		}
+		// An empty keyring file makes gpg write the legacy format apt reads.
+		importKeys := fmt.Sprintf("mkdir -p %s %s && touch %s", path.Dir(aptKeyring), tmpDir, aptKeyring)
+		for _, key := range aptKeys {
+			keyPath := path.Join(tmpDir, path.Base(key))
+			importKeys += fmt.Sprintf(" && %s && gpg --no-default-keyring --keyring %s --batch --import %s && rm -f %s", pinnedKeyScript(key, keyPath, aptFingerprints), aptKeyring, keyPath, keyPath)
+		}
+		pipes = []*pipeline.Pipe{
+			{
+				Name: "Adding Vector apt Keys",
+				Cmd:  exec.Command("sh", "-c", importKeys),
+			},
+			{
+				Name: "Adding Vector apt Repository",
+				Cmd:  exec.Command("sh", "-c", "echo '"+aptRepo+"' > /etc/apt/sources.list.d/vector.list"),
+			},
+			{
+				Name: "Updating apt Packages",
+				Cmd:  exec.Command("apt-get", "update"),
+			},
+			{
+				Name: "Installing Vector",
+				Cmd:  exec.Command("apt-get", "install", "-y", pkg),
+			},
+			// Debian based distros leave web server logs readable by adm.
+			{
+				Name: "Adding vector to the adm group",
+				Cmd:  exec.Command("usermod", "-aG", "adm", "vector"),
+			},
+		}
+	case "yum", "dnf":
+		pkg := "vector"
+		if version != "" {
+			pkg = "vector-" + version + "-1"
+		}
+		fetchKeys := "mkdir -p " + rpmKeyDir
+		for _, key := range rpmKeys {
+			fetchKeys += " && " + pinnedKeyScript(key, path.Join(rpmKeyDir, path.Base(key)), rpmFingerprints)
+		}
+		pipes = []*pipeline.Pipe{
+			{
+				Name: "Adding Vector rpm Keys",
+				Cmd:  exec.Command("sh", "-c", fetchKeys),
+			},
+			{
+				Name: "Adding Vector yum Repository",
+				Cmd:  exec.Command("sh", "-c", "echo '"+yumRepo()+"' > /etc/yum.repos.d/vector.repo"),
+			},
+			{
+				Name: "Installing Vector",
+				Cmd:  exec.Command(sysInfo.PkgMngr, "install", "-y", pkg),
+			},
+		}
+	default:
+		return nil, fmt.Errorf("vector is only supported with apt, yum or dnf")
+	}
+
+	if init := service.Init(sysInfo); init != sysinfo.InitSystemd {
+		pipes = append(pipes, linuxService(sysInfo.Root).InstallPipes(init)...)
+	}
+
+	return pipes, nil
+}
+
+func LinuxUninstallPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	var pipes []*pipeline.Pipe
+	init := service.Init(sysInfo)
+	svc := linuxService(sysInfo.Root)
+
+	if service.Managed(sysInfo) {
+		pipes = svc.StopPipes(init)
+	}
+
+	pipes = append(pipes, &pipeline.Pipe{
+		Name: "Uninstalling Vector Agent",
+		Cmd:  exec.Command(sysInfo.PkgMngr, "remove", "-y", "vector"),
+	})
+
+	if init != sysinfo.InitSystemd {
+		pipes = append(pipes, svc.RemovePipes(init)...)
+	}
+
+	return pipes
+}
+
+func LinuxRestartPipes(sysInfo sysinfo.SysInfo) []*pipeline.Pipe {
+	if !service.Managed(sysInfo) {
+		return nil
+	}
+
+	return linuxService(sysInfo.Root).RestartPipes(service.Init(sysInfo))
+}
@@ -0,0 +1,55 @@
+package pipes
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// exportedKey generates a throwaway key and returns the armored public key
+// file and its fingerprint.
+func exportedKey(t *testing.T) (string, string) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("GNUPGHOME", home)
+
+	gpg := func(args ...string) string {
+		out, err := exec.Command("gpg", append([]string{"--batch"}, args...)...).Output()
+		require.NoError(t, err, strings.Join(args, " "))
+		return string(out)
+	}
+	gpg("--passphrase", "", "--quick-gen-key", "hg-cli test <test@example.com>", "ed25519", "sign", "never")
+
+	keyPath := filepath.Join(home, "key.public")
+	require.NoError(t, os.WriteFile(keyPath, []byte(gpg("--armor", "--export")), 0644))
+
+	for _, line := range strings.Split(gpg("--with-colons", "--fingerprint"), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "fpr" {
+			return keyPath, fields[9]
+		}
+	}
+	t.Fatal("no fingerprint")
+	return "", ""
+}
+
+func TestPinnedKeyScript(t *testing.T) {
+	keyPath, fingerprint := exportedKey(t)
+	dest := filepath.Join(t.TempDir(), "vector.public")
+
+	script := pinnedKeyScript("file://"+keyPath, dest, []string{rpmFingerprints[0], fingerprint})
+	out, err := exec.Command("sh", "-c", script).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.FileExists(t, dest)
+
+	script = pinnedKeyScript("file://"+keyPath, dest, rpmFingerprints)
+	out, err = exec.Command("sh", "-c", script).CombinedOutput()
+	require.Error(t, err)
+	require.Contains(t, string(out), "unpinned key "+fingerprint)
+	require.NoFileExists(t, dest)
+}
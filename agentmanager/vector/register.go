@@ -0,0 +1,33 @@
+package vector
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+func init() {
+	agentmanager.Register(agentmanager.Definition{
+		Name:        "vector",
+		DisplayName: "Vector",
+		Flags:       []string{"collection-interval", "log-files", "log-format", "log-match"},
+		New: func(options map[string]interface{}, sysInfo sysinfo.SysInfo) agentmanager.Agent {
+			return NewVectorAgent(options, sysInfo)
+		},
+		ServiceSettings: GetServiceSettings,
+		Summary: func(base formatters.ActionSummary, options map[string]interface{}) formatters.SummaryContent {
+			return &formatters.OtelContribSummary{
+				ActionSummary: base,
+				Receiver:      ServiceDetails["linux"]["receiver"],
+				Exporter:      ServiceDetails["linux"]["exporter"],
+			}
+		},
+		Validate: validate,
+		Spec: func() agentmanager.Spec {
+			return &Spec{}
+		},
+		AddFlags:    addFlags,
+		FlagOptions: flagOptions,
+		Fields:      newViews,
+	})
+}
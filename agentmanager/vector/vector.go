@@ -0,0 +1,76 @@
+package vector
+
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+type Vector struct {
+	apikey          string
+	sysinfo         sysinfo.SysInfo
+	options         map[string]interface{}
+	serviceSettings map[string]string
+	naming          naming.Naming
+	endpoint        endpoint.Endpoint
+	files           []string
+	format          string
+	matches         []Match
+	interval        string
+}
+
+func NewVectorAgent(options map[string]interface{}, sysInfo sysinfo.SysInfo) *Vector {
+	apikey, ok := options["apikey"].(string)
+	if !ok {
+		apikey = ""
+	}
+
+	files, _ := options["logFiles"].([]string)
+	if len(files) == 0 {
+		files = []string{DefaultLogFile}
+	}
+	format, _ := options["logFormat"].(string)
+	if format == "" {
+		format = FormatNginx
+	}
+	matches, _ := options["logMatches"].(map[string]string)
+	interval, _ := options["interval"].(string)
+	if interval == "" {
+		interval = DefaultInterval
+	}
+
+	agent := &Vector{
+		apikey:          apikey,
+		sysinfo:         sysInfo,
+		options:         options,
+		serviceSettings: GetServiceSettings(sysInfo),
+		naming:          naming.New(options),
+		endpoint:        endpoint.New(options),
+		files:           files,
+		format:          format,
+		matches:         NewMatches(matches),
+		interval:        interval,
+	}
+
+	return agent
+}
+
+func (v *Vector) config(hostname string) Config {
+	prefix := v.naming.MetricPrefix(v.apikey, "vector")
+	if hostname != "" {
+		prefix += "." + hostname
+	}
+
+	return Config{
+		Files:              v.files,
+		Format:             v.format,
+		Matches:            v.matches,
+		Interval:           v.interval,
+		Prefix:             prefix,
+		Address:            v.endpoint.HostPort(),
+		Protocol:           v.endpoint.Transport(),
+		TLS:                v.endpoint.UseTLS(),
+		TLSCA:              v.endpoint.TLSCA,
+		InsecureSkipVerify: v.endpoint.InsecureSkipVerify,
+	}
+}
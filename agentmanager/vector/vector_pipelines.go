@@ -0,0 +1,204 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	vectorPipes "github.com/hostedgraphite/hg-cli/agentmanager/vector/pipes"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+)
+
+// Validate checks the naming, endpoint and generated config options.
+func (v *Vector) Validate() error {
+	if err := v.naming.Validate(); err != nil {
+		return err
+	}
+	if v.naming.Template != "" || v.naming.TagSupport {
+		return fmt.Errorf("graphite templates and tag support are only supported by telegraf")
+	}
+	if err := v.endpoint.Validate(); err != nil {
+		return err
+	}
+	return v.config("").Validate()
+}
+
+func (v *Vector) InstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = v.sysinfo
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	if sysInfo.Os != "linux" {
+		return nil, fmt.Errorf("vector is only supported on linux")
+	}
+	if sysInfo.Root != "" {
+		return nil, fmt.Errorf("vector is installed from packages and can't be staged with --root")
+	}
+
+	version, _ := v.options["version"].(string)
+	pipes, err := vectorPipes.LinuxInstallPipes(sysInfo, version)
+	if err != nil {
+		return nil, err
+	}
+	pipes = append(pipes, v.configPipe()...)
+
+	if start, _ := v.options["startService"].(bool); start {
+		pipes = append(pipes, vectorPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Installing Vector Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (v *Vector) ConfigurePipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = v.sysinfo
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	pipes := v.configPipe()
+	if start, _ := v.options["startService"].(bool); start {
+		pipes = append(pipes, vectorPipes.LinuxRestartPipes(sysInfo)...)
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Configuring Vector Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (v *Vector) IsInstalled() bool {
+	if _, err := exec.LookPath("vector"); err != nil {
+		return false
+	}
+	info, err := os.Stat(v.serviceSettings["configPath"])
+	return err == nil && !info.IsDir()
+}
+
+func (v *Vector) configPipe() []*pipeline.Pipe {
+	configPath := v.serviceSettings["configPath"]
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Writing Vector vector.yaml", exec.Command("mkdir", "-p", "/etc/vector")).PostRun(
+			func(ctx context.Context) error {
+				hostname, err := v.naming.ResolveHostname()
+				if err != nil {
+					return err
+				}
+
+				config, err := v.config(hostname).Render()
+				if err != nil {
+					return err
+				}
+
+				return os.WriteFile(configPath, config, 0640)
+			},
+		).Script(
+			func(shell string) (string, error) {
+				hostname, resolve, err := v.naming.ScriptHostname(shell, false)
+				if err != nil {
+					return "", err
+				}
+
+				config, err := v.config(hostname).Render()
+				if err != nil {
+					return "", err
+				}
+
+				script := "mkdir -p /etc/vector\n" + utils.WriteFileScript(shell, configPath, config, 0640)
+				if resolve != "" {
+					script += "\n" + utils.ReplaceScript(shell, configPath, naming.HostnamePlaceholder, resolve)
+				}
+				return script, nil
+			},
+		),
+		// The config holds the api key, so it's only readable by the vector group.
+		pipeline.NewPipe("Setting vector.yaml permissions", exec.Command("chgrp", "vector", configPath)),
+	}
+	return pipes
+}
+
+func (v *Vector) UninstallPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = v.sysinfo
+
+	if sysInfo.Os != "linux" {
+		return nil, fmt.Errorf("vector is only supported on linux")
+	}
+	if sysInfo.Root != "" {
+		return nil, fmt.Errorf("vector is installed from packages and can't be staged with --root")
+	}
+
+	pipes := vectorPipes.LinuxUninstallPipes(sysInfo)
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Uninstalling Vector Agent (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+func (v *Vector) UpdateApiKeyPipeline(updates chan *pipeline.Pipe) (*pipeline.Pipeline, error) {
+	var sysInfo = v.sysinfo
+	var configPath string
+
+	if err := v.naming.Validate(); err != nil {
+		return nil, err
+	}
+	if v.endpoint.IsSet() {
+		return nil, fmt.Errorf("vector's endpoint can't be changed with update-apikey, use hg-cli apply to rewrite the config")
+	}
+
+	if v.options["config"] != nil {
+		configPath = v.options["config"].(string)
+	} else {
+		configPath = v.serviceSettings["configPath"]
+	}
+
+	pipes := []*pipeline.Pipe{
+		pipeline.NewPipe("Updating Vector vector.yaml", exec.Command("sleep", "1")).PostRun(
+			func(ctx context.Context) error {
+				return v.updateConfig(configPath)
+			},
+		),
+	}
+
+	pipeline := pipeline.NewPipeline(fmt.Sprintf("Updating HostedGraphite Api Key (%s-%s)", sysInfo.Os, sysInfo.PkgMngr), pipes, updates)
+
+	return &pipeline, nil
+}
+
+var prefixRegex = regexp.MustCompile(`prefix = "(.*?)"`)
+
+// updateConfig swaps the api key in the metric prefix, keeping any segments
+// and hostname unless new ones were given.
+func (v *Vector) updateConfig(configPath string) error {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+	updated := string(content)
+
+	match := prefixRegex.FindStringSubmatch(updated)
+	if match == nil {
+		return fmt.Errorf("no hg-cli metric prefix found in %s", configPath)
+	}
+
+	prefix := naming.ReplaceKey(match[1], v.apikey, "vector")
+	if v.naming.HasPrefix() || v.naming.HasHostname() {
+		hostname, err := v.naming.ResolveHostname()
+		if err != nil {
+			return err
+		}
+		prefix = v.config(hostname).Prefix
+	}
+	updated = prefixRegex.ReplaceAllLiteralString(updated, fmt.Sprintf(`prefix = "%s"`, prefix))
+
+	if err := os.WriteFile(configPath, []byte(updated), 0640); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package vector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
+	"github.com/hostedgraphite/hg-cli/utils"
+)
+
+type views struct {
+	apikey           string
+	header           string
+	path             string
+	confirmUninstall bool
+	files            string
+	format           string
+	errorPattern     string
+	interval         string
+	output           forms.Output
+}
+
+func newViews(sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	return &views{
+		header: styles.MetricfireLogo,
+		apikey: forms.DefaultApiKey,
+		format: FormatNginx,
+	}
+}
+
+func (v *views) InstallView() (*huh.Group, error) {
+	installGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(v.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(v.apikey)
+			}).
+			Value(&v.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("logFiles").
+			Title("Log Files").
+			Description("Comma separated files to tail").
+			Prompt("Files: ").
+			Placeholder(DefaultLogFile).
+			Value(&v.files),
+
+		huh.NewSelect[string]().
+			Key("logFormat").
+			Title("Log Format").
+			Options(
+				huh.NewOption("nginx (requests by status class)", FormatNginx),
+				huh.NewOption("plain (matching lines only)", FormatPlain),
+			).
+			Value(&v.format),
+
+		huh.NewInput().
+			Key("errorPattern").
+			Title("Error Pattern").
+			Description("Optional regex, matching lines are counted as logs.errors").
+			Prompt("Regex: ").
+			Value(&v.errorPattern).
+			Validate(func(s string) error {
+				if s == "" {
+					if v.format == FormatPlain {
+						return fmt.Errorf("the plain log format needs an error pattern")
+					}
+					return nil
+				}
+				return Config{
+					Files:    []string{DefaultLogFile},
+					Format:   FormatNginx,
+					Matches:  []Match{{Name: "errors", Pattern: s}},
+					Interval: DefaultInterval,
+				}.Validate()
+			}),
+
+		huh.NewInput().
+			Key("interval").
+			Title("Flush Interval").
+			Prompt("Interval: ").
+			Placeholder(DefaultInterval).
+			Value(&v.interval).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				_, err := time.ParseDuration(s)
+				return err
+			}),
+	)
+
+	return installGroup, nil
+}
+
+func (v *views) InstallOptions(form *huh.Form, options map[string]interface{}) {
+	options["logFiles"] = forms.SplitList(v.files)
+	options["logFormat"] = v.format
+	if v.errorPattern != "" {
+		options["logMatches"] = map[string]string{"errors": v.errorPattern}
+	}
+	options["interval"] = v.interval
+}
+
+func (v *views) OutputView() (*huh.Group, error) {
+	return forms.OutputGroup(&v.output, false, endpoint.Modes...), nil
+}
+
+func (v *views) UninstallView() (*huh.Group, error) {
+	uninstallGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(v.header),
+		huh.NewConfirm().
+			Key("confirmUninstall").
+			Title("Are you sure you want to uninstall Vector?").
+			Description("This will remove the agent, but not the configuration files").
+			Value(&v.confirmUninstall),
+	)
+
+	return uninstallGroup, nil
+}
+
+func (v *views) UpdateApiKeyView(defaultPath string) (*huh.Group, error) {
+	updateGroup := huh.NewGroup(
+		huh.NewNote().
+			Title(v.header),
+
+		huh.NewInput().
+			Key("apikey").
+			Title("Enter your new Hosted Graphite API key").
+			Prompt("API Key: ").
+			Validate(func(s string) error {
+				return utils.ValidateAPIKey(v.apikey)
+			}).
+			Value(&v.apikey).
+			EchoMode(huh.EchoModePassword),
+
+		huh.NewInput().
+			Key("path").
+			Title("Enter the path to the yaml file").
+			Prompt("Path: ").
+			Description("The default location is already populated. If the path is different please update below.").
+			Placeholder(defaultPath).
+			Value(&v.path),
+	)
+
+	return updateGroup, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// EnvVar holds the api key for scripts and CI, it doesn't show up in ps or
+// the shell history like --api-key does.
+const EnvVar = "HG_API_KEY"
+
+// Key is an api key and where it was found.
+type Key struct {
+	Value  string
+	Source string
+}
+
+// ReadKeyFile reads an api key from a file, or from stdin when path is -.
+func ReadKeyFile(path string) (string, error) {
+	var content []byte
+	var err error
+
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading api key file: %v", err)
+	}
+
+	apikey := strings.TrimSpace(string(content))
+	if apikey == "" {
+		return "", fmt.Errorf("api key file %s is empty", path)
+	}
+	return apikey, nil
+}
+
+// Lookup finds the api key when --api-key isn't passed, from keyFile, then
+// $HG_API_KEY, the profile's apiKey and finally the key stored by
+// `hg-cli auth login`. An empty key means there's none.
+func Lookup(keyFile string) (Key, error) {
+	if keyFile != "" {
+		apikey, err := ReadKeyFile(keyFile)
+		return Key{Value: apikey, Source: "--api-key-file"}, err
+	}
+
+	if apikey := os.Getenv(EnvVar); apikey != "" {
+		return Key{Value: apikey, Source: "$" + EnvVar}, nil
+	}
+
+	if p := profile.Current(); p.ApiKey.IsSet() {
+		apikey, err := p.ApiKey.Resolve()
+		if err != nil {
+			return Key{}, fmt.Errorf("profile api key: %v", err)
+		}
+		return Key{Value: apikey, Source: "profile " + profile.Name()}, nil
+	}
+
+	for _, store := range Stores() {
+		apikey, err := store.Get(profile.Name())
+		if err != nil {
+			// A keyring without a session, e.g. over ssh, is as good as empty
+			if _, ok := store.(keyring); ok {
+				continue
+			}
+			return Key{}, err
+		}
+		if apikey != "" {
+			return Key{Value: apikey, Source: store.Name()}, nil
+		}
+	}
+
+	return Key{}, nil
+}
+
+// ApplyFlag fills in the --api-key flag of cmd, when it has one and it
+// wasn't passed, and masks the key in the output. Listing agents doesn't need
+// the key, so --list never prompts for the file's passphrase.
+func ApplyFlag(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("api-key")
+	if flag == nil {
+		return nil
+	}
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		return nil
+	}
+
+	if !flag.Changed {
+		keyFile, _ := cmd.Flags().GetString("api-key-file")
+		key, err := Lookup(keyFile)
+		if err != nil {
+			return err
+		}
+		if key.Value != "" {
+			if err := cmd.Flags().Set("api-key", key.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	utils.AddSecret(flag.Value.String())
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(PassphraseEnvVar, "correct horse")
+
+	store := &fileStore{}
+	require.NoError(t, store.Set("prod", "prod-key"))
+	require.NoError(t, store.Set("staging", "staging-key"))
+
+	apikey, err := (&fileStore{}).Get("staging")
+	require.NoError(t, err)
+	require.Equal(t, "staging-key", apikey)
+
+	apikey, err = (&fileStore{passphrase: "wrong"}).Get("prod")
+	require.Error(t, err)
+	require.Empty(t, apikey)
+	require.Error(t, (&fileStore{passphrase: "wrong"}).Set("sandbox", "sandbox-key"))
+
+	path, err := credentialsPath()
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	deleted, err := (&fileStore{}).Delete("prod")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	apikey, err = (&fileStore{}).Get("prod")
+	require.NoError(t, err)
+	require.Empty(t, apikey)
+}
+
+// Files written before the key derivation moved to crypto/pbkdf2 still open.
+func TestFileStoreExistingFile(t *testing.T) {
+	sealed, err := hex.DecodeString("30313233343536373839616278e23bed007bda193361ad0c8314929cebfe569feb368e3a4fbf")
+	require.NoError(t, err)
+	creds := credentials{Salt: []byte("0123456789abcdef"), Keys: map[string][]byte{"default": sealed}}
+
+	apikey, err := (&fileStore{passphrase: "secret"}).open(creds, "default", sealed)
+	require.NoError(t, err)
+	require.Equal(t, "my-api-key", apikey)
+}
+
+func TestLookup(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+	// No keyring, only the encrypted file
+	defer func(original string) { goos = original }(goos)
+	goos = "windows"
+
+	key, err := Lookup("")
+	require.NoError(t, err)
+	require.Empty(t, key.Value)
+
+	profile.Use("prod", profile.Profile{ApiKey: profile.ApiKey{Value: "profile-key"}})
+	defer profile.Use("", profile.Profile{})
+	key, err = Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, Key{Value: "profile-key", Source: "profile prod"}, key)
+
+	t.Setenv(EnvVar, "env-key")
+	key, err = Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, "env-key", key.Value)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-key\n"), 0600))
+	key, err = Lookup(keyFile)
+	require.NoError(t, err)
+	require.Equal(t, "file-key", key.Value)
+}
+
+func TestApplyFlagList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+	t.Setenv(PassphraseEnvVar, "")
+	defer func(original string) { goos = original }(goos)
+	goos = "windows"
+	defer func(original func(string) (string, error)) { promptPassphrase = original }(promptPassphrase)
+	promptPassphrase = func(string) (string, error) { return "", errors.New("no terminal") }
+	require.NoError(t, (&fileStore{passphrase: "secret"}).Set(profile.Name(), "file-key"))
+
+	command := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("api-key", "", "")
+		cmd.Flags().BoolP("list", "l", false, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	require.Error(t, ApplyFlag(command()))
+
+	cmd := command("--list")
+	require.NoError(t, ApplyFlag(cmd))
+	apikey, _ := cmd.Flags().GetString("api-key")
+	require.Empty(t, apikey)
+}
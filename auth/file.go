@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+	"github.com/hostedgraphite/hg-cli/profile"
+)
+
+// PassphraseEnvVar unlocks the encrypted file without a prompt.
+const PassphraseEnvVar = "HG_PASSPHRASE"
+
+// iterations of PBKDF2, the OWASP recommendation for HMAC-SHA256.
+const iterations = 600000
+
+// credentials is the encrypted file, each key is sealed with AES-256-GCM
+// using a key derived from the passphrase and bound to its profile name.
+type credentials struct {
+	Salt []byte            `json:"salt"`
+	Keys map[string][]byte `json:"keys"`
+}
+
+// fileStore is used where there's no OS keyring, e.g. on servers.
+type fileStore struct {
+	passphrase string
+}
+
+// promptPassphrase asks for the passphrase on the terminal.
+var promptPassphrase = func(title string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("set %s to unlock the encrypted api key file", PassphraseEnvVar)
+	}
+	var passphrase string
+	err := huh.NewInput().
+		Title(title).
+		EchoMode(huh.EchoModePassword).
+		Value(&passphrase).
+		Run()
+	return passphrase, err
+}
+
+func credentialsPath() (string, error) {
+	dir, err := profile.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials"), nil
+}
+
+func (f *fileStore) Name() string {
+	path, _ := credentialsPath()
+	return "encrypted file " + path
+}
+
+func (f *fileStore) load() (credentials, error) {
+	creds := credentials{Keys: map[string][]byte{}}
+
+	path, err := credentialsPath()
+	if err != nil {
+		return creds, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return creds, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(content, &creds); err != nil {
+		return creds, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if creds.Keys == nil {
+		creds.Keys = map[string][]byte{}
+	}
+	return creds, nil
+}
+
+func (f *fileStore) save(creds credentials) error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *fileStore) aead(salt []byte) (cipher.AEAD, error) {
+	if f.passphrase == "" {
+		f.passphrase = os.Getenv(PassphraseEnvVar)
+	}
+	if f.passphrase == "" {
+		passphrase, err := promptPassphrase("Passphrase for the encrypted api key file")
+		if err != nil {
+			return nil, err
+		}
+		f.passphrase = passphrase
+	}
+
+	key, err := pbkdf2.Key(sha256.New, f.passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (f *fileStore) Get(profile string) (string, error) {
+	creds, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := creds.Keys[profile]
+	if !ok {
+		return "", nil
+	}
+	return f.open(creds, profile, sealed)
+}
+
+func (f *fileStore) open(creds credentials, profile string, sealed []byte) (string, error) {
+	aead, err := f.aead(creds.Salt)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("the encrypted api key for %s is corrupt", profile)
+	}
+	apikey, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(profile))
+	if err != nil {
+		return "", fmt.Errorf("can't decrypt the api key for %s, wrong passphrase?", profile)
+	}
+	return string(apikey), nil
+}
+
+func (f *fileStore) Set(profile, apikey string) error {
+	creds, err := f.load()
+	if err != nil {
+		return err
+	}
+	if creds.Salt == nil {
+		creds.Salt = make([]byte, 16)
+		if _, err := rand.Read(creds.Salt); err != nil {
+			return err
+		}
+	}
+
+	// Every key in the file shares the passphrase, check it opens the others
+	for name, sealed := range creds.Keys {
+		if _, err := f.open(creds, name, sealed); err != nil {
+			return err
+		}
+		break
+	}
+
+	aead, err := f.aead(creds.Salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	creds.Keys[profile] = aead.Seal(nonce, nonce, []byte(apikey), []byte(profile))
+
+	return f.save(creds)
+}
+
+// Delete doesn't need the passphrase, the other keys stay sealed.
+func (f *fileStore) Delete(profile string) (bool, error) {
+	creds, err := f.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := creds.Keys[profile]; !ok {
+		return false, nil
+	}
+	delete(creds.Keys, profile)
+	return true, f.save(creds)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const service = "hg-cli"
+
+var (
+	execCommand = exec.Command
+	lookPath    = exec.LookPath
+	goos        = runtime.GOOS
+)
+
+// Store keeps the api key of each profile outside the config file.
+type Store interface {
+	// Name describes the store to the user.
+	Name() string
+	// Get returns an empty key when none is stored for the profile.
+	Get(profile string) (string, error)
+	Set(profile, apikey string) error
+	// Delete reports whether there was a key to remove.
+	Delete(profile string) (bool, error)
+}
+
+// Stores are the stores a key is looked up in, the OS keyring when there is
+// one and then the encrypted file.
+func Stores() []Store {
+	var stores []Store
+	if k, ok := osKeyring(); ok {
+		stores = append(stores, k)
+	}
+	return append(stores, &fileStore{})
+}
+
+// NewStore returns the store named keyring or file.
+func NewStore(name string) (Store, error) {
+	switch name {
+	case "keyring":
+		k, ok := osKeyring()
+		if !ok {
+			return nil, fmt.Errorf("no OS keyring found, on linux install secret-tool (libsecret) or use --store file")
+		}
+		return k, nil
+	case "file":
+		return &fileStore{}, nil
+	}
+	return nil, fmt.Errorf("unknown store %q, use keyring or file", name)
+}
+
+// keyring stores keys with the macOS security tool or libsecret's
+// secret-tool. The key is passed on stdin, never as an argument.
+type keyring struct {
+	tool string
+}
+
+func osKeyring() (keyring, bool) {
+	tool := "secret-tool"
+	if goos == "darwin" {
+		tool = "security"
+	} else if goos != "linux" {
+		return keyring{}, false
+	}
+	if _, err := lookPath(tool); err != nil {
+		return keyring{}, false
+	}
+	return keyring{tool: tool}, true
+}
+
+func (k keyring) Name() string {
+	return "OS keyring"
+}
+
+func (k keyring) Get(profile string) (string, error) {
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		cmd = execCommand("security", "find-generic-password", "-s", service, "-a", profile, "-w")
+	} else {
+		cmd = execCommand("secret-tool", "lookup", "service", service, "account", profile)
+	}
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && k.notFound(exitErr) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading the keyring: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// notFound tells a missing key from a keyring error: security exits 44 and
+// secret-tool exits 1 without saying anything.
+func (k keyring) notFound(err *exec.ExitError) bool {
+	if k.tool == "security" {
+		return err.ExitCode() == 44
+	}
+	return err.ExitCode() == 1 && len(err.Stderr) == 0
+}
+
+func (k keyring) Set(profile, apikey string) error {
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		// security -i reads the command from stdin, keeping the key out of ps
+		cmd = execCommand("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -w %s\n",
+			service, strconv.Quote(profile), strconv.Quote("Hosted Graphite API key"), strconv.Quote(apikey)))
+	} else {
+		cmd = execCommand("secret-tool", "store", "--label=Hosted Graphite API key ("+profile+")", "service", service, "account", profile)
+		cmd.Stdin = strings.NewReader(apikey)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error writing to the keyring: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k keyring) Delete(profile string) (bool, error) {
+	apikey, err := k.Get(profile)
+	if err != nil || apikey == "" {
+		return false, err
+	}
+
+	var cmd *exec.Cmd
+	if k.tool == "security" {
+		cmd = execCommand("security", "delete-generic-password", "-s", service, "-a", profile)
+	} else {
+		cmd = execCommand("secret-tool", "clear", "service", service, "account", profile)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("error removing the key from the keyring: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
@@ -1,9 +1,10 @@
 package agent
 
 import (
-	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
 	"github.com/hostedgraphite/hg-cli/cmd/agent/apiupdater"
 	"github.com/hostedgraphite/hg-cli/cmd/agent/install"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/script"
 	"github.com/hostedgraphite/hg-cli/cmd/agent/uninstall"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 
@@ -21,7 +22,7 @@ func AgentCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 
 			if listAgents {
-				utils.ShowAvailableAgents()
+				agentmanager.ShowAvailableAgents()
 				return nil
 			}
 			return nil
@@ -40,6 +41,7 @@ func AgentCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 	cmd.AddCommand(install.InstallCmd(sysinfo))
 	cmd.AddCommand(uninstall.UninstallCmd(sysinfo))
 	cmd.AddCommand(apiupdater.ApiUpdateCmd(sysinfo))
+	cmd.AddCommand(script.ScriptCmd())
 	cmd.PersistentFlags().BoolVarP(&listAgents, "list", "l", false, "List Available Agents")
 
 	return cmd
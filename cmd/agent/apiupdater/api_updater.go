@@ -4,11 +4,10 @@ import (
 	"fmt"
 
 	"github.com/hostedgraphite/hg-cli/agentmanager"
-	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
-	"github.com/hostedgraphite/hg-cli/agentmanager/telegraf"
-	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/flags"
 	"github.com/hostedgraphite/hg-cli/formatters"
 	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/profile"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 	cliUtils "github.com/hostedgraphite/hg-cli/utils"
 
@@ -17,7 +16,9 @@ import (
 
 func ApiUpdateCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 	var agentName, apikey, path string
-	var completed bool
+	var completed, keyEnv bool
+	var naming flags.NamingFlags
+	var endpoint flags.EndpointFlags
 
 	cmd := &cobra.Command{
 		Use:   "update-apikey <agent>",
@@ -29,12 +30,18 @@ func ApiUpdateCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 				return nil
 			}
 
+			args = profile.AgentArgs(args)
 			err := validateArgs(args)
 			if err != nil {
 				return err
 			}
 			agentName = args[0]
 
+			err = flags.ValidateAgentFlags(cmd, agentName)
+			if err != nil {
+				return err
+			}
+
 			if cliUtils.AgentRequiresSudo(sysinfo.Os, "update", sysinfo.PkgMngr, agentName) && !sysinfo.SudoPerm {
 				return fmt.Errorf("this cmd requires admin privileges, please run as root")
 			}
@@ -50,8 +57,19 @@ func ApiUpdateCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 			if !completed {
 				return nil
 			}
+			if err := cliUtils.ValidateAPIKey(apikey); err != nil {
+				return err
+			}
 
-			err := execute(apikey, agentName, path, sysinfo)
+			options := map[string]interface{}{
+				"config":        path,
+				"apikey":        apikey,
+				"apiKeyEnvFile": keyEnv,
+			}
+			naming.Options(options)
+			endpoint.Options(options)
+
+			err := execute(agentName, options, sysinfo)
 			if err != nil {
 				return err
 			}
@@ -62,52 +80,34 @@ func ApiUpdateCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 
 	cmd.Flags().StringVar(&apikey, "api-key", "", "Your Hosted Graphite API key (required)")
 	cmd.Flags().StringVar(&path, "config", "", "The path to the agent configuration file")
+	naming.Register(cmd)
+	endpoint.Register(cmd)
+	flags.RegisterKeyEnvFile(cmd, &keyEnv)
 
 	return cmd
 }
 
 func validateArgs(args []string) error {
-	if len(args) == 0 || !utils.ValidateAgent(args[0]) {
+	if len(args) == 0 || !agentmanager.ValidateAgent(args[0]) {
 		return fmt.Errorf("no agent specified or agent not supported; see 'cli agent -l' for compatible agents")
 	}
 	return nil
 }
 
-func execute(apikey, agentName, path string, sysInfo sysinfo.SysInfo) error {
+func execute(agentName string, options map[string]interface{}, sysInfo sysinfo.SysInfo) error {
 	var err error
-	var serviceSettings map[string]string
-	var summary formatters.SummaryContent
+	path := options["config"].(string)
 
-	options := map[string]interface{}{
-		"config": path,
-		"apikey": apikey,
-	}
 	agent := agentmanager.NewAgent(agentName, options, sysInfo)
+	serviceSettings := agentmanager.ServiceSettings(agentName, sysInfo)
+	summary := agentmanager.NewSummary(agentName, formatters.ActionSummary{
+		Success:    true,
+		Action:     "Update Api Key",
+		Config:     path,
+		RestartCmd: serviceSettings["restartHint"],
+	}, options)
+
 	updates := make(chan *pipeline.Pipe)
-	switch agentName {
-	case "telegraf":
-		serviceSettings = telegraf.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
-		summary = &formatters.TelegrafSummary{
-			ActionSummary: formatters.ActionSummary{
-				Agent:      agentName,
-				Success:    true,
-				Action:     "Update Api Key",
-				Config:     path,
-				RestartCmd: serviceSettings["restartHint"],
-			},
-		}
-	case "otel":
-		serviceSettings = otel.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
-		summary = &formatters.OtelContribSummary{
-			ActionSummary: formatters.ActionSummary{
-				Agent:      agentName,
-				Success:    true,
-				Action:     "Update Api Key",
-				Config:     path,
-				RestartCmd: serviceSettings["restartHint"],
-			},
-		}
-	}
 	updateApikeyPipeline, err := agent.UpdateApiKeyPipeline(updates)
 	if err != nil {
 		return err
@@ -123,6 +123,10 @@ func execute(apikey, agentName, path string, sysInfo sysinfo.SysInfo) error {
 	if err != nil {
 		return err
 	}
+	// The runner only reports its own errors, a failed step is on the pipeline.
+	if updateApikeyPipeline.Failed() {
+		return updateApikeyPipeline.Err
+	}
 
 	fmt.Println(formatters.GenerateCliSummary(summary))
 
@@ -0,0 +1,133 @@
+package flags
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ValidateAgentFlags rejects flags that only apply to other agents.
+func ValidateAgentFlags(cmd *cobra.Command, agentName string) error {
+	agent, _ := agentmanager.Lookup(agentName)
+
+	for _, def := range agentmanager.Definitions() {
+		for _, flag := range def.Flags {
+			if slices.Contains(agent.Flags, flag) {
+				continue
+			}
+			if cmd.Flags().Lookup(flag) != nil && cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s is not supported by %s", flag, agentName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NamingFlags are the metric prefix and hostname flags shared by the install
+// and update commands.
+type NamingFlags struct {
+	Prefix       string
+	Segments     []string
+	Hostname     string
+	HostnameMode string
+	Template     string
+	TagSupport   bool
+}
+
+func (f *NamingFlags) Register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Prefix, "prefix", "", "Replace the agent name in the metric prefix <apikey>.<segments>.<prefix>")
+	cmd.Flags().StringSliceVar(&f.Segments, "prefix-segments", []string{}, "Segments such as env, team or region added after the api key (comma separated)")
+	cmd.Flags().StringVar(&f.Hostname, "hostname", "", "Override the hostname the agent reports")
+	cmd.Flags().StringVar(&f.HostnameMode, "hostname-mode", "", "Detect the hostname using: "+strings.Join(naming.HostnameModes, ", "))
+	cmd.Flags().StringVar(&f.Template, "template", "", "Graphite template string, e.g. host.tags.measurement.field (telegraf only)")
+	cmd.Flags().BoolVar(&f.TagSupport, "graphite-tag-support", false, "Send tags as graphite tags (telegraf only)")
+}
+
+// Options adds the naming flags to the agent options.
+func (f *NamingFlags) Options(options map[string]interface{}) map[string]interface{} {
+	options["prefix"] = f.Prefix
+	options["segments"] = f.Segments
+	options["hostname"] = f.Hostname
+	options["hostnameMode"] = f.HostnameMode
+	options["template"] = f.Template
+	options["tagSupport"] = f.TagSupport
+
+	return options
+}
+
+// EndpointFlags choose where the agent sends metrics.
+type EndpointFlags struct {
+	Mode               string
+	Address            string
+	TLSCA              string
+	InsecureSkipVerify bool
+}
+
+func (f *EndpointFlags) Register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Mode, "endpoint", "", "Carbon endpoint to send metrics to: "+strings.Join(endpoint.Modes, ", ")+" (default plaintext). otel and node_exporter only support plaintext, collectd doesn't support tls")
+	cmd.Flags().StringVar(&f.Address, "endpoint-address", "", "Custom or private carbon endpoint host:port")
+	cmd.Flags().StringVar(&f.TLSCA, "tls-ca", "", "CA bundle used to verify the tls endpoint")
+	cmd.Flags().BoolVar(&f.InsecureSkipVerify, "tls-insecure-skip-verify", false, "Skip verifying the tls endpoint certificate")
+}
+
+// Options adds the endpoint flags to the agent options.
+func (f *EndpointFlags) Options(options map[string]interface{}) map[string]interface{} {
+	options["endpoint"] = f.Mode
+	options["endpointAddress"] = f.Address
+	options["tlsCA"] = f.TLSCA
+	options["tlsInsecureSkipVerify"] = f.InsecureSkipVerify
+
+	return options
+}
+
+// RegisterKeyEnvFile adds --api-key-env-file, shared by install and
+// update-apikey.
+func RegisterKeyEnvFile(cmd *cobra.Command, keyEnv *bool) {
+	cmd.Flags().BoolVar(keyEnv, "api-key-env-file", false, "Keep the api key in a root-only environment file loaded by the systemd service, the config references $HG_API_KEY (telegraf and otel only)")
+}
+
+// AgentFlags are the agent selection flags shared by the commands that build
+// an install pipeline. The agents add their own flags through the registry.
+type AgentFlags struct {
+	flags    *pflag.FlagSet
+	Mirror   string
+	KeyEnv   bool
+	Naming   NamingFlags
+	Endpoint EndpointFlags
+}
+
+func (f *AgentFlags) Register(cmd *cobra.Command) {
+	f.flags = cmd.Flags()
+	agentmanager.AddFlags(f.flags)
+	f.Naming.Register(cmd)
+	f.Endpoint.Register(cmd)
+	RegisterKeyEnvFile(cmd, &f.KeyEnv)
+	cmd.Flags().StringVar(&f.Mirror, "mirror", "", "Download agent release archives from this mirror, laid out as <mirror>/<host>/<path>")
+}
+
+// Options adds the agent flags to the agent options.
+func (f *AgentFlags) Options(options map[string]interface{}) map[string]interface{} {
+	agentmanager.FlagOptions(f.flags, options)
+	options["apiKeyEnvFile"] = f.KeyEnv
+	f.Naming.Options(options)
+	f.Endpoint.Options(options)
+
+	return options
+}
+
+// Validate checks the flags with the agent's own validation.
+func (f *AgentFlags) Validate(agentName, os string) error {
+	def, ok := agentmanager.Lookup(agentName)
+	if !ok || def.Validate == nil {
+		return nil
+	}
+	return def.Validate(f.Options(map[string]interface{}{}), os)
+}
@@ -2,14 +2,19 @@ package install
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/hostedgraphite/hg-cli/agentmanager"
-	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
-	"github.com/hostedgraphite/hg-cli/agentmanager/telegraf"
-	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
+	"github.com/hostedgraphite/hg-cli/agentmanager/container"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/flags"
 	"github.com/hostedgraphite/hg-cli/formatters"
 	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/styles"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/views/config"
 	cliUtils "github.com/hostedgraphite/hg-cli/utils"
 
 	// windows color support
@@ -21,7 +26,11 @@ func InstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 		completed bool
 		apikey    string
 		agentName string
-		plugins   []string
+		mode      string
+		compose   string
+		user      bool
+		root      string
+		agent     flags.AgentFlags
 	)
 
 	cmd := &cobra.Command{
@@ -37,14 +46,42 @@ func InstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 				return nil
 			}
 
-			err := validateArgs(args, plugins)
+			args = profile.AgentArgs(args)
+			err := validateArgs(args)
 			if err != nil {
 				return err
 			}
 
+			if err := container.ValidateMode(mode, sysinfo.Os); err != nil {
+				return err
+			}
+			if compose != "" && mode != container.ModeDocker {
+				return fmt.Errorf("--compose-file can only be used with --mode docker")
+			}
+			if user && (mode == container.ModeDocker || sysinfo.Os == "windows") {
+				return fmt.Errorf("--user can only be used for host installs on linux and darwin")
+			}
+			if root != "" {
+				if mode == container.ModeDocker || user || sysinfo.Os != "linux" {
+					return fmt.Errorf("--root can only be used for host installs on linux")
+				}
+				if root, err = filepath.Abs(root); err != nil {
+					return err
+				}
+			}
+
+			err = flags.ValidateAgentFlags(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if err := agent.Validate(args[0], sysinfo.Os); err != nil {
+				return err
+			}
+
 			agentName = args[0]
-			// Validate if the cmd requires sudo
-			if cliUtils.AgentRequiresSudo(sysinfo.Os, "install", sysinfo.PkgMngr, agentName) && !sysinfo.SudoPerm {
+			// Validate if the cmd requires sudo, user installs stay in $HOME and
+			// staged ones in the root
+			if !user && root == "" && cliUtils.AgentRequiresSudo(sysinfo.Os, "install", sysinfo.PkgMngr, agentName) && !sysinfo.SudoPerm {
 				return fmt.Errorf("this cmd requires admin privileges, please run as root")
 			}
 
@@ -60,8 +97,25 @@ func InstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 			if !completed {
 				return nil
 			}
+			if err := cliUtils.ValidateAPIKey(apikey); err != nil {
+				return err
+			}
 
-			err := execute(apikey, agentName, plugins, sysinfo)
+			options := map[string]interface{}{
+				"apikey":      apikey,
+				"mode":        mode,
+				"composeFile": compose,
+				"userScope":   user,
+			}
+			agent.Options(options)
+
+			target := sysinfo
+			if root != "" {
+				target = sysinfo.Staged(root)
+			}
+			target.Mirror = agent.Mirror
+
+			err := execute(agentName, options, target)
 
 			if err != nil {
 				return err
@@ -72,72 +126,67 @@ func InstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&apikey, "api-key", "", "Your Hosted Graphite API key (required)")
-	cmd.Flags().StringSliceVar(&plugins, "plugins", []string{}, "List of plugins to include during install (comma separated)")
+	agent.Register(cmd)
+	cmd.Flags().StringVar(&mode, "mode", container.ModeHost, "Deploy as a host install or a docker container: "+strings.Join(container.Modes, ", "))
+	cmd.Flags().StringVar(&compose, "compose-file", "", "With --mode docker, write a docker-compose.yml here instead of starting the container")
+	cmd.Flags().BoolVar(&user, "user", false, "Install for the current user without root: the binary in ~/.local/bin, the config in ~/.config and a systemd user unit or launchd agent")
+	cmd.Flags().StringVar(&root, "root", "", "Stage the install under this directory, e.g. an image build root, instead of this host. Agents install from their release archives with systemd units, nothing is started")
 
 	return cmd
 }
 
-func validateArgs(args, plugins []string) error {
+func validateArgs(args []string) error {
 
-	if len(args) == 0 || !utils.ValidateAgent(args[0]) {
+	if len(args) == 0 || !agentmanager.ValidateAgent(args[0]) {
 		return fmt.Errorf("no agent specified or agent not supported; see 'cli agent -l' for compatible agents")
 	}
 
-	// TODO: Ensure Otel doesn't have any plugins passed.
-
 	return nil
 }
 
-func execute(apikey, agentName string, plugins []string, sysInfo sysinfo.SysInfo) error {
+func execute(agentName string, options map[string]interface{}, sysInfo sysinfo.SysInfo) error {
 	var err error
-	var selectedPlugins []string
-	var serviceSettings map[string]string
-	var summary formatters.SummaryContent
 
-	options := map[string]interface{}{
-		"apikey": apikey,
-	}
+	def, _ := agentmanager.Lookup(agentName)
+	serviceSettings := def.ServiceSettings(sysInfo)
 
-	switch agentName {
-	case "telegraf":
-		serviceSettings = telegraf.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
-		if len(plugins) == 0 {
-			selectedPlugins = telegraf.DefaultTelegrafPlugins
-		} else {
-			selectedPlugins = plugins
-		}
-		summary = &formatters.TelegrafSummary{
-			ActionSummary: formatters.ActionSummary{
-				Agent:    agentName,
-				Success:  true,
-				Action:   "Install",
-				Config:   serviceSettings["configPath"],
-				StartCmd: serviceSettings["startHint"],
-				Error:    "",
-			},
-			Plugins: selectedPlugins,
+	if plugins, _ := options["plugins"].([]string); len(plugins) == 0 && len(def.DefaultPlugins) > 0 {
+		catalog, err := config.LoadPlugins()
+		if err != nil {
+			return err
 		}
-		options["plugins"] = selectedPlugins
-	case "otel":
-		serviceSettings = otel.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
-		summary = &formatters.OtelContribSummary{
-			ActionSummary: formatters.ActionSummary{
-				Agent:    agentName,
-				Success:  true,
-				Action:   "Install",
-				Config:   serviceSettings["configPath"],
-				StartCmd: serviceSettings["startHint"],
-				Error:    "",
-			},
-			Receiver: serviceSettings["receiver"],
-			Exporter: serviceSettings["exporter"],
+		options["plugins"] = catalog.SupportedOn(sysInfo.Os, def.DefaultPlugins)
+	}
+
+	if container.Mode(options) != container.ModeDocker {
+		agentmanager.PlatformDefaults(options, sysInfo)
+		if warning := agentmanager.PlatformWarning(sysInfo); warning != "" {
+			fmt.Println(styles.DefaultStyles().Cli.Render("Warning: " + warning))
 		}
 	}
-	agent := agentmanager.NewAgent(agentName, options, sysInfo)
+
+	agent := def.New(options, sysInfo)
 
 	// Build the pipeline
 	updates := make(chan *pipeline.Pipe)
-	installPipeline, err := agent.InstallPipeline(updates)
+	var installPipeline *pipeline.Pipeline
+	if container.Mode(options) == container.ModeDocker {
+		containerAgent, ok := agent.(agentmanager.ContainerAgent)
+		if !ok {
+			return fmt.Errorf("%s can't be deployed with --mode docker", def.DisplayName)
+		}
+		serviceSettings = containerAgent.ContainerSettings()
+		installPipeline, err = containerAgent.ContainerPipeline(updates)
+	} else if userScope, _ := options["userScope"].(bool); userScope {
+		userAgent, ok := agent.(agentmanager.UserAgent)
+		if !ok {
+			return fmt.Errorf("%s can't be installed with --user", def.DisplayName)
+		}
+		serviceSettings = userAgent.UserSettings()
+		installPipeline, err = userAgent.UserInstallPipeline(updates)
+	} else {
+		installPipeline, err = agent.InstallPipeline(updates)
+	}
 	if err != nil {
 		return err
 	}
@@ -152,6 +201,18 @@ func execute(apikey, agentName string, plugins []string, sysInfo sysinfo.SysInfo
 	if err != nil {
 		return err
 	}
+	// The runner only reports its own errors, a failed step is on the pipeline.
+	if installPipeline.Failed() {
+		return installPipeline.Err
+	}
+
+	summary := agentmanager.NewSummary(agentName, formatters.ActionSummary{
+		Success:  true,
+		Action:   "Install",
+		Config:   serviceSettings["configPath"],
+		StartCmd: serviceSettings["startHint"],
+		Endpoint: endpoint.New(options).Describe(),
+	}, options)
 
 	fmt.Println(formatters.GenerateCliSummary(summary))
 
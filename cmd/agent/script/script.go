@@ -0,0 +1,153 @@
+package script
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/flags"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/views/config"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// Targets are the package managers a script can be rendered for on each os,
+// an empty package manager installs the release binaries.
+var Targets = map[string][]string{
+	"linux":   {"apt", "yum", "dnf", "zypper", "apk", "pacman", ""},
+	"darwin":  {"brew", ""},
+	"windows": {""},
+}
+
+// InitSystems are the linux init systems a script can manage the agent with.
+var InitSystems = []string{sysinfo.InitSystemd, sysinfo.InitOpenRC, sysinfo.InitSysV, sysinfo.InitRunit, sysinfo.InitNone}
+
+func ScriptCmd() *cobra.Command {
+	var (
+		target    sysinfo.SysInfo
+		agentName string
+		apikey    string
+		version   string
+		output    string
+		agent     flags.AgentFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "script <agent>",
+		Short: "Export an agent install as a shell script.",
+		Long: "Render the steps `agent install` would run on the target system as a standalone bash script, " +
+			"or a PowerShell script for windows, so it can be reviewed and run with your own tooling. " +
+			"The script contains the api key.",
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			list, _ := cmd.Flags().GetBool("list")
+			if list {
+				return nil
+			}
+
+			args = profile.AgentArgs(args)
+			if len(args) == 0 || !agentmanager.ValidateAgent(args[0]) {
+				return fmt.Errorf("no agent specified or agent not supported; see 'hg-cli agent -l' for compatible agents")
+			}
+			if err := ValidateTarget(target); err != nil {
+				return err
+			}
+			if err := flags.ValidateAgentFlags(cmd, args[0]); err != nil {
+				return err
+			}
+			agentName = args[0]
+
+			return cmd.MarkFlagRequired("api-key")
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list, _ := cmd.Flags().GetBool("list"); list {
+				return nil
+			}
+			if err := cliUtils.CheckKeyFormat(apikey); err != nil {
+				return err
+			}
+
+			options := map[string]interface{}{
+				"apikey":  apikey,
+				"version": version,
+			}
+			agent.Options(options)
+
+			target.Mirror = agent.Mirror
+			script, err := Render(agentName, options, target)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err = os.Stdout.Write(script)
+				return err
+			}
+			// Owner only, the script holds the api key.
+			return os.WriteFile(output, script, 0700)
+		},
+	}
+
+	cmd.Flags().StringVar(&target.Os, "os", "linux", "Target operating system: linux, darwin or windows")
+	cmd.Flags().StringVar(&target.PkgMngr, "pkg", "", "Target package manager: apt, yum, dnf, zypper, apk, pacman or brew, empty installs the release binaries")
+	cmd.Flags().StringVar(&target.Arch, "arch", "amd64", "Target architecture, e.g. amd64 or arm64")
+	cmd.Flags().StringVar(&target.Distro, "distro", "", "Target distribution, used for binary installs on rpm based systems")
+	cmd.Flags().StringVar(&target.InitSystem, "init", "", "Target init system: systemd, openrc, sysv, runit or none, defaults to the distro's own")
+	cmd.Flags().StringVar(&apikey, "api-key", "", "Your Hosted Graphite API key (required)")
+	cmd.Flags().StringVar(&version, "version", "", "Agent version to install, defaults to the latest release")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the script to a file instead of stdout")
+	agent.Register(cmd)
+
+	return cmd
+}
+
+func ValidateTarget(target sysinfo.SysInfo) error {
+	pkgMngrs, ok := Targets[target.Os]
+	if !ok {
+		return fmt.Errorf("unsupported os %q (available: linux, darwin, windows)", target.Os)
+	}
+	if !slices.Contains(pkgMngrs, target.PkgMngr) {
+		return fmt.Errorf("unsupported package manager %q for %s", target.PkgMngr, target.Os)
+	}
+	if target.InitSystem != "" && (target.Os != "linux" || !slices.Contains(InitSystems, target.InitSystem)) {
+		return fmt.Errorf("unsupported init system %q for %s", target.InitSystem, target.Os)
+	}
+	return nil
+}
+
+// Render builds the install pipeline the agent would run on the target and
+// exports it in the target's shell.
+func Render(agentName string, options map[string]interface{}, target sysinfo.SysInfo) ([]byte, error) {
+	def, ok := agentmanager.Lookup(agentName)
+	if !ok {
+		return nil, fmt.Errorf("agent %q not supported", agentName)
+	}
+
+	if plugins, _ := options["plugins"].([]string); len(plugins) == 0 && len(def.DefaultPlugins) > 0 {
+		catalog, err := config.LoadPlugins()
+		if err != nil {
+			return nil, err
+		}
+		options["plugins"] = catalog.SupportedOn(target.Os, def.DefaultPlugins)
+	}
+
+	agent := def.New(options, target)
+
+	// The pipeline is only exported, nothing reads its updates.
+	installPipeline, err := agent.InstallPipeline(make(chan *pipeline.Pipe))
+	if err != nil {
+		return nil, err
+	}
+
+	shell := pipeline.Bash
+	if target.Os == "windows" {
+		shell = pipeline.PowerShell
+	}
+
+	return installPipeline.Export(shell)
+}
@@ -0,0 +1,99 @@
+package script
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/agents"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestRenderGolden(t *testing.T) {
+	// Darwin pipes install under the invoking user's home.
+	t.Setenv("HOME", "/Users/hg")
+	t.Setenv("SUDO_USER", "hg")
+
+	tests := []struct {
+		golden  string
+		agent   string
+		target  sysinfo.SysInfo
+		options map[string]interface{}
+	}{
+		{"telegraf-linux-apt", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, nil},
+		{"telegraf-linux-yum", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "yum", Arch: "amd64"}, map[string]interface{}{"statsd": true}},
+		{"telegraf-linux-dnf", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "dnf", Arch: "arm64"}, nil},
+		{"telegraf-linux-bin", "telegraf", sysinfo.SysInfo{Os: "linux", Arch: "amd64", Distro: "fedora"}, map[string]interface{}{"hostname": "", "hostnameMode": "short"}},
+		{"telegraf-linux-zypper", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "zypper", Arch: "amd64"}, nil},
+		{"telegraf-linux-apk", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "apk", Arch: "amd64"}, nil},
+		{"telegraf-darwin-brew", "telegraf", sysinfo.SysInfo{Os: "darwin", PkgMngr: "brew", Arch: "arm64"}, nil},
+		{"telegraf-windows", "telegraf", sysinfo.SysInfo{Os: "windows", Arch: "amd64"}, map[string]interface{}{"statsd": true}},
+		{"otel-linux-apt", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, nil},
+		{"otel-linux-bin", "otel", sysinfo.SysInfo{Os: "linux", Arch: "amd64"}, map[string]interface{}{"hostname": ""}},
+		{"otel-linux-zypper", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "zypper", Arch: "amd64"}, nil},
+		{"otel-linux-apk", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "apk", Arch: "arm64"}, nil},
+		{"telegraf-linux-apt-sysv", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64", InitSystem: sysinfo.InitSysV}, map[string]interface{}{"startService": true}},
+		{"telegraf-linux-bin-runit", "telegraf", sysinfo.SysInfo{Os: "linux", Arch: "amd64", InitSystem: sysinfo.InitRunit}, map[string]interface{}{"startService": true}},
+		{"otel-linux-apt-sysv", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64", InitSystem: sysinfo.InitSysV}, map[string]interface{}{"startService": true}},
+		{"otel-linux-apk-openrc", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "apk", Arch: "amd64"}, map[string]interface{}{"startService": true}},
+		{"otel-linux-pacman", "otel", sysinfo.SysInfo{Os: "linux", PkgMngr: "pacman", Arch: "amd64"}, nil},
+		{"otel-darwin", "otel", sysinfo.SysInfo{Os: "darwin", PkgMngr: "brew", Arch: "arm64"}, nil},
+		{"telegraf-linux-apt-envfile", "telegraf", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, map[string]interface{}{"apiKeyEnvFile": true}},
+		{"otel-linux-bin-envfile", "otel", sysinfo.SysInfo{Os: "linux", Arch: "amd64"}, map[string]interface{}{"apiKeyEnvFile": true}},
+		{"otel-windows", "otel", sysinfo.SysInfo{Os: "windows", Arch: "amd64"}, map[string]interface{}{"hostname": ""}},
+		{"collectd-linux-apt", "collectd", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, map[string]interface{}{"version": ""}},
+		{"collectd-linux-dnf", "collectd", sysinfo.SysInfo{Os: "linux", PkgMngr: "dnf", Arch: "amd64"}, map[string]interface{}{"version": ""}},
+		{"alloy-linux-apt", "alloy", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, map[string]interface{}{"version": ""}},
+		{"vector-linux-apt", "vector", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"}, map[string]interface{}{"version": ""}},
+		{"vector-linux-dnf", "vector", sysinfo.SysInfo{Os: "linux", PkgMngr: "dnf", Arch: "amd64"}, map[string]interface{}{"version": ""}},
+		{"alloy-linux-bin-openrc", "alloy", sysinfo.SysInfo{Os: "linux", Arch: "amd64", InitSystem: sysinfo.InitOpenRC}, map[string]interface{}{"startService": true}},
+		{"collectd-linux-apt-runit", "collectd", sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64", InitSystem: sysinfo.InitRunit}, map[string]interface{}{"version": "", "startService": true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			options := map[string]interface{}{
+				"apikey":   "my-api-key",
+				"version":  "1.2.3",
+				"hostname": "web-1",
+				"plugins":  []string{},
+			}
+			for key, value := range test.options {
+				options[key] = value
+			}
+
+			script, err := Render(test.agent, options, test.target)
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", test.golden+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(path, script, 0644))
+			}
+
+			expected, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(script))
+		})
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	_, err := Render("telegraf", map[string]interface{}{
+		"apikey":       "my-api-key",
+		"version":      "1.2.3",
+		"plugins":      []string{},
+		"hostnameMode": "cloud",
+	}, sysinfo.SysInfo{Os: "linux", PkgMngr: "apt", Arch: "amd64"})
+	require.Error(t, err)
+}
+
+func TestValidateTarget(t *testing.T) {
+	require.NoError(t, ValidateTarget(sysinfo.SysInfo{Os: "linux", PkgMngr: "apt"}))
+	require.NoError(t, ValidateTarget(sysinfo.SysInfo{Os: "windows"}))
+	require.Error(t, ValidateTarget(sysinfo.SysInfo{Os: "windows", PkgMngr: "apt"}))
+	require.Error(t, ValidateTarget(sysinfo.SysInfo{Os: "plan9"}))
+}
@@ -2,11 +2,12 @@ package uninstall
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/hostedgraphite/hg-cli/agentmanager"
-	"github.com/hostedgraphite/hg-cli/agentmanager/utils"
 	"github.com/hostedgraphite/hg-cli/formatters"
 	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/profile"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 	cliUtils "github.com/hostedgraphite/hg-cli/utils"
 
@@ -16,6 +17,8 @@ import (
 func UninstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 	var agentName string
 	var completed bool
+	var user bool
+	var root string
 
 	cmd := &cobra.Command{
 		Use:   "uninstall <agent>",
@@ -28,13 +31,23 @@ func UninstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 				return nil
 			}
 
+			args = profile.AgentArgs(args)
 			err := validateArgs(args)
 			if err != nil {
 				return err
 			}
 			agentName = args[0]
-			// Validate if the cmd requires sudo
-			if cliUtils.AgentRequiresSudo(sysinfo.Os, "uninstall", sysinfo.PkgMngr, agentName) && !sysinfo.SudoPerm {
+			if root != "" {
+				if user || sysinfo.Os != "linux" {
+					return fmt.Errorf("--root can only be used for host installs on linux")
+				}
+				if root, err = filepath.Abs(root); err != nil {
+					return err
+				}
+			}
+			// Validate if the cmd requires sudo, user installs stay in $HOME and
+			// staged ones in the root
+			if !user && root == "" && cliUtils.AgentRequiresSudo(sysinfo.Os, "uninstall", sysinfo.PkgMngr, agentName) && !sysinfo.SudoPerm {
 				return fmt.Errorf("this cmd requires admin privileges, please run as root")
 			}
 			completed = true
@@ -45,7 +58,12 @@ func UninstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 				return nil
 			}
 
-			err := execute(agentName, sysinfo)
+			target := sysinfo
+			if root != "" {
+				target = sysinfo.Staged(root)
+			}
+
+			err := execute(agentName, target, user)
 			if err != nil {
 				return err
 			}
@@ -54,26 +72,38 @@ func UninstallCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&user, "user", false, "Uninstall an agent installed with --user")
+	cmd.Flags().StringVar(&root, "root", "", "Uninstall an agent staged under this directory with --root")
+
 	return cmd
 }
 
 func validateArgs(args []string) error {
 	var err error
 
-	if len(args) == 0 || !utils.ValidateAgent(args[0]) {
+	if len(args) == 0 || !agentmanager.ValidateAgent(args[0]) {
 		return fmt.Errorf("no agent specified or agent not supported; see 'cli agent -l' for compatible agents")
 	}
 
 	return err
 }
 
-func execute(agentName string, sysInfo sysinfo.SysInfo) error {
+func execute(agentName string, sysInfo sysinfo.SysInfo, user bool) error {
 	var err error
 	var summary formatters.SummaryContent
+	var uninstallPipeline *pipeline.Pipeline
 
 	agent := agentmanager.NewAgent(agentName, nil, sysInfo)
 	updates := make(chan *pipeline.Pipe)
-	uninstallPipeline, err := agent.UninstallPipeline(updates)
+	if user {
+		userAgent, ok := agent.(agentmanager.UserAgent)
+		if !ok {
+			return fmt.Errorf("%s has no --user install to uninstall", agentName)
+		}
+		uninstallPipeline, err = userAgent.UserUninstallPipeline(updates)
+	} else {
+		uninstallPipeline, err = agent.UninstallPipeline(updates)
+	}
 	if err != nil {
 		return err
 	}
@@ -88,23 +118,15 @@ func execute(agentName string, sysInfo sysinfo.SysInfo) error {
 	if err != nil {
 		return err
 	}
+	// The runner only reports its own errors, a failed step is on the pipeline.
+	if uninstallPipeline.Failed() {
+		return uninstallPipeline.Err
+	}
 
-	data := formatters.ActionSummary{
-		Agent:   agentName,
+	summary = agentmanager.NewSummary(agentName, formatters.ActionSummary{
 		Success: true,
 		Action:  "Uninstall",
-	}
-
-	switch agentName {
-	case "telegraf":
-		summary = &formatters.TelegrafSummary{
-			ActionSummary: data,
-		}
-	case "otel":
-		summary = &formatters.OtelContribSummary{
-			ActionSummary: data,
-		}
-	}
+	}, nil)
 
 	fmt.Println(formatters.GenerateCliSummary(summary))
 
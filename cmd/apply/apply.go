@@ -0,0 +1,153 @@
+package apply
+
+import (
+	"fmt"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/auth"
+	"github.com/hostedgraphite/hg-cli/formatters"
+	"github.com/hostedgraphite/hg-cli/pipeline"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/views/config"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+func ApplyCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
+	var (
+		completed bool
+		file      string
+		mirror    string
+		spec      Spec
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "Install or configure an agent from a spec file.",
+		Long: "Install or configure an agent from a yaml spec file. Agents that are already installed " +
+			"are reconfigured, so apply can be re-run safely from cloud-init or configuration management.",
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			spec, err = LoadSpec(file)
+			if err != nil {
+				return err
+			}
+			spec = spec.WithProfile(profile.Current())
+			if !spec.ApiKey.IsSet() {
+				keyFile, _ := cmd.Flags().GetString("api-key-file")
+				key, err := auth.Lookup(keyFile)
+				if err != nil {
+					return err
+				}
+				spec.ApiKey.Value = key.Value
+			}
+
+			if err = spec.Validate(sysinfo.Os); err != nil {
+				return err
+			}
+
+			if cliUtils.AgentRequiresSudo(sysinfo.Os, "install", sysinfo.PkgMngr, spec.AgentName()) && !sysinfo.SudoPerm {
+				return fmt.Errorf("this cmd requires admin privileges, please run as root")
+			}
+
+			completed = true
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !completed {
+				return nil
+			}
+
+			options, err := spec.Options()
+			if err != nil {
+				return err
+			}
+			cliUtils.AddSecret(options["apikey"].(string))
+			if err := cliUtils.ValidateAPIKey(options["apikey"].(string)); err != nil {
+				return err
+			}
+
+			target := sysinfo
+			target.Mirror = mirror
+
+			return execute(spec.AgentName(), options, target)
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the spec file (required)")
+	cmd.Flags().StringVar(&mirror, "mirror", "", "Download agent release archives from this mirror, laid out as <mirror>/<host>/<path>")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func execute(agentName string, options map[string]interface{}, sysInfo sysinfo.SysInfo) error {
+	var err error
+	var agentPipeline *pipeline.Pipeline
+
+	def, _ := agentmanager.Lookup(agentName)
+	serviceSettings := def.ServiceSettings(sysInfo)
+
+	if plugins, _ := options["plugins"].([]string); len(plugins) == 0 && len(def.DefaultPlugins) > 0 {
+		catalog, err := config.LoadPlugins()
+		if err != nil {
+			return err
+		}
+		options["plugins"] = catalog.SupportedOn(sysInfo.Os, def.DefaultPlugins)
+	}
+
+	agentmanager.PlatformDefaults(options, sysInfo)
+	if warning := agentmanager.PlatformWarning(sysInfo); warning != "" {
+		fmt.Println(styles.DefaultStyles().Cli.Render("Warning: " + warning))
+	}
+
+	action := "Install"
+	agent := def.New(options, sysInfo)
+	if agent.IsInstalled() {
+		action = "Configure"
+	}
+
+	updates := make(chan *pipeline.Pipe)
+	if action == "Configure" {
+		agentPipeline, err = agent.ConfigurePipeline(updates)
+	} else {
+		agentPipeline, err = agent.InstallPipeline(updates)
+	}
+	if err != nil {
+		return err
+	}
+
+	runner := pipeline.NewRunner(
+		agentPipeline,
+		true,
+		updates,
+	)
+	err = runner.Run()
+	if err != nil {
+		return err
+	}
+	// The runner only reports its own errors, a failed step is on the pipeline.
+	if agentPipeline.Failed() {
+		return agentPipeline.Err
+	}
+
+	summary := agentmanager.NewSummary(agentName, formatters.ActionSummary{
+		Success:    true,
+		Action:     action,
+		Config:     serviceSettings["configPath"],
+		StartCmd:   serviceSettings["startHint"],
+		RestartCmd: serviceSettings["restartHint"],
+		Endpoint:   endpoint.New(options).Describe(),
+	}, options)
+
+	fmt.Println(formatters.GenerateCliSummary(summary))
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package apply
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the answers file read by `hg-cli apply`. Every field maps onto the
+// same option the install flags and the TUI set.
+type Spec struct {
+	Agent   string     `yaml:"agent"`
+	Version string     `yaml:"version"`
+	ApiKey  ApiKeySpec `yaml:"apiKey"`
+
+	Prefix         string   `yaml:"prefix"`
+	PrefixSegments []string `yaml:"prefixSegments"`
+	Hostname       string   `yaml:"hostname"`
+	HostnameMode   string   `yaml:"hostnameMode"`
+
+	Endpoint EndpointSpec `yaml:"endpoint"`
+	Service  ServiceSpec  `yaml:"service"`
+
+	// AgentFields are the remaining fields, decoded into the agent's own spec
+	// once the agent is known.
+	AgentFields map[string]interface{} `yaml:",inline"`
+}
+
+// ApiKeySpec says where the api key comes from, the same as in a config
+// file profile.
+type ApiKeySpec = profile.ApiKey
+
+type EndpointSpec struct {
+	Mode               string `yaml:"mode"`
+	Address            string `yaml:"address"`
+	TLSCA              string `yaml:"tlsCA"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+type ServiceSpec struct {
+	// Start enables and (re)starts the agent service once configured.
+	Start bool `yaml:"start"`
+	// ApiKeyEnvFile keeps the api key in a root-only environment file loaded
+	// by the service, telegraf and otel on systemd only.
+	ApiKeyEnvFile bool `yaml:"apiKeyEnvFile"`
+}
+
+func LoadSpec(path string) (Spec, error) {
+	var spec Spec
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return spec, fmt.Errorf("error reading spec: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("error parsing spec: %v", err)
+	}
+	if def, ok := agentmanager.Lookup(spec.Agent); ok {
+		if _, err := spec.agentSpec(def); err != nil {
+			return spec, fmt.Errorf("error parsing spec: %v", err)
+		}
+	}
+
+	return spec, nil
+}
+
+// agentSpec decodes the agent fields into the agent's own spec, rejecting
+// fields the agent doesn't have.
+func (s Spec) agentSpec(def agentmanager.Definition) (agentmanager.Spec, error) {
+	var spec agentmanager.Spec
+	known := map[string]interface{}{}
+	if def.Spec != nil {
+		spec = def.Spec()
+		content, err := yaml.Marshal(spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(content, &known); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := slices.Sorted(maps.Keys(s.AgentFields))
+	for _, field := range fields {
+		if _, ok := known[field]; !ok {
+			return nil, fmt.Errorf("%s is not supported by the %s agent", field, def.Name)
+		}
+	}
+	if spec == nil {
+		return nil, nil
+	}
+
+	content, err := yaml.Marshal(s.AgentFields)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// WithProfile fills in the agent, api key, endpoint and prefix the spec
+// leaves out from the config file profile.
+func (s Spec) WithProfile(p profile.Profile) Spec {
+	if s.Agent == "" {
+		s.Agent = p.Agent
+	}
+	if !s.ApiKey.IsSet() {
+		s.ApiKey = p.ApiKey
+	}
+	if s.Endpoint == (EndpointSpec{}) {
+		s.Endpoint = EndpointSpec(p.Endpoint)
+	}
+	if s.Prefix == "" {
+		s.Prefix = p.Prefix
+	}
+	if len(s.PrefixSegments) == 0 {
+		s.PrefixSegments = p.PrefixSegments
+	}
+	return s
+}
+
+// AgentName returns the agent in the form used by the install command.
+func (s Spec) AgentName() string {
+	if def, ok := agentmanager.Lookup(s.Agent); ok {
+		return def.Name
+	}
+	return strings.ToLower(s.Agent)
+}
+
+func (s Spec) Validate(os string) error {
+	def, ok := agentmanager.Lookup(s.Agent)
+	if !ok {
+		return fmt.Errorf("agent %q not supported; see 'hg-cli agent -l' for compatible agents", s.Agent)
+	}
+
+	sources := 0
+	for _, source := range []string{s.ApiKey.Value, s.ApiKey.Env, s.ApiKey.File} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("apiKey needs exactly one of value, env or file")
+	}
+
+	if s.Service.ApiKeyEnvFile && !slices.Contains(def.Flags, "api-key-env-file") {
+		return fmt.Errorf("service.apiKeyEnvFile is not supported by the %s agent", def.Name)
+	}
+	if _, err := s.agentSpec(def); err != nil {
+		return err
+	}
+
+	options := s.options()
+	if err := naming.New(options).Validate(); err != nil {
+		return err
+	}
+	if def.Validate == nil {
+		return nil
+	}
+	return def.Validate(options, os)
+}
+
+// ResolveApiKey reads the api key from whichever source the spec names.
+func (s Spec) ResolveApiKey() (string, error) {
+	return s.ApiKey.Resolve()
+}
+
+// Options converts the spec into agent options, resolving the api key.
+func (s Spec) Options() (map[string]interface{}, error) {
+	apikey, err := s.ResolveApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	options := s.options()
+	options["apikey"] = apikey
+
+	return options, nil
+}
+
+func (s Spec) options() map[string]interface{} {
+	options := map[string]interface{}{
+		"version":               s.Version,
+		"prefix":                s.Prefix,
+		"segments":              s.PrefixSegments,
+		"hostname":              s.Hostname,
+		"hostnameMode":          s.HostnameMode,
+		"endpoint":              s.Endpoint.Mode,
+		"endpointAddress":       s.Endpoint.Address,
+		"tlsCA":                 s.Endpoint.TLSCA,
+		"tlsInsecureSkipVerify": s.Endpoint.InsecureSkipVerify,
+		"startService":          s.Service.Start,
+		"apiKeyEnvFile":         s.Service.ApiKeyEnvFile,
+	}
+
+	if def, ok := agentmanager.Lookup(s.Agent); ok {
+		if spec, err := s.agentSpec(def); err == nil && spec != nil {
+			spec.Options(options)
+		}
+	}
+
+	return options
+}
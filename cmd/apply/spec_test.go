@@ -0,0 +1,97 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/agents"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeSpec(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "hg.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadSpec(t *testing.T) {
+	t.Setenv("HG_API_KEY", "my-key")
+
+	spec, err := LoadSpec(writeSpec(t, `
+agent: telegraf
+version: 1.33.1
+apiKey:
+  env: HG_API_KEY
+plugins: [cpu, mem]
+prefixSegments: [prod]
+endpoint:
+  mode: tls
+service:
+  start: true
+`))
+	require.NoError(t, err)
+	require.NoError(t, spec.Validate("linux"))
+
+	options, err := spec.Options()
+	require.NoError(t, err)
+	require.Equal(t, "my-key", options["apikey"])
+	require.Equal(t, "1.33.1", options["version"])
+	require.Equal(t, []string{"cpu", "mem"}, options["plugins"])
+	require.Equal(t, []string{"prod"}, options["segments"])
+	require.Equal(t, "tls", options["endpoint"])
+	require.Equal(t, true, options["startService"])
+}
+
+func TestLoadSpecUnknownField(t *testing.T) {
+	_, err := LoadSpec(writeSpec(t, "agent: telegraf\npluginz: [cpu]\n"))
+	require.Error(t, err)
+}
+
+func TestLoadSpecUnsupportedField(t *testing.T) {
+	_, err := LoadSpec(writeSpec(t, "agent: telegraf\nreceivers: [nginx]\n"))
+	require.ErrorContains(t, err, "receivers is not supported by the telegraf agent")
+}
+
+func parseSpec(t *testing.T, content string) Spec {
+	var spec Spec
+	require.NoError(t, yaml.Unmarshal([]byte(content), &spec))
+	return spec
+}
+
+func TestCollectdSpecOptions(t *testing.T) {
+	spec := parseSpec(t, "agent: collectd\napiKey: {value: key}\nplugins: [cpu, load]\n")
+	require.NoError(t, spec.Validate("linux"))
+
+	options := spec.options()
+	require.Equal(t, []string{"cpu", "load"}, options["collectdPlugins"])
+	require.Empty(t, options["plugins"])
+}
+
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"Unknown Agent", "agent: munin\napiKey: {value: key}"},
+		{"No Api Key", "agent: telegraf"},
+		{"Two Api Key Sources", "agent: telegraf\napiKey: {value: key, env: HG_API_KEY}"},
+		{"Otel Options On Telegraf", "agent: telegraf\napiKey: {value: key}\nreceivers: [nginx]"},
+		{"Telegraf Options On Otel", "agent: otel\napiKey: {value: key}\nplugins: [cpu]"},
+		{"Unknown Collectd Plugin", "agent: collectd\napiKey: {value: key}\nplugins: [gpu]"},
+		{"Collectd TLS", "agent: collectd\napiKey: {value: key}\nendpoint: {mode: tls}"},
+		{"StatsD On Otel", "agent: otel\napiKey: {value: key}\nstatsd: {enabled: true}"},
+		{"Bad StatsD Port", "agent: telegraf\napiKey: {value: key}\nstatsd: {enabled: true, port: 70000}"},
+		{"Logs On Telegraf", "agent: telegraf\napiKey: {value: key}\nlogs: {format: nginx}"},
+		{"Vector Plain Without Matches", "agent: vector\napiKey: {value: key}\nlogs: {format: plain}"},
+		{"Otel TLS", "agent: opentelemetry\napiKey: {value: key}\nendpoint: {mode: tls}"},
+		{"Env File On Alloy", "agent: alloy\napiKey: {value: key}\nservice: {apiKeyEnvFile: true}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Error(t, parseSpec(t, test.spec).Validate("linux"))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hostedgraphite/hg-cli/auth"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/styles"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+func AuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth <command>",
+		Short: "Store the API key so commands don't need --api-key.",
+		Long: "Store the API key of the current profile in the OS keyring or an encrypted file. " +
+			"Commands use it when no --api-key, --api-key-file, $" + auth.EnvVar + " or profile apiKey is given.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(LoginCmd())
+	cmd.AddCommand(LogoutCmd())
+	cmd.AddCommand(StatusCmd())
+
+	return cmd
+}
+
+func LoginCmd() *cobra.Command {
+	var storeName string
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store the API key for the current profile.",
+		Long: "Store the API key for the current profile. The key is read from --api-key-file, stdin when piped, " +
+			"or prompted for, so it never appears in ps or the shell history.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if storeName == "" {
+				storeName = "file"
+				if _, err := auth.NewStore("keyring"); err == nil {
+					storeName = "keyring"
+				}
+			}
+			store, err := auth.NewStore(storeName)
+			if err != nil {
+				return err
+			}
+
+			keyFile, _ := cmd.Flags().GetString("api-key-file")
+			apikey, err := readApiKey(keyFile)
+			if err != nil {
+				return err
+			}
+			cliUtils.AddSecret(apikey)
+
+			if err := cliUtils.ValidateAPIKey(apikey); err != nil {
+				return err
+			}
+			if err := store.Set(profile.Name(), apikey); err != nil {
+				return err
+			}
+
+			fmt.Println(styles.DefaultStyles().Cli.Render(fmt.Sprintf("Stored the api key %s for profile %s in the %s", cliUtils.MaskKey(apikey), profile.Name(), store.Name())))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&storeName, "store", "", "Where to store the key: keyring or file, defaults to the keyring when there is one")
+
+	return cmd
+}
+
+// readApiKey reads the key for login from the key file, stdin when it's
+// piped, or a password prompt.
+func readApiKey(keyFile string) (string, error) {
+	if keyFile != "" {
+		return auth.ReadKeyFile(keyFile)
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return auth.ReadKeyFile("-")
+	}
+
+	var apikey string
+	err := huh.NewInput().
+		Title("Enter your Hosted Graphite API key").
+		Prompt("API Key: ").
+		EchoMode(huh.EchoModePassword).
+		Value(&apikey).
+		Run()
+	if err != nil {
+		return "", err
+	}
+	if apikey == "" {
+		return "", fmt.Errorf("api key is empty")
+	}
+	return apikey, nil
+}
+
+func LogoutCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "logout",
+		Short:         "Remove the stored API key of the current profile.",
+		Long:          "Remove the API key of the current profile from the OS keyring and the encrypted file.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := styles.DefaultStyles()
+
+			var removed bool
+			for _, store := range auth.Stores() {
+				deleted, err := store.Delete(profile.Name())
+				if err != nil {
+					return err
+				}
+				if deleted {
+					removed = true
+					fmt.Println(s.Cli.Render(fmt.Sprintf("Removed the api key for profile %s from the %s", profile.Name(), store.Name())))
+				}
+			}
+
+			if !removed {
+				fmt.Println(s.Cli.Render(fmt.Sprintf("No api key is stored for profile %s", profile.Name())))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func StatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "status",
+		Short:         "Show which API key commands will use.",
+		Long:          "Show the masked API key commands will use for the current profile and where it comes from.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyFile, _ := cmd.Flags().GetString("api-key-file")
+			key, err := auth.Lookup(keyFile)
+			if err != nil {
+				return err
+			}
+
+			s := styles.DefaultStyles()
+			if key.Value == "" {
+				fmt.Println(s.Cli.Render(fmt.Sprintf("No api key found for profile %s, run `hg-cli auth login` or set $%s", profile.Name(), auth.EnvVar)))
+				return nil
+			}
+
+			fmt.Println(s.Cli.Render(fmt.Sprintf("Profile: %s\nApi key: %s\nSource:  %s", profile.Name(), cliUtils.MaskKey(key.Value), key.Source)))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,365 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
+
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+const apiHost = "api.hostedgraphite.com"
+
+const timeout = 5 * time.Second
+
+// Checks shell out and hit the network, tests swap these out.
+var (
+	lookPath   = exec.LookPath
+	readFile   = os.ReadFile
+	lookupHost = func(host string) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return net.DefaultResolver.LookupHost(ctx, host)
+	}
+	dial = func(address string) error {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	serverTime = func(url string) (time.Time, error) {
+		client := http.Client{Timeout: timeout}
+		resp, err := client.Head(url)
+		if err != nil {
+			return time.Time{}, err
+		}
+		resp.Body.Close()
+		return http.ParseTime(resp.Header.Get("Date"))
+	}
+	now = time.Now
+)
+
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type System struct {
+	Os            string `json:"os"`
+	Arch          string `json:"arch"`
+	Distro        string `json:"distro,omitempty"`
+	DistroVersion string `json:"distro_version,omitempty"`
+	PkgMngr       string `json:"package_manager,omitempty"`
+	InitSystem    string `json:"init_system,omitempty"`
+	Container     string `json:"container,omitempty"`
+	// Virtualization is wsl or the cloud provider.
+	Virtualization string `json:"virtualization,omitempty"`
+	Root           bool   `json:"root"`
+}
+
+type Report struct {
+	System System  `json:"system"`
+	Checks []Check `json:"checks"`
+}
+
+// Count returns how many checks ended with the status.
+func (r Report) Count(status string) int {
+	var count int
+	for _, check := range r.Checks {
+		if check.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Run checks the host can install agents and reach Hosted Graphite.
+func Run(sysInfo sysinfo.SysInfo, e endpoint.Endpoint) Report {
+	report := Report{
+		System: System{
+			Os:             sysInfo.Os,
+			Arch:           sysInfo.Arch,
+			Distro:         sysInfo.Distro,
+			DistroVersion:  sysInfo.DistroVersion,
+			PkgMngr:        sysInfo.PkgMngr,
+			InitSystem:     sysInfo.InitSystem,
+			Container:      sysInfo.Container,
+			Virtualization: sysInfo.Virtualization,
+			Root:           sysInfo.SudoPerm,
+		},
+	}
+
+	report.Checks = append(report.Checks,
+		checkSystem(sysInfo),
+		checkRoot(sysInfo),
+		checkTools(sysInfo),
+	)
+	report.Checks = append(report.Checks, checkDNS(e)...)
+	report.Checks = append(report.Checks, checkTCP(e)...)
+	report.Checks = append(report.Checks,
+		checkClock(),
+		checkDiskSpace(),
+	)
+	if sysInfo.Os == "linux" {
+		report.Checks = append(report.Checks, checkSELinux())
+	}
+	report.Checks = append(report.Checks, checkInstalls(sysInfo))
+
+	return report
+}
+
+func checkSystem(sysInfo sysinfo.SysInfo) Check {
+	check := Check{Name: "System", Status: Pass}
+
+	parts := []string{sysInfo.Os + "/" + sysInfo.Arch}
+	if sysInfo.Distro != "" {
+		parts = append(parts, strings.TrimSpace(sysInfo.Distro+" "+sysInfo.DistroVersion))
+	}
+	if sysInfo.PkgMngr != "" {
+		parts = append(parts, sysInfo.PkgMngr)
+	}
+	if sysInfo.InitSystem != "" {
+		parts = append(parts, "init "+sysInfo.InitSystem)
+	}
+	if sysInfo.Container != "" {
+		parts = append(parts, sysInfo.Container+" container")
+	}
+	if sysInfo.Virtualization != "" {
+		parts = append(parts, sysInfo.Virtualization)
+	}
+	check.Detail = strings.Join(parts, ", ")
+
+	switch {
+	case sysInfo.Os != "linux" && sysInfo.Os != "darwin" && sysInfo.Os != "windows":
+		check.Status = Fail
+		check.Detail += ": unsupported operating system"
+	case sysInfo.Containerized() || sysInfo.Virtualization == sysinfo.VirtualizationWSL:
+		check.Status = Warn
+		check.Detail += ": " + agentmanager.PlatformWarning(sysInfo)
+	case sysInfo.Os == "linux" && sysInfo.PkgMngr == "":
+		check.Status = Warn
+		check.Detail += ": no supported package manager, agents install from release archives"
+	case sysInfo.InitSystem == sysinfo.InitNone:
+		check.Status = Warn
+		check.Detail += ": no init system, agents have to be started by hand"
+	}
+
+	return check
+}
+
+func checkRoot(sysInfo sysinfo.SysInfo) Check {
+	if sysInfo.SudoPerm {
+		return Check{Name: "Privileges", Status: Pass, Detail: "running with admin privileges"}
+	}
+
+	detail := "not root, installs and uninstalls need sudo"
+	if sysInfo.Os == "windows" {
+		detail = "not an administrator, installs and uninstalls need an elevated shell"
+	} else if sysInfo.PkgMngr == "brew" {
+		// Homebrew installs run as the user.
+		return Check{Name: "Privileges", Status: Pass, Detail: "not root, homebrew installs don't need it"}
+	}
+	return Check{Name: "Privileges", Status: Warn, Detail: detail}
+}
+
+// requiredTools lists the commands install pipelines shell out to.
+func requiredTools(sysInfo sysinfo.SysInfo) []string {
+	switch sysInfo.Os {
+	case "linux":
+		tools := []string{"curl", "wget", "tar"}
+		switch sysInfo.PkgMngr {
+		case "apt":
+			tools = append(tools, "gpg", "dpkg")
+		case "yum", "dnf", "zypper":
+			tools = append(tools, "rpm")
+		}
+		return tools
+	case "darwin":
+		return []string{"curl", "tar"}
+	case "windows":
+		return []string{"powershell"}
+	}
+	return nil
+}
+
+func checkTools(sysInfo sysinfo.SysInfo) Check {
+	var found, missing []string
+
+	for _, tool := range requiredTools(sysInfo) {
+		if _, err := lookPath(tool); err != nil {
+			missing = append(missing, tool)
+		} else {
+			found = append(found, tool)
+		}
+	}
+
+	if len(missing) > 0 {
+		return Check{Name: "Tools", Status: Warn, Detail: "missing " + strings.Join(missing, ", ")}
+	}
+	return Check{Name: "Tools", Status: Pass, Detail: strings.Join(found, ", ")}
+}
+
+func carbonHost(e endpoint.Endpoint) string {
+	host, _, _ := net.SplitHostPort(e.HostPort())
+	return host
+}
+
+func checkDNS(e endpoint.Endpoint) []Check {
+	var checks []Check
+
+	for _, host := range []string{carbonHost(e), apiHost} {
+		check := Check{Name: "DNS " + host, Status: Pass}
+		addrs, err := lookupHost(host)
+		if err != nil {
+			check.Status = Fail
+			check.Detail = err.Error()
+		} else {
+			check.Detail = strings.Join(addrs, ", ")
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkTCP connects to the carbon ports and the api. A custom endpoint
+// address is checked as given.
+func checkTCP(e endpoint.Endpoint) []Check {
+	var checks []Check
+
+	addresses := []string{e.HostPort()}
+	if e.Address == "" {
+		tls := endpoint.New(map[string]interface{}{"endpoint": endpoint.TLS})
+		addresses = append(addresses, tls.HostPort())
+	}
+	addresses = append(addresses, net.JoinHostPort(apiHost, "443"))
+
+	for _, address := range addresses {
+		check := Check{Name: "TCP " + address, Status: Pass, Detail: "reachable"}
+		if err := dial(address); err != nil {
+			check.Status = Fail
+			check.Detail = err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkClock compares the local clock with the api's Date header, metrics
+// stamped too far from the server's time land in the wrong place.
+func checkClock() Check {
+	check := Check{Name: "Clock", Status: Pass}
+
+	sent := now()
+	server, err := serverTime("https://" + apiHost + "/")
+	if err != nil {
+		check.Status = Warn
+		check.Detail = fmt.Sprintf("couldn't get the server time: %v", err)
+		return check
+	}
+	// Assume the server stamped the response halfway through the request.
+	local := sent.Add(now().Sub(sent) / 2)
+
+	skew := local.Sub(server).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	check.Detail = fmt.Sprintf("%s off the server time", skew)
+
+	switch {
+	case skew > 5*time.Minute:
+		check.Status = Fail
+	case skew > 30*time.Second:
+		check.Status = Warn
+	}
+
+	return check
+}
+
+const (
+	minDiskSpace  = 200 << 20
+	warnDiskSpace = 1 << 30
+)
+
+// checkDiskSpace looks at the temp dir, where installs download packages.
+func checkDiskSpace() Check {
+	dir := "/tmp"
+	if runtime.GOOS == "windows" {
+		dir = os.TempDir()
+	}
+
+	check := Check{Name: "Disk " + dir, Status: Pass}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		check.Status = Warn
+		check.Detail = err.Error()
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d MB free", free>>20)
+
+	switch {
+	case free < minDiskSpace:
+		check.Status = Fail
+	case free < warnDiskSpace:
+		check.Status = Warn
+	}
+
+	return check
+}
+
+func checkSELinux() Check {
+	check := Check{Name: "SELinux", Status: Pass}
+
+	enforce, err := readFile("/sys/fs/selinux/enforce")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		check.Detail = "disabled"
+	case err != nil:
+		check.Status = Warn
+		check.Detail = err.Error()
+	case strings.TrimSpace(string(enforce)) == "1":
+		// Binary installs relabel on Fedora and RHEL only.
+		check.Status = Warn
+		check.Detail = "enforcing, agents installed from release archives may need restorecon"
+	default:
+		check.Detail = "permissive"
+	}
+
+	return check
+}
+
+func checkInstalls(sysInfo sysinfo.SysInfo) Check {
+	var installed []string
+
+	for _, def := range agentmanager.Definitions() {
+		if def.New(map[string]interface{}{}, sysInfo).IsInstalled() {
+			configPath := def.ServiceSettings(sysInfo)["configPath"]
+			installed = append(installed, fmt.Sprintf("%s (%s)", def.Name, configPath))
+		}
+	}
+
+	if len(installed) == 0 {
+		return Check{Name: "Agents", Status: Pass, Detail: "none installed"}
+	}
+	return Check{Name: "Agents", Status: Pass, Detail: strings.Join(installed, ", ")}
+}
@@ -0,0 +1,84 @@
+package doctor
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTools(t *testing.T) {
+	defer func(original func(string) (string, error)) { lookPath = original }(lookPath)
+	lookPath = func(tool string) (string, error) {
+		if tool == "gpg" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + tool, nil
+	}
+
+	check := checkTools(sysinfo.SysInfo{Os: "linux", PkgMngr: "apt"})
+	require.Equal(t, Check{Name: "Tools", Status: Warn, Detail: "missing gpg"}, check)
+
+	check = checkTools(sysinfo.SysInfo{Os: "linux", PkgMngr: "dnf"})
+	require.Equal(t, Check{Name: "Tools", Status: Pass, Detail: "curl, wget, tar, rpm"}, check)
+}
+
+func TestCheckClock(t *testing.T) {
+	defer func(original func(string) (time.Time, error)) { serverTime = original }(serverTime)
+	defer func(original func() time.Time) { now = original }(now)
+
+	local := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return local }
+
+	tests := []struct {
+		skew   time.Duration
+		status string
+	}{
+		{2 * time.Second, Pass},
+		{-45 * time.Second, Warn},
+		{10 * time.Minute, Fail},
+	}
+	for _, test := range tests {
+		serverTime = func(string) (time.Time, error) { return local.Add(test.skew), nil }
+		require.Equal(t, test.status, checkClock().Status, "skew %s", test.skew)
+	}
+
+	serverTime = func(string) (time.Time, error) { return time.Time{}, errors.New("timeout") }
+	require.Equal(t, Warn, checkClock().Status)
+}
+
+func TestCheckSELinux(t *testing.T) {
+	defer func(original func(string) ([]byte, error)) { readFile = original }(readFile)
+
+	readFile = func(string) ([]byte, error) { return nil, fs.ErrNotExist }
+	require.Equal(t, Check{Name: "SELinux", Status: Pass, Detail: "disabled"}, checkSELinux())
+
+	readFile = func(string) ([]byte, error) { return []byte("0\n"), nil }
+	require.Equal(t, Pass, checkSELinux().Status)
+
+	readFile = func(string) ([]byte, error) { return []byte("1\n"), nil }
+	require.Equal(t, Warn, checkSELinux().Status)
+}
+
+func TestCheckTCPCustomAddress(t *testing.T) {
+	defer func(original func(string) error) { dial = original }(dial)
+
+	var dialed []string
+	dial = func(address string) error {
+		dialed = append(dialed, address)
+		if address == "relay.internal:2003" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	checks := checkTCP(endpoint.Endpoint{Address: "relay.internal:2003"})
+	require.Equal(t, []string{"relay.internal:2003", "api.hostedgraphite.com:443"}, dialed)
+	require.Equal(t, Fail, checks[0].Status)
+	require.Equal(t, Pass, checks[1].Status)
+}
@@ -0,0 +1,81 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/styles"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+
+	"github.com/spf13/cobra"
+)
+
+func DoctorCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
+	var (
+		jsonOutput bool
+		address    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check this host can install agents and reach Hosted Graphite.",
+		Long: "Run a set of environment checks: the detected system, privileges, required tools, " +
+			"DNS and TCP reachability of the carbon endpoint and api, clock skew, temp disk space, " +
+			"SELinux and existing agent installs. Include the --json output when opening a support ticket.",
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return endpoint.Endpoint{Address: address}.Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report := Run(sysinfo, endpoint.Endpoint{Address: address})
+
+			if jsonOutput {
+				return WriteJSON(os.Stdout, report)
+			}
+			_, err := io.WriteString(os.Stdout, Render(report))
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the report as json")
+	cmd.Flags().StringVar(&address, "endpoint-address", "", "Check a custom carbon host:port instead of Hosted Graphite's")
+
+	return cmd
+}
+
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// Render formats the report for a terminal.
+func Render(report Report) string {
+	s := styles.DoctorStyles()
+	var b strings.Builder
+
+	var width int
+	for _, check := range report.Checks {
+		width = max(width, len(check.Name))
+	}
+
+	for _, check := range report.Checks {
+		var status string
+		switch check.Status {
+		case Pass:
+			status = s.Pass.Render("PASS")
+		case Warn:
+			status = s.Warn.Render("WARN")
+		default:
+			status = s.Fail.Render("FAIL")
+		}
+		fmt.Fprintf(&b, "%s  %s %s\n", status, s.Name.Render(fmt.Sprintf("%-*s", width, check.Name)), s.Detail.Render(check.Detail))
+	}
+
+	fmt.Fprintf(&b, "\n%d passed, %d warnings, %d failed\n", report.Count(Pass), report.Count(Warn), report.Count(Fail))
+	return b.String()
+}
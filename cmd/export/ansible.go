@@ -0,0 +1,150 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApiKeyPlaceholder stands in for the api key while rendering the role, the
+// template swaps it for the hg_api_key variable.
+const ApiKeyPlaceholder = "__HG_API_KEY__"
+
+// RoleFile is one file of the generated role, relative to the role directory.
+type RoleFile struct {
+	Name    string
+	Content []byte
+}
+
+// Role is an ansible role running the install script rendered for an agent,
+// re-running it when the script changes, enabling the agent at boot and
+// restarting it after.
+type Role struct {
+	Agent       string
+	DisplayName string
+	Script      []byte
+	// RestartCmd is run by the handler through a shell, empty when there's
+	// no service.
+	RestartCmd string
+	// EnableCmd enables the service at boot through a shell, empty when
+	// there's no service.
+	EnableCmd string
+}
+
+type task struct {
+	Name   string                 `yaml:"name"`
+	Module map[string]interface{} `yaml:",inline"`
+}
+
+func (r Role) scriptName() string {
+	return fmt.Sprintf("install-%s.sh", r.Agent)
+}
+
+func (r Role) handlerName() string {
+	return "Restart " + r.DisplayName
+}
+
+// Files renders the role's defaults, tasks, handlers and script template.
+func (r Role) Files() ([]RoleFile, error) {
+	scriptPath := filepath.Join(ScriptDir, r.scriptName())
+
+	tasks := []task{
+		{"Check the Hosted Graphite api key is set", map[string]interface{}{
+			"ansible.builtin.assert": map[string]interface{}{
+				"that":     "hg_api_key | length > 0",
+				"fail_msg": "hg_api_key is required, keep it in ansible-vault",
+			},
+		}},
+		{"Create " + ScriptDir, map[string]interface{}{
+			"ansible.builtin.file": map[string]interface{}{
+				"path":  ScriptDir,
+				"state": "directory",
+				"mode":  "0700",
+			},
+		}},
+		{"Render the " + r.DisplayName + " install script", map[string]interface{}{
+			"ansible.builtin.template": map[string]interface{}{
+				"src":  r.scriptName() + ".j2",
+				"dest": scriptPath,
+				"mode": "0700",
+			},
+			"register": "hg_install_script",
+		}},
+	}
+	install := task{"Install and configure " + r.DisplayName, map[string]interface{}{
+		"ansible.builtin.command": scriptPath,
+		"when":                    "hg_install_script.changed",
+	}}
+	tasks = append(tasks, install)
+	// The script is rendered without starting the agent, enabling it is
+	// cheap enough to run every time and brings back a disabled service.
+	if r.EnableCmd != "" {
+		tasks = append(tasks, task{"Enable " + r.DisplayName + " at boot", map[string]interface{}{
+			"ansible.builtin.shell": r.EnableCmd,
+			"changed_when":          false,
+		}})
+	}
+
+	handlers := []task{}
+	if r.RestartCmd != "" {
+		install.Module["notify"] = r.handlerName()
+		handlers = append(handlers, task{r.handlerName(), map[string]interface{}{
+			"ansible.builtin.shell": r.RestartCmd,
+		}})
+	}
+
+	defaults := map[string]string{"hg_api_key": ""}
+
+	files := []RoleFile{}
+	for _, file := range []struct {
+		name    string
+		comment string
+		content interface{}
+	}{
+		{"defaults/main.yml", "# Your Hosted Graphite API key, the role needs to run with become.\n", defaults},
+		{"tasks/main.yml", "", tasks},
+		{"handlers/main.yml", "", handlers},
+	} {
+		content, err := encodeYaml(file.content)
+		if err != nil {
+			return nil, err
+		}
+		content = append([]byte("---\n"+file.comment), content...)
+		files = append(files, RoleFile{Name: file.name, Content: content})
+	}
+
+	// Everything but the api key is literal, the script is full of braces
+	// jinja would otherwise try to expand.
+	template := "{% raw %}" + strings.ReplaceAll(string(r.Script), ApiKeyPlaceholder, "{% endraw %}{{ hg_api_key }}{% raw %}") + "{% endraw %}"
+	files = append(files, RoleFile{Name: "templates/" + r.scriptName() + ".j2", Content: []byte(template)})
+
+	return files, nil
+}
+
+// WriteRole writes the role files into dir.
+func WriteRole(files []RoleFile, dir string) error {
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, file.Content, 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeYaml(content interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(content); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ScriptDir is where the generated install scripts are written on the host.
+const ScriptDir = "/var/lib/hg-cli"
+
+type cloudConfig struct {
+	WriteFiles []writeFile `yaml:"write_files"`
+	RunCmd     [][]string  `yaml:"runcmd"`
+}
+
+type writeFile struct {
+	Path        string `yaml:"path"`
+	Owner       string `yaml:"owner"`
+	Permissions string `yaml:"permissions"`
+	Content     string `yaml:"content"`
+}
+
+// CloudConfig renders a #cloud-config block writing the install script and
+// running it on first boot.
+func CloudConfig(agent string, script []byte) ([]byte, error) {
+	path := filepath.Join(ScriptDir, fmt.Sprintf("install-%s.sh", agent))
+
+	config := cloudConfig{
+		WriteFiles: []writeFile{{
+			Path:  path,
+			Owner: "root:root",
+			// Owner only, the script holds the api key.
+			Permissions: "0700",
+			Content:     string(script),
+		}},
+		RunCmd: [][]string{{"bash", path}},
+	}
+
+	content, err := encodeYaml(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte("#cloud-config\n"), content...), nil
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/service"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/flags"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/script"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+func ExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "export <format>",
+		Short:         "Export an agent install for configuration management.",
+		Long:          "Generate an Ansible role or cloud-init config reproducing what `hg-cli agent install` does on linux hosts.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(AnsibleCmd())
+	cmd.AddCommand(CloudInitCmd())
+
+	return cmd
+}
+
+// exportFlags are the target and agent flags shared by the export formats.
+type exportFlags struct {
+	target  sysinfo.SysInfo
+	version string
+	agent   flags.AgentFlags
+}
+
+func (f *exportFlags) register(cmd *cobra.Command) {
+	f.target.Os = "linux"
+	cmd.Flags().StringVar(&f.target.PkgMngr, "pkg", "", "Target package manager: apt, yum, dnf, zypper, apk or pacman, empty installs the release binaries")
+	cmd.Flags().StringVar(&f.target.Arch, "arch", "amd64", "Target architecture, e.g. amd64 or arm64")
+	cmd.Flags().StringVar(&f.target.Distro, "distro", "", "Target distribution, used for binary installs on rpm based systems")
+	cmd.Flags().StringVar(&f.target.InitSystem, "init", "", "Target init system: systemd, openrc, sysv, runit or none, defaults to the distro's own")
+	cmd.Flags().StringVar(&f.version, "version", "", "Agent version to install, defaults to the latest release")
+	f.agent.Register(cmd)
+}
+
+// agentArg is the <agent> argument, or the profile's default agent.
+func agentArg(args []string) string {
+	if args = profile.AgentArgs(args); len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
+func (f *exportFlags) validate(cmd *cobra.Command, agentName string) error {
+	if agentName == "" {
+		return fmt.Errorf("no agent specified and the profile has no default agent")
+	}
+	if !agentmanager.ValidateAgent(agentName) {
+		return fmt.Errorf("agent %q not supported; see 'hg-cli agent -l' for compatible agents", agentName)
+	}
+	if err := script.ValidateTarget(f.target); err != nil {
+		return err
+	}
+	return flags.ValidateAgentFlags(cmd, agentName)
+}
+
+func (f *exportFlags) render(agentName, apikey string, startService bool) ([]byte, error) {
+	options := map[string]interface{}{
+		"apikey":       apikey,
+		"version":      f.version,
+		"startService": startService,
+	}
+	f.agent.Options(options)
+	f.target.Mirror = f.agent.Mirror
+
+	return script.Render(agentName, options, f.target)
+}
+
+func AnsibleCmd() *cobra.Command {
+	var (
+		outputDir string
+		export    exportFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ansible <agent>",
+		Short: "Generate an Ansible role installing an agent.",
+		Long: "Generate an Ansible role with tasks, a script template and a restart handler running the same steps as " +
+			"`hg-cli agent install`. The api key is read from the hg_api_key variable.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return export.validate(cmd, agentArg(args))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def, _ := agentmanager.Lookup(agentArg(args))
+
+			installScript, err := export.render(def.Name, ApiKeyPlaceholder, false)
+			if err != nil {
+				return err
+			}
+
+			role := Role{
+				Agent:       def.Name,
+				DisplayName: def.DisplayName,
+				Script:      installScript,
+			}
+			// Without an init system there's no service to enable or for a
+			// handler to restart.
+			if service.Init(export.target) != sysinfo.InitNone {
+				settings := def.ServiceSettings(export.target)
+				// Roles run with become, sudo isn't needed. Hints can chain
+				// commands, node_exporter's restarts the collector too.
+				role.RestartCmd = strings.ReplaceAll(settings["restartHint"], "sudo ", "")
+				role.EnableCmd = strings.ReplaceAll(settings["enableHint"], "sudo ", "")
+			}
+
+			files, err := role.Files()
+			if err != nil {
+				return err
+			}
+			if err := WriteRole(files, outputDir); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "Wrote the %s role to %s\n", def.DisplayName, outputDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Directory the role is written to (required)")
+	export.register(cmd)
+	cmd.MarkFlagRequired("output-dir")
+
+	return cmd
+}
+
+func CloudInitCmd() *cobra.Command {
+	var (
+		apikey string
+		output string
+		export exportFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cloud-init <agent>",
+		Short: "Generate a cloud-init config installing an agent.",
+		Long: "Generate a #cloud-config block that writes and runs the same steps as `hg-cli agent install` on first boot, " +
+			"then starts the agent. The config contains the api key, keep the user data private.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return export.validate(cmd, agentArg(args))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def, _ := agentmanager.Lookup(agentArg(args))
+			if err := cliUtils.CheckKeyFormat(apikey); err != nil {
+				return err
+			}
+
+			installScript, err := export.render(def.Name, apikey, true)
+			if err != nil {
+				return err
+			}
+
+			config, err := CloudConfig(def.Name, installScript)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err = os.Stdout.Write(config)
+				return err
+			}
+			return os.WriteFile(output, config, 0600)
+		},
+	}
+
+	cmd.Flags().StringVar(&apikey, "api-key", "", "Your Hosted Graphite API key (required)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the config to a file instead of stdout")
+	export.register(cmd)
+	cmd.MarkFlagRequired("api-key")
+
+	return cmd
+}
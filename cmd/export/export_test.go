@@ -0,0 +1,138 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/agents"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRoleFiles(t *testing.T) {
+	role := Role{
+		Agent:       "telegraf",
+		DisplayName: "Telegraf",
+		Script:      []byte("#!/usr/bin/env bash\necho '" + ApiKeyPlaceholder + ".telegraf' ${current_step}\n"),
+		RestartCmd:  "service telegraf restart",
+		EnableCmd:   "systemctl enable telegraf",
+	}
+
+	files, err := role.Files()
+	require.NoError(t, err)
+
+	byName := map[string]string{}
+	for _, file := range files {
+		byName[file.Name] = string(file.Content)
+	}
+	require.Len(t, byName, 4)
+
+	var tasks []map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(byName["tasks/main.yml"]), &tasks))
+	require.Len(t, tasks, 5)
+	require.Equal(t, "Restart Telegraf", tasks[3]["notify"])
+	require.Equal(t, "/var/lib/hg-cli/install-telegraf.sh", tasks[3]["ansible.builtin.command"])
+	require.Equal(t, "Enable Telegraf at boot", tasks[4]["name"])
+	require.Equal(t, "systemctl enable telegraf", tasks[4]["ansible.builtin.shell"])
+
+	var handlers []map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(byName["handlers/main.yml"]), &handlers))
+	require.Equal(t, "Restart Telegraf", handlers[0]["name"])
+	require.Equal(t, "service telegraf restart", handlers[0]["ansible.builtin.shell"])
+
+	template := byName["templates/install-telegraf.sh.j2"]
+	require.True(t, strings.HasPrefix(template, "{% raw %}#!/usr/bin/env bash"))
+	require.Contains(t, template, "echo '{% endraw %}{{ hg_api_key }}{% raw %}.telegraf' ${current_step}")
+	require.NotContains(t, template, ApiKeyPlaceholder)
+}
+
+func TestRoleFilesWithoutService(t *testing.T) {
+	role := Role{Agent: "otel", DisplayName: "OpenTelemetry", Script: []byte("#!/usr/bin/env bash\n")}
+
+	files, err := role.Files()
+	require.NoError(t, err)
+
+	byName := map[string]string{}
+	for _, file := range files {
+		byName[file.Name] = string(file.Content)
+	}
+
+	var tasks []map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(byName["tasks/main.yml"]), &tasks))
+	require.Len(t, tasks, 4)
+	require.NotContains(t, tasks[3], "notify")
+
+	var handlers []map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(byName["handlers/main.yml"]), &handlers))
+	require.Empty(t, handlers)
+}
+
+func TestCloudConfig(t *testing.T) {
+	script := "#!/usr/bin/env bash\nset -euo pipefail\necho done\n"
+
+	config, err := CloudConfig("collectd", []byte(script))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(config), "#cloud-config\n"))
+
+	var parsed cloudConfig
+	require.NoError(t, yaml.Unmarshal(config, &parsed))
+	require.Equal(t, script, parsed.WriteFiles[0].Content)
+	require.Equal(t, "0700", parsed.WriteFiles[0].Permissions)
+	require.Equal(t, [][]string{{"bash", "/var/lib/hg-cli/install-collectd.sh"}}, parsed.RunCmd)
+}
+
+// releaseStubs puts wget, curl and tar on PATH, unpacking a fake telegraf
+// and otel release into the extraction directory.
+func releaseStubs(t *testing.T) {
+	bin := t.TempDir()
+	stubs := map[string]string{
+		"wget": `while [ $# -gt 0 ]; do [ "$1" = -O ] && touch "$2"; shift; done`,
+		"curl": `while [ $# -gt 0 ]; do [ "$1" = -o ] && touch "$2"; shift; done`,
+		"tar": `while [ $# -gt 0 ]; do [ "$1" = -C ] && dir="$2"; shift; done
+release="$dir/telegraf-1.2.3"
+mkdir -p "$release/etc/telegraf" "$release/usr/bin" "$release/usr/lib/telegraf/scripts"
+touch "$release/etc/telegraf/telegraf.conf" "$release/usr/lib/telegraf/scripts/telegraf.service"
+printf '#!/bin/sh\nprintf "[agent]\\n  hostname = \\"\\"\\n\\n[[outputs.graphite]]\\n  servers = [\\"localhost:2003\\"]\\n  prefix = \\"\\"\\n\\n[[inputs.cpu]]\\n"\n' > "$release/usr/bin/telegraf"
+printf '#!/bin/sh\n' > "$dir/otelcol-contrib"
+chmod +x "$release/usr/bin/telegraf" "$dir/otelcol-contrib"`,
+	}
+	for name, stub := range stubs {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+stub+"\n"), 0755))
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+}
+
+func TestRoleScriptReruns(t *testing.T) {
+	releaseStubs(t)
+
+	for _, agent := range []string{"telegraf", "otel"} {
+		t.Run(agent, func(t *testing.T) {
+			root := t.TempDir()
+			var export exportFlags
+			export.register(&cobra.Command{})
+			export.target = sysinfo.SysInfo{Os: "linux", Arch: "amd64"}.Staged(root)
+			export.version = "1.2.3"
+			installScript, err := export.render(agent, ApiKeyPlaceholder, false)
+			require.NoError(t, err)
+
+			files, err := Role{Agent: agent, Script: installScript}.Files()
+			require.NoError(t, err)
+			// Render the template the way ansible would.
+			template := string(files[len(files)-1].Content)
+			template = strings.NewReplacer("{% raw %}", "", "{% endraw %}", "", "{{ hg_api_key }}", "key").Replace(template)
+			path := filepath.Join(t.TempDir(), "install.sh")
+			require.NoError(t, os.WriteFile(path, []byte(template), 0700))
+
+			// A changed template re-runs the whole script on a configured host.
+			for run := 1; run <= 2; run++ {
+				output, err := exec.Command("bash", path).CombinedOutput()
+				require.NoError(t, err, "run %d: %s", run, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/kubernetes"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
+	"github.com/hostedgraphite/hg-cli/cmd/agent/flags"
+	"github.com/hostedgraphite/hg-cli/profile"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+func K8sCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "k8s <command>",
+		Short:         "Deploy agents to Kubernetes.",
+		Long:          "Generate the manifests deploying an agent to every node of a Kubernetes cluster.",
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(ManifestCmd())
+
+	return cmd
+}
+
+func ManifestCmd() *cobra.Command {
+	var (
+		apikey        string
+		version       string
+		namespace     string
+		outputDir     string
+		plugins       []string
+		scrapers      []string
+		interval      string
+		receivers     []string
+		kubeletstats  bool
+		kubeInventory bool
+		naming        flags.NamingFlags
+		endpoint      flags.EndpointFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "manifest <agent>",
+		Short: "Render the Kubernetes manifests for an agent.",
+		Long: "Render a namespace, RBAC, api key Secret, agent ConfigMap and node DaemonSet for an agent. " +
+			"The manifests are written to stdout, or to a directory with --output-dir.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			args = profile.AgentArgs(args)
+			if len(args) == 0 {
+				return fmt.Errorf("no agent specified and the profile has no default agent")
+			}
+			if !agentmanager.ValidateAgent(args[0]) {
+				return fmt.Errorf("agent %q not supported; see 'hg-cli agent -l' for compatible agents", args[0])
+			}
+			return flags.ValidateAgentFlags(cmd, args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cliUtils.CheckKeyFormat(apikey); err != nil {
+				return err
+			}
+
+			options := map[string]interface{}{
+				"apikey":        apikey,
+				"version":       version,
+				"plugins":       plugins,
+				"scrapers":      scrapers,
+				"interval":      interval,
+				"receivers":     receivers,
+				"kubeletstats":  kubeletstats,
+				"kubeInventory": kubeInventory,
+			}
+			naming.Options(options)
+			endpoint.Options(options)
+
+			manifest, err := newManifest(profile.AgentArgs(args)[0], namespace, options)
+			if err != nil {
+				return err
+			}
+
+			files, err := manifest.Files()
+			if err != nil {
+				return err
+			}
+
+			if outputDir != "" {
+				if err := kubernetes.WriteDir(files, outputDir); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Wrote %d manifests to %s\n", len(files), outputDir)
+				return nil
+			}
+
+			stream, err := kubernetes.Stream(files)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(stream)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&apikey, "api-key", "", "Your Hosted Graphite API key, stored in a Secret (required)")
+	cmd.Flags().StringVar(&version, "version", "", "Agent image tag, defaults to latest")
+	cmd.Flags().StringVar(&namespace, "namespace", kubernetes.DefaultNamespace, "Namespace the agent is deployed to")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Write one yaml file per resource kind to this directory instead of stdout")
+	cmd.Flags().StringSliceVar(&plugins, "plugins", []string{}, "Telegraf plugins to include (comma separated)")
+	cmd.Flags().StringSliceVar(&scrapers, "scrapers", []string{}, "Otel hostmetrics scrapers to enable, defaults to all (comma separated)")
+	cmd.Flags().StringVar(&interval, "collection-interval", otel.DefaultCollectionInterval, "Otel collection interval, e.g. 30s or 1m")
+	cmd.Flags().StringSliceVar(&receivers, "receivers", []string{}, "Additional Otel receivers: "+strings.Join(otel.OptionalReceiverNames(), ", "))
+	cmd.Flags().BoolVar(&kubeletstats, "kubeletstats", false, "Collect pod and container metrics from each node's kubelet")
+	cmd.Flags().BoolVar(&kubeInventory, "kube-inventory", false, "Collect cluster wide object metrics from a single replica Deployment")
+	naming.Register(cmd)
+	endpoint.Register(cmd)
+	cmd.MarkFlagRequired("api-key")
+
+	return cmd
+}
+
+func newManifest(agentName, namespace string, options map[string]interface{}) (kubernetes.Manifest, error) {
+	var manifest kubernetes.Manifest
+
+	if naming.New(options).HasHostname() {
+		return manifest, fmt.Errorf("the hostname is the node name in kubernetes, --hostname and --hostname-mode can't be used")
+	}
+
+	def, _ := agentmanager.Lookup(agentName)
+	// Nodes are always linux, whatever machine renders the manifests.
+	agent := def.New(options, sysinfo.SysInfo{Os: "linux", Arch: "amd64"})
+
+	kubernetesAgent, ok := agent.(agentmanager.KubernetesAgent)
+	if !ok {
+		return manifest, fmt.Errorf("%s can't be deployed to kubernetes", def.DisplayName)
+	}
+
+	node, cluster, err := kubernetesAgent.KubernetesWorkloads()
+	if err != nil {
+		return manifest, err
+	}
+
+	manifest = kubernetes.Manifest{
+		Namespace: namespace,
+		ApiKey:    options["apikey"].(string),
+		Node:      node,
+		Cluster:   cluster,
+	}
+	return manifest, nil
+}
@@ -4,23 +4,65 @@ import (
 	"fmt"
 	"os"
 
+	_ "github.com/hostedgraphite/hg-cli/agentmanager/agents"
+	"github.com/hostedgraphite/hg-cli/auth"
 	"github.com/hostedgraphite/hg-cli/cmd/agent"
+	"github.com/hostedgraphite/hg-cli/cmd/apply"
+	authcmd "github.com/hostedgraphite/hg-cli/cmd/auth"
+	"github.com/hostedgraphite/hg-cli/cmd/doctor"
+	"github.com/hostedgraphite/hg-cli/cmd/export"
+	"github.com/hostedgraphite/hg-cli/cmd/k8s"
+	"github.com/hostedgraphite/hg-cli/profile"
 	"github.com/hostedgraphite/hg-cli/styles"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
+	cliUtils "github.com/hostedgraphite/hg-cli/utils"
 
 	"github.com/spf13/cobra"
 )
 
+var profileName, apiKeyFile string
+
 var rootCmd = &cobra.Command{
 	Use:           "hg-cli",
 	Short:         "CLI to interact with Hosted Graphite",
 	Long:          "CLI to interact with Hosted Graphite",
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := useProfile(cmd); err != nil {
+			return err
+		}
+		return auth.ApplyFlag(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
 }
 
+// useProfile loads the selected config file profile and fills in the flags
+// that weren't passed from it.
+func useProfile(cmd *cobra.Command) error {
+	name := profileName
+	if name == "" {
+		name = os.Getenv(profile.EnvVar)
+	}
+
+	path, err := profile.Path()
+	if err != nil {
+		return err
+	}
+	config, err := profile.Load(path)
+	if err != nil {
+		return err
+	}
+	name, p, err := config.Select(name)
+	if err != nil {
+		return err
+	}
+
+	profile.Use(name, p)
+	return p.ApplyFlags(cmd)
+}
+
 // Add top level commands here
 func init() {
 	sysinfo, err := sysinfo.GetSystemInformation()
@@ -30,7 +72,17 @@ func init() {
 	}
 	rootCmd.AddCommand(TuiEnableCmd(sysinfo))
 	rootCmd.AddCommand(agent.AgentCmd(sysinfo))
+	rootCmd.AddCommand(apply.ApplyCmd(sysinfo))
+	rootCmd.AddCommand(k8s.K8sCmd())
+	rootCmd.AddCommand(export.ExportCmd())
+	rootCmd.AddCommand(doctor.DoctorCmd(sysinfo))
+	rootCmd.AddCommand(authcmd.AuthCmd())
+	rootCmd.PersistentFlags().StringVar(&apiKeyFile, "api-key-file", "", "Read the api key from this file, or stdin with -, instead of passing --api-key")
+	rootCmd.PersistentFlags().BoolVar(&cliUtils.SkipValidation, "skip-validate", false, "Only check the api key's format, without asking the Hosted Graphite api, for hosts that can't reach it")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Config file profile to use, defaults to $"+profile.EnvVar+" or the config's default")
 	rootCmd.SetUsageFunc(styles.CustomUsageFunc)
+	// Run the profile hook before the subcommands' own.
+	cobra.EnableTraverseRunHooks = true
 }
 
 func Execute() {
@@ -38,7 +90,9 @@ func Execute() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	if err := rootCmd.Execute(); err != nil {
-		content := err.Error()
-		fmt.Println(s.Error.Render(content))
+		content := cliUtils.Mask(err.Error())
+		// Keep errors out of output redirected to a script or manifest.
+		fmt.Fprintln(os.Stderr, s.Error.Render(content))
+		os.Exit(1)
 	}
 }
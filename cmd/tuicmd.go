@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/hostedgraphite/hg-cli/auth"
+	"github.com/hostedgraphite/hg-cli/profile"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 	"github.com/hostedgraphite/hg-cli/tui"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
 
 	"github.com/spf13/cobra"
 )
@@ -17,7 +20,12 @@ func TuiEnableCmd(sysinfo sysinfo.SysInfo) *cobra.Command {
 		Short: "Enable tui",
 		Long:  "Enable tui",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := tui.StartTui(sysinfo); err != nil {
+			target := sysinfo
+			target.Mirror = profile.Current().Mirror
+			if key, err := auth.Lookup(apiKeyFile); err == nil {
+				forms.DefaultApiKey = key.Value
+			}
+			if err := tui.StartTui(target); err != nil {
 				fmt.Println("Error launching TUI:", err)
 				os.Exit(1)
 			}
@@ -8,14 +8,14 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hostedgraphite/hg-cli/styles"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"github.com/hostedgraphite/hg-cli/utils"
 )
 
-var otelSummaryTemplate = `
+var summaryTemplate = `
 {{if eq .Action "Install"}}
 	{{.SuccessMessage}}
 	{{.Config}}
+	{{.Endpoint}}
 	{{.StartCmd}}
 {{else if eq .Action "Update Api Key"}}
 	{{.SuccessMessage}}
@@ -29,6 +29,7 @@ var telegrafSummaryTemplate = `
 	{{.SuccessMessage}}
 	{{.Plugins}}
 	{{.Config}}
+	{{.Endpoint}}
 	{{.StartCmd}}
 {{else if eq .Action "Update Api Key"}}
 	{{.SuccessMessage}}
@@ -38,7 +39,6 @@ var telegrafSummaryTemplate = `
 `
 
 var (
-	titleCaser    = cases.Title(language.English)
 	labelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#20b9f7")).Bold(true)
 	restartLabel  = labelStyle.Render("Restart Command   : ")
 	startLabel    = labelStyle.Render("Start Command     : ")
@@ -46,6 +46,7 @@ var (
 	pluginsLabel  = labelStyle.Render("Plugins Installed : ")
 	receiverLabel = labelStyle.Render("Receiver          : ")
 	exporterLabel = labelStyle.Render("Exporter          : ")
+	endpointLabel = labelStyle.Render("Endpoint          : ")
 )
 
 var defaultCallToAction = `
@@ -66,6 +67,7 @@ type ActionSummary struct {
 	Config     string
 	StartCmd   string
 	RestartCmd string
+	Endpoint   string
 	Error      string
 }
 
@@ -84,41 +86,48 @@ type SummaryContent interface {
 	GenerateContent() map[string]string
 }
 
-func (o *OtelContribSummary) GenerateContent() map[string]string {
+// GenerateContent covers the fields shared by every agent, agent summaries
+// add their own details on top.
+func (a *ActionSummary) GenerateContent() map[string]string {
 	data := make(map[string]string)
 
-	switch o.ActionSummary.Action {
-	case "Install":
-		data["StartCmd"] = o.ActionSummary.StartCmd
-		data["Config"] = o.ActionSummary.Config
+	switch a.Action {
+	case "Install", "Configure":
+		data["StartCmd"] = a.StartCmd
+		data["RestartCmd"] = a.RestartCmd
+		data["Config"] = a.Config
+		data["Endpoint"] = a.Endpoint
+	case "Update Api Key":
+		data["RestartCmd"] = a.RestartCmd
+		data["Config"] = a.Config
+	}
+	data["Action"] = a.Action
+	data["Agent"] = a.Agent
+	data["SuccessMessage"] = a.Action
+
+	return data
+}
+
+func (o *OtelContribSummary) GenerateContent() map[string]string {
+	data := o.ActionSummary.GenerateContent()
+
+	switch o.Action {
+	case "Install", "Configure":
 		data["Receiver"] = o.Receiver
 		data["Exporter"] = o.Exporter
-	case "Update Api Key":
-		data["RestartCmd"] = o.ActionSummary.RestartCmd
-		data["Config"] = o.ActionSummary.Config
 	}
-	data["Action"] = o.Action
-	data["Agent"] = o.Agent
-	data["SuccessMessage"] = o.Action
 
 	return data
 
 }
 
 func (t *TelegrafSummary) GenerateContent() map[string]string {
-	data := make(map[string]string)
+	data := t.ActionSummary.GenerateContent()
+
 	switch t.Action {
-	case "Install":
-		data["StartCmd"] = t.StartCmd
-		data["Config"] = t.Config
+	case "Install", "Configure":
 		data["Plugins"] = strings.Join(t.Plugins, ", ")
-	case "Update Api Key":
-		data["RestartCmd"] = t.RestartCmd
-		data["Config"] = t.Config
 	}
-	data["Action"] = t.Action
-	data["Agent"] = t.Agent
-	data["SuccessMessage"] = t.Action
 
 	return data
 }
@@ -137,6 +146,8 @@ func formatField(key, value string, s styles.Summary) string {
 		return s.Base.Render(s.KeyWord.Render("Receiver: ") + s.Items.Render(value))
 	case "Exporter":
 		return s.Base.Render(s.KeyWord.Render("Exporter: ") + s.Items.Render(value))
+	case "Endpoint":
+		return s.Base.Render(s.KeyWord.Render("Endpoint: ") + s.Items.Render(value))
 	case "Error":
 		return s.Status.Render(fmt.Sprintf("Error: %s", value))
 	case "SuccessMessage":
@@ -150,25 +161,21 @@ func GenerateSummary(summary SummaryContent, width, height int) string {
 	var viewStr strings.Builder
 	var err error
 	var tmpl *template.Template
-	data := summary.GenerateContent()
+	data := maskContent(summary.GenerateContent())
 	s := styles.SummaryStyles(true)
-	agent := data["Agent"]
 
 	for key, value := range data {
 		data[key] = formatField(key, value, s)
 	}
 
-	switch agent {
-	case "Telegraf":
+	switch summary.(type) {
+	case *TelegrafSummary:
 		tmpl, err = template.New("telegraf").Parse(telegrafSummaryTemplate)
-		if err != nil {
-			return fmt.Sprintf("Error parsing template: %v", err)
-		}
-	case "OpenTelemetry":
-		tmpl, err = template.New("otel").Parse(otelSummaryTemplate)
-		if err != nil {
-			return fmt.Sprintf("Error parsing template: %v", err)
-		}
+	default:
+		tmpl, err = template.New("summary").Parse(summaryTemplate)
+	}
+	if err != nil {
+		return fmt.Sprintf("Error parsing template: %v", err)
 	}
 
 	var buf bytes.Buffer
@@ -187,10 +194,18 @@ func GenerateSummary(summary SummaryContent, width, height int) string {
 	return styles.PlaceContent(width, height, content)
 }
 
+// maskContent hides the api key should any summary field contain it.
+func maskContent(data map[string]string) map[string]string {
+	for key, value := range data {
+		data[key] = utils.Mask(value)
+	}
+	return data
+}
+
 func renderCallToAction(action string, s styles.Summary) string {
 	var ctoAction string
 	switch action {
-	case "Install", "Update Api Key":
+	case "Install", "Configure", "Update Api Key":
 		ctoAction = defaultCallToAction
 	case "Uninstall":
 		ctoAction = uninstallCallToAction
@@ -201,7 +216,7 @@ func renderCallToAction(action string, s styles.Summary) string {
 func GenerateCliSummary(summary SummaryContent) string {
 	var viewStr strings.Builder
 	var cmd, ctoAction, extrasOptions string
-	data := summary.GenerateContent()
+	data := maskContent(summary.GenerateContent())
 	agent := data["Agent"]
 	action := data["Action"]
 	restartCmd := data["RestartCmd"]
@@ -214,13 +229,20 @@ func GenerateCliSummary(summary SummaryContent) string {
 	case "Update Api Key":
 		cmd = fmt.Sprintf("%s %s\n", restartLabel, restartCmd)
 		ctoAction = defaultCallToAction
-	case "Install":
-		if agent == "telegraf" {
+	case "Install", "Configure":
+		if data["Plugins"] != "" {
 			extrasOptions = fmt.Sprintf("%s %s\n", pluginsLabel, data["Plugins"])
-		} else if agent == "otel" {
-			extrasOptions = fmt.Sprintf("%s %s\n%s %s\n", receiverLabel, data["Receiver"], exporterLabel, data["Exporter"])
+		}
+		if data["Receiver"] != "" {
+			extrasOptions += fmt.Sprintf("%s %s\n%s %s\n", receiverLabel, data["Receiver"], exporterLabel, data["Exporter"])
+		}
+		if data["Endpoint"] != "" {
+			extrasOptions += fmt.Sprintf("%s %s\n", endpointLabel, data["Endpoint"])
 		}
 		cmd = fmt.Sprintf("%s %s\n", startLabel, startCmd)
+		if action == "Configure" {
+			cmd = fmt.Sprintf("%s %s\n", restartLabel, restartCmd)
+		}
 		ctoAction = defaultCallToAction
 	case "Uninstall":
 		ctoAction = uninstallCallToAction
@@ -228,7 +250,7 @@ func GenerateCliSummary(summary SummaryContent) string {
 		return viewStr.String()
 	}
 
-	header := "\n" + titleCaser.String(agent) + " Service Details"
+	header := "\n" + agent + " Service Details"
 
 	viewStr.WriteString(pipelineTitle.Render(header))
 	viewStr.WriteString("\n" + cmd)
@@ -1,6 +1,6 @@
 module github.com/hostedgraphite/hg-cli
 
-go 1.23.4
+go 1.24.0
 
 require (
 	github.com/charmbracelet/bubbles v0.20.0
@@ -10,8 +10,9 @@ require (
 	github.com/charmbracelet/log v0.4.1
 	github.com/charmbracelet/x/term v0.2.1
 	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
 	github.com/stretchr/testify v1.10.0
-	golang.org/x/text v0.23.0
+	golang.org/x/sys v0.31.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -37,8 +38,7 @@ require (
 	github.com/muesli/termenv v0.16.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/exp v0.0.0-20231006140011-7918f672742d // indirect
 	golang.org/x/sync v0.12.0 // indirect
-	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hostedgraphite/hg-cli/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +49,28 @@ func TestPipelineRun(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func TestPipelineMasksSecrets(t *testing.T) {
+	utils.AddSecret("aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee")
+	running := make(chan *Pipe)
+
+	pipeline := Pipeline{
+		Pipes: []*Pipe{
+			{
+				Name: "Print the key",
+				Cmd:  exec.Command("echo", "prefix = aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee.telegraf"),
+			},
+		},
+		Running: running,
+	}
+
+	ctx := PipelineRunner(&pipeline)
+	for ctx.Err() == nil {
+		select {
+		case <-running:
+		case <-ctx.Done():
+		}
+	}
+
+	require.Equal(t, []string{"prefix = ****eeee.telegraf\n"}, pipeline.OutputLog)
+}
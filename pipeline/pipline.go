@@ -2,9 +2,12 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"time"
+
+	"github.com/hostedgraphite/hg-cli/utils"
 )
 
 type Pipe struct {
@@ -18,6 +21,7 @@ type Pipe struct {
 
 	ctx     context.Context
 	postRun func(context.Context) error
+	script  func(shell string) (string, error)
 }
 
 func (p *Pipe) execPostRun() error {
@@ -101,10 +105,11 @@ func (p *Pipeline) Run() error {
 		output, err = pipe.Run()
 		p.LastRun = pipe
 
-		p.OutputLog = append(p.OutputLog, output)
+		// The output and errors are shown to the user, keep the api key out
+		p.OutputLog = append(p.OutputLog, utils.Mask(output))
 		if err != nil {
 			p.failed = true
-			p.Err = err
+			p.Err = maskError(err)
 			break
 		}
 	}
@@ -114,6 +119,13 @@ func (p *Pipeline) Run() error {
 	return err
 }
 
+func maskError(err error) error {
+	if masked := utils.Mask(err.Error()); masked != err.Error() {
+		return errors.New(masked)
+	}
+	return err
+}
+
 func (p *Pipeline) IsCompleted() bool {
 	return p.completed
 }
@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	Bash       = "bash"
+	PowerShell = "powershell"
+)
+
+// Script sets the shell equivalent of the pipe, used in place of its Cmd and
+// PostRun when the pipeline is exported. Pipes with a PostRun need one, the
+// Go code run after the command can't be exported otherwise.
+func (p *Pipe) Script(script func(shell string) (string, error)) *Pipe {
+	p.script = script
+	return p
+}
+
+func (p *Pipe) scriptStep(shell string) (string, error) {
+	if p.script != nil {
+		return p.script(shell)
+	}
+	if p.postRun != nil {
+		return "", fmt.Errorf("step %q can't be exported as a script", p.Name)
+	}
+
+	args := p.Cmd.Args
+	if shell == PowerShell {
+		// Windows pipes already run powershell, keep just the command.
+		if len(args) == 3 && (args[0] == "powershell" || args[0] == "pwsh") && args[1] == "-Command" {
+			return args[2], nil
+		}
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = PowerShellQuote(arg)
+		}
+		return "& " + strings.Join(quoted, " "), nil
+	}
+
+	if len(args) == 3 && (args[0] == "bash" || args[0] == "sh") && args[1] == "-c" {
+		return args[2], nil
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+const bashHeader = `#!/usr/bin/env bash
+# %s
+# Generated by hg-cli, review before running as root.
+set -euo pipefail
+
+current_step=""
+trap 'echo "hg-cli: step failed: ${current_step}" >&2' ERR
+
+step() {
+  current_step="$1"
+  echo "==> $1"
+}
+`
+
+const powerShellHeader = `# %s
+# Generated by hg-cli, review before running as Administrator.
+$ErrorActionPreference = "Stop"
+
+function Step($name) {
+  $script:currentStep = $name
+  Write-Host "==> $name"
+}
+
+function Check-Exit {
+  if ($LASTEXITCODE -and $LASTEXITCODE -ne 0) {
+    throw "hg-cli: step failed: $script:currentStep (exit code $LASTEXITCODE)"
+  }
+}
+`
+
+// Export renders the pipeline as a standalone bash or PowerShell script
+// running the same steps, stopping at the first failure.
+func (p *Pipeline) Export(shell string) ([]byte, error) {
+	var b strings.Builder
+
+	switch shell {
+	case Bash:
+		fmt.Fprintf(&b, bashHeader, p.Name)
+	case PowerShell:
+		fmt.Fprintf(&b, powerShellHeader, p.Name)
+	default:
+		return nil, fmt.Errorf("unsupported shell %q (available: %s, %s)", shell, Bash, PowerShell)
+	}
+
+	for _, pipe := range p.Pipes {
+		step, err := pipe.scriptStep(shell)
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteString("\n")
+		if shell == PowerShell {
+			fmt.Fprintf(&b, "Step %s\n%s\nCheck-Exit\n", PowerShellQuote(pipe.Name), step)
+		} else {
+			fmt.Fprintf(&b, "step %s\n%s\n", ShellQuote(pipe.Name), step)
+		}
+	}
+
+	if shell == PowerShell {
+		b.WriteString("\nWrite-Host \"==> Done\"\n")
+	} else {
+		b.WriteString("\necho \"==> Done\"\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// ShellQuote quotes s for bash, leaving simple words as they are.
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// PowerShellQuote quotes s as a literal PowerShell string.
+func PowerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,231 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVar names the profile when --profile isn't passed.
+const EnvVar = "HG_PROFILE"
+
+var Outputs = []string{"text", "json"}
+
+// Config is the hg-cli config file, named profiles holding the defaults used
+// by the commands and pre-filled into the TUI, e.g. one per account.
+type Config struct {
+	// Default is the profile used when none is named.
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	ApiKey ApiKey `yaml:"apiKey"`
+	// Agent is used by the commands when no agent is given.
+	Agent          string   `yaml:"agent"`
+	Endpoint       Endpoint `yaml:"endpoint"`
+	Prefix         string   `yaml:"prefix"`
+	PrefixSegments []string `yaml:"prefixSegments"`
+	// Mirror serves the agent release archives, see sysinfo.DownloadURL.
+	Mirror string `yaml:"mirror"`
+	// Output is the report format, text or json.
+	Output string `yaml:"output"`
+}
+
+// ApiKey says where the api key comes from, exactly one source should be set
+// so the key itself doesn't have to live in the file.
+type ApiKey struct {
+	Value string `yaml:"value"`
+	Env   string `yaml:"env"`
+	File  string `yaml:"file"`
+}
+
+type Endpoint struct {
+	Mode               string `yaml:"mode"`
+	Address            string `yaml:"address"`
+	TLSCA              string `yaml:"tlsCA"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+var (
+	current     Profile
+	currentName string
+)
+
+// Current is the profile selected for this run, empty without a config file.
+func Current() Profile {
+	return current
+}
+
+// Name is the selected profile's name, "default" when none is selected.
+func Name() string {
+	if currentName == "" {
+		return "default"
+	}
+	return currentName
+}
+
+// Use makes p the profile for this run.
+func Use(name string, p Profile) {
+	current = p
+	currentName = name
+}
+
+// Dir is ~/.config/hg-cli, or under $XDG_CONFIG_HOME when set.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "hg-cli"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding the home directory: %v", err)
+	}
+	return filepath.Join(home, ".config", "hg-cli"), nil
+}
+
+// Path is the config file in Dir.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// Load reads the config file, a missing file is an empty config.
+func Load(path string) (Config, error) {
+	var config Config
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("error reading config: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Select returns the named profile, or the default one when name is empty,
+// along with its name. Without either the profile is empty and the commands
+// keep their own defaults.
+func (c Config) Select(name string) (string, Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return "", Profile{}, nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return name, p, fmt.Errorf("profile %q not found, the config has: %s", name, strings.Join(c.Names(), ", "))
+	}
+	if p.Output != "" && !slices.Contains(Outputs, p.Output) {
+		return name, p, fmt.Errorf("profile %q: output must be one of %s", name, strings.Join(Outputs, ", "))
+	}
+
+	return name, p, nil
+}
+
+func (c Config) Names() []string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve reads the api key from whichever source is set.
+func (k ApiKey) Resolve() (string, error) {
+	var apikey string
+
+	switch {
+	case k.Value != "":
+		apikey = k.Value
+	case k.Env != "":
+		apikey = os.Getenv(k.Env)
+		if apikey == "" {
+			return "", fmt.Errorf("api key environment variable %s is not set", k.Env)
+		}
+	case k.File != "":
+		content, err := os.ReadFile(k.File)
+		if err != nil {
+			return "", fmt.Errorf("error reading api key file: %v", err)
+		}
+		apikey = strings.TrimSpace(string(content))
+	}
+
+	if apikey == "" {
+		return "", fmt.Errorf("api key is empty")
+	}
+
+	return apikey, nil
+}
+
+func (k ApiKey) IsSet() bool {
+	return k.Value != "" || k.Env != "" || k.File != ""
+}
+
+// AgentArgs adds the profile's agent to the arguments of commands taking an
+// <agent> when none was given.
+func AgentArgs(args []string) []string {
+	if len(args) == 0 && current.Agent != "" {
+		return []string{current.Agent}
+	}
+	return args
+}
+
+// flagValues maps the command line flags onto the profile's values.
+func (p Profile) flagValues() map[string]string {
+	values := map[string]string{
+		"endpoint":         p.Endpoint.Mode,
+		"endpoint-address": p.Endpoint.Address,
+		"tls-ca":           p.Endpoint.TLSCA,
+		"prefix":           p.Prefix,
+		"prefix-segments":  strings.Join(p.PrefixSegments, ","),
+		"mirror":           p.Mirror,
+	}
+	if p.Endpoint.InsecureSkipVerify {
+		values["tls-insecure-skip-verify"] = strconv.FormatBool(true)
+	}
+	if p.Output == "json" {
+		values["json"] = strconv.FormatBool(true)
+	}
+	return values
+}
+
+// ApplyFlags sets the flags of cmd that weren't passed to the profile's
+// values, so the profile only fills in defaults. The api key is looked up
+// by the auth package along with its other sources.
+func (p Profile) ApplyFlags(cmd *cobra.Command) error {
+	for name, value := range p.flagValues() {
+		flag := cmd.Flags().Lookup(name)
+		if value == "" || flag == nil || flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("profile %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+const config = `
+default: prod
+profiles:
+  prod:
+    apiKey:
+      env: PROD_API_KEY
+    agent: telegraf
+    prefixSegments: [prod, eu]
+  staging:
+    apiKey:
+      value: staging-key
+    endpoint:
+      mode: tls
+    output: json
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestSelect(t *testing.T) {
+	config, err := Load(writeConfig(t, config))
+	require.NoError(t, err)
+
+	name, p, err := config.Select("")
+	require.NoError(t, err)
+	require.Equal(t, "prod", name)
+	require.Equal(t, "telegraf", p.Agent)
+
+	_, p, err = config.Select("staging")
+	require.NoError(t, err)
+	require.Equal(t, "tls", p.Endpoint.Mode)
+
+	_, _, err = config.Select("sandbox")
+	require.EqualError(t, err, `profile "sandbox" not found, the config has: prod, staging`)
+}
+
+func TestLoadMissing(t *testing.T) {
+	config, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+
+	_, p, err := config.Select("")
+	require.NoError(t, err)
+	require.Equal(t, Profile{}, p)
+}
+
+func TestApplyFlags(t *testing.T) {
+	var prefix string
+	var segments []string
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&prefix, "prefix", "", "")
+	cmd.Flags().StringSliceVar(&segments, "prefix-segments", []string{}, "")
+	require.NoError(t, cmd.ParseFlags([]string{"--prefix", "web"}))
+
+	p := Profile{Prefix: "app", PrefixSegments: []string{"prod", "eu"}}
+	require.NoError(t, p.ApplyFlags(cmd))
+	require.Equal(t, "web", prefix)
+	require.Equal(t, []string{"prod", "eu"}, segments)
+}
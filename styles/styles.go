@@ -227,7 +227,6 @@ func AgentsPageStyle(agent string) *huh.Theme {
 
 func CustomKeyMap() *huh.KeyMap {
 	km := huh.NewDefaultKeyMap()
-	km.MultiSelect.Filter.Unbind()
 	km.Select.Filter.Unbind()
 	km.MultiSelect.Toggle.SetHelp("x", "select")
 
@@ -274,6 +273,39 @@ func CustomUsageFunc(cmd *cobra.Command) error {
 	if cmd.HasAvailableSubCommands() {
 		usage += "\n\nUse \"" + cmd.CommandPath() + " [command] --help\" for more information about a command."
 	}
-	fmt.Println(usage)
+	// Usage shown for an error goes to stderr, help writes it to stdout.
+	fmt.Fprintln(cmd.OutOrStderr(), usage)
 	return nil
 }
+
+type Doctor struct {
+	Pass,
+	Warn,
+	Fail,
+	Name,
+	Detail lipgloss.Style
+}
+
+func DoctorStyles() Doctor {
+	var s Doctor
+
+	s.Pass = lipgloss.NewStyle().
+		Foreground(green).
+		Bold(true)
+
+	s.Warn = lipgloss.NewStyle().
+		Foreground(orange).
+		Bold(true)
+
+	s.Fail = lipgloss.NewStyle().
+		Foreground(red).
+		Bold(true)
+
+	s.Name = lipgloss.NewStyle().
+		Foreground(lightText)
+
+	s.Detail = lipgloss.NewStyle().
+		Foreground(paragraph)
+
+	return s
+}
@@ -0,0 +1,74 @@
+package sysinfo
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	ContainerDocker     = "docker"
+	ContainerPodman     = "podman"
+	ContainerLXC        = "lxc"
+	ContainerKubernetes = "kubernetes"
+)
+
+const (
+	VirtualizationWSL   = "wsl"
+	VirtualizationAWS   = "aws"
+	VirtualizationGCP   = "gcp"
+	VirtualizationAzure = "azure"
+)
+
+var (
+	readFile = os.ReadFile
+	getenv   = os.Getenv
+)
+
+func fileContains(path, substr string) bool {
+	content, err := readFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(content)), substr)
+}
+
+// detectContainer works out which runtime hg-cli is running under. A pod is
+// reported as kubernetes whatever runtime the node uses.
+func detectContainer() string {
+	switch {
+	case getenv("KUBERNETES_SERVICE_HOST") != "" || pathExists("/var/run/secrets/kubernetes.io/serviceaccount"):
+		return ContainerKubernetes
+	case pathExists("/run/.containerenv"):
+		return ContainerPodman
+	case pathExists("/.dockerenv") || fileContains("/proc/1/cgroup", "docker"):
+		return ContainerDocker
+	case getenv("container") == "lxc" || fileContains("/proc/1/environ", "container=lxc") || pathExists("/dev/lxd"):
+		return ContainerLXC
+	}
+	return ""
+}
+
+// Azure VMs share the Hyper-V DMI vendor, this asset tag tells them apart.
+const azureAssetTag = "7783-7084-3265-9085-8269-3286-77"
+
+// detectVirtualization spots WSL and the AWS, GCP and Azure VMs from the DMI
+// tables, without calling out to any metadata service.
+func detectVirtualization() string {
+	switch {
+	case getenv("WSL_DISTRO_NAME") != "" || fileContains("/proc/sys/kernel/osrelease", "microsoft"):
+		return VirtualizationWSL
+	case fileContains("/sys/class/dmi/id/sys_vendor", "amazon") || fileContains("/sys/class/dmi/id/bios_version", "amazon"):
+		return VirtualizationAWS
+	case fileContains("/sys/class/dmi/id/product_name", "google compute engine"):
+		return VirtualizationGCP
+	case fileContains("/sys/class/dmi/id/chassis_asset_tag", azureAssetTag):
+		return VirtualizationAzure
+	}
+	return ""
+}
+
+// Containerized reports whether hg-cli runs inside a container, where host
+// metrics agents only see the container.
+func (s SysInfo) Containerized() bool {
+	return s.Container != ""
+}
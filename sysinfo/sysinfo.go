@@ -1,8 +1,10 @@
 package sysinfo
 
 import (
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -10,17 +12,83 @@ import (
 )
 
 type SysInfo struct {
-	Os       string
-	Arch     string
-	PkgMngr  string
-	Distro   string
+	Os      string
+	Arch    string
+	PkgMngr string
+	Distro  string
+	// DistroVersion is the os-release VERSION_ID, e.g. 22.04 or 9.3.
+	DistroVersion string
+	// InitSystem manages services on linux: systemd, openrc, sysv, runit or
+	// none, e.g. in a container.
+	InitSystem string
+	// Container is the runtime hg-cli runs under: docker, podman, lxc or
+	// kubernetes, empty on a host.
+	Container string
+	// Virtualization is wsl or the cloud the VM runs in: aws, gcp or azure.
+	Virtualization string
+	// Root stages installs under a directory, e.g. an image build directory,
+	// instead of the live system. Empty installs onto this host.
+	Root string
+	// Mirror serves the agent release archives in place of their upstream
+	// hosts, see DownloadURL.
+	Mirror   string
 	SudoPerm bool
 	Width    int
 	Height   int
 }
 
+const (
+	InitSystemd = "systemd"
+	InitOpenRC  = "openrc"
+	InitSysV    = "sysv"
+	InitRunit   = "runit"
+	InitNone    = "none"
+)
+
+// Path returns where an absolute path of the target system lives on this
+// host, under the install root when there is one.
+func (s SysInfo) Path(path string) string {
+	if s.Root == "" {
+		return path
+	}
+	return filepath.Join(s.Root, path)
+}
+
+// DownloadURL returns where to download a release archive from, the mirror
+// keeps the upstream host and path: <mirror>/<host>/<path>.
+func (s SysInfo) DownloadURL(upstream string) string {
+	if s.Mirror == "" {
+		return upstream
+	}
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return upstream
+	}
+	return strings.TrimSuffix(s.Mirror, "/") + "/" + u.Host + u.Path
+}
+
+// Staged describes an install staged under root rather than onto this host.
+// Nothing about the host's distro applies to the target: agents install from
+// their release archives and get systemd units.
+func (s SysInfo) Staged(root string) SysInfo {
+	return SysInfo{
+		Os:       s.Os,
+		Arch:     s.Arch,
+		Root:     root,
+		Mirror:   s.Mirror,
+		SudoPerm: s.SudoPerm,
+		Width:    s.Width,
+		Height:   s.Height,
+	}
+}
+
 var execCommand = exec.Command
 
+var pathExists = func(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func checkSudoPerm() bool {
 	return os.Getegid() == 0
 }
@@ -49,32 +117,82 @@ func getOSRelease() (string, error) {
 	return string(output), err
 }
 
+// osReleaseField returns a field of /etc/os-release with its quotes removed.
+func osReleaseField(releaseInfo, field string) string {
+	for _, line := range strings.Split(releaseInfo, "\n") {
+		if value, found := strings.CutPrefix(line, field+"="); found {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
+
+// distroMap maps an os-release ID, or one of the IDs in ID_LIKE, to its
+// package manager.
+var distroMap = map[string]string{
+	"ubuntu":              "apt",
+	"debian":              "apt",
+	"redhat":              "yum",
+	"centos":              "yum",
+	"rhel":                "yum",
+	"fedora":              "dnf",
+	"suse":                "zypper",
+	"opensuse":            "zypper",
+	"opensuse-leap":       "zypper",
+	"opensuse-tumbleweed": "zypper",
+	"sles":                "zypper",
+	"alpine":              "apk",
+	"arch":                "pacman",
+}
+
 func checkDistroPkgMngr(releaseInfo string) (string, string) {
-	var distribution string
-	lines := strings.Split(releaseInfo, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "ID=") {
-			distribution = strings.TrimPrefix(line, "ID=")
-			distribution = strings.Trim(distribution, `"`) // Remove quotes if present
-			break
+	distribution := osReleaseField(releaseInfo, "ID")
+
+	// Amazon Linux 2 is yum based, 2023 moved to dnf.
+	if distribution == "amzn" {
+		if osReleaseField(releaseInfo, "VERSION_ID") == "2" {
+			return distribution, "yum"
 		}
+		return distribution, "dnf"
 	}
 
-	var packageManager string
-	var distroMap = map[string]string{
-		"ubuntu": "apt",
-		"debian": "apt",
-		"redhat": "yum",
-		"centos": "yum",
-		"rhel":   "yum",
-		"fedora": "dnf",
+	if packageManager, ok := distroMap[distribution]; ok {
+		return distribution, packageManager
+	}
+
+	// Derivatives such as Rocky, Alma, Mint or Pop!_OS name their parents in
+	// ID_LIKE, closest first.
+	for _, like := range strings.Fields(osReleaseField(releaseInfo, "ID_LIKE")) {
+		if packageManager, ok := distroMap[like]; ok {
+			return distribution, packageManager
+		}
+	}
+
+	return distribution, ""
+}
+
+// detectInitSystem checks for the runtime directories each init system
+// creates, so an installed but unused init isn't picked up.
+func detectInitSystem(container string) string {
+	switch {
+	case pathExists("/run/systemd/system"):
+		return InitSystemd
+	case pathExists("/run/openrc"):
+		return InitOpenRC
+	case pathExists("/run/runit"):
+		return InitRunit
+	case container != "":
+		// Containers run the app as pid 1, whatever init scripts the image
+		// has. LXC system containers boot a real init, caught above.
+		return InitNone
+	case pathExists("/etc/init.d"):
+		return InitSysV
 	}
-	packageManager = distroMap[distribution]
-	return distribution, packageManager
+	return InitNone
 }
 
 func GetSystemInformation() (SysInfo, error) {
-	var distro, pkgmngr string
+	var distro, distroVersion, pkgmngr, initSystem, container, virtualization string
 	var sudoPerm bool
 
 	goOs := runtime.GOOS
@@ -89,8 +207,12 @@ func GetSystemInformation() (SysInfo, error) {
 		releaseInfo, err := getOSRelease()
 		if err == nil {
 			distro, pkgmngr = checkDistroPkgMngr(releaseInfo)
+			distroVersion = osReleaseField(releaseInfo, "VERSION_ID")
 			sudoPerm = checkSudoPerm()
 		}
+		container = detectContainer()
+		virtualization = detectVirtualization()
+		initSystem = detectInitSystem(container)
 	case "windows":
 		sudoPerm = checkSudoPermWindows()
 	}
@@ -108,13 +230,17 @@ func GetSystemInformation() (SysInfo, error) {
 	initialHeight, initialWidth := GetInitialDimensions()
 
 	system := SysInfo{
-		Os:       strings.ToLower(goOs),
-		Arch:     strings.ToLower(goArch),
-		PkgMngr:  strings.ToLower(pkgmngr),
-		Distro:   strings.ToLower(distro),
-		SudoPerm: sudoPerm,
-		Width:    initialWidth,
-		Height:   initialHeight,
+		Os:             strings.ToLower(goOs),
+		Arch:           strings.ToLower(goArch),
+		PkgMngr:        strings.ToLower(pkgmngr),
+		Distro:         strings.ToLower(distro),
+		DistroVersion:  distroVersion,
+		InitSystem:     initSystem,
+		Container:      container,
+		Virtualization: virtualization,
+		SudoPerm:       sudoPerm,
+		Width:          initialWidth,
+		Height:         initialHeight,
 	}
 
 	return system, nil
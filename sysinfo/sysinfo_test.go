@@ -1,7 +1,9 @@
 package sysinfo
 
 import (
+	"os"
 	"os/exec"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -83,6 +85,37 @@ func TestRhelDistroPkg(t *testing.T) {
 	require.Equal(t, expectedPkgMngr, pkgMngr)
 }
 
+func TestDistroPkgFamilies(t *testing.T) {
+	tests := []struct {
+		name    string
+		release string
+		distro  string
+		pkgMngr string
+		version string
+	}{
+		{"Rocky", "ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\nVERSION_ID=\"9.3\"\n", "rocky", "yum", "9.3"},
+		{"Alma", "ID=\"almalinux\"\nID_LIKE=\"rhel centos fedora\"\nVERSION_ID=\"9.4\"\n", "almalinux", "yum", "9.4"},
+		{"Amazon Linux 2", "ID=\"amzn\"\nID_LIKE=\"centos rhel fedora\"\nVERSION_ID=\"2\"\n", "amzn", "yum", "2"},
+		{"Amazon Linux 2023", "ID=\"amzn\"\nID_LIKE=\"fedora\"\nVERSION_ID=\"2023\"\n", "amzn", "dnf", "2023"},
+		{"Mint", "ID=linuxmint\nID_LIKE=\"ubuntu debian\"\nVERSION_ID=\"21.3\"\n", "linuxmint", "apt", "21.3"},
+		{"Pop", "ID=pop\nID_LIKE=\"ubuntu debian\"\nVERSION_ID=\"22.04\"\n", "pop", "apt", "22.04"},
+		{"openSUSE Leap", "ID=\"opensuse-leap\"\nID_LIKE=\"suse opensuse\"\nVERSION_ID=\"15.5\"\n", "opensuse-leap", "zypper", "15.5"},
+		{"Alpine", "ID=alpine\nVERSION_ID=3.19.1\n", "alpine", "apk", "3.19.1"},
+		{"Arch", "ID=arch\nBUILD_ID=rolling\n", "arch", "pacman", ""},
+		{"Manjaro", "ID=manjaro\nID_LIKE=arch\n", "manjaro", "pacman", ""},
+		{"Unknown", "ID=plan9\nID_LIKE=bell\n", "plan9", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distro, pkgMngr := checkDistroPkgMngr(test.release)
+			require.Equal(t, test.distro, distro)
+			require.Equal(t, test.pkgMngr, pkgMngr)
+			require.Equal(t, test.version, osReleaseField(test.release, "VERSION_ID"))
+		})
+	}
+}
+
 func TestBadDistroPkg(t *testing.T) {
 	distro, pkgMngr := checkDistroPkgMngr("")
 	require.Equal(t, "", distro)
@@ -121,3 +154,99 @@ func TestCheckBrewOnLinux(t *testing.T) {
 	}
 	require.True(t, checkHgCliBrewInstall())
 }
+
+func TestDetectInitSystem(t *testing.T) {
+	originalPathExists := pathExists
+	defer func() { pathExists = originalPathExists }()
+
+	tests := []struct {
+		paths     []string
+		container string
+		want      string
+	}{
+		{[]string{"/run/systemd/system", "/etc/init.d"}, "", InitSystemd},
+		{[]string{"/run/openrc", "/etc/init.d"}, "", InitOpenRC},
+		{[]string{"/run/runit", "/etc/init.d"}, "", InitRunit},
+		{[]string{"/etc/init.d"}, ContainerDocker, InitNone},
+		{[]string{"/run/systemd/system"}, ContainerLXC, InitSystemd},
+		{[]string{"/etc/init.d"}, "", InitSysV},
+		{nil, "", InitNone},
+	}
+
+	for _, test := range tests {
+		pathExists = func(path string) bool {
+			return slices.Contains(test.paths, path)
+		}
+		require.Equal(t, test.want, detectInitSystem(test.container), "paths %v", test.paths)
+	}
+}
+
+// fakePlatform stubs the files and environment platform detection reads.
+func fakePlatform(t *testing.T, files, env map[string]string) {
+	originalPathExists, originalReadFile, originalGetenv := pathExists, readFile, getenv
+	t.Cleanup(func() { pathExists, readFile, getenv = originalPathExists, originalReadFile, originalGetenv })
+
+	pathExists = func(path string) bool {
+		_, ok := files[path]
+		return ok
+	}
+	readFile = func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+	getenv = func(key string) string {
+		return env[key]
+	}
+}
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		env   map[string]string
+		want  string
+	}{
+		{map[string]string{"/.dockerenv": ""}, nil, ContainerDocker},
+		{map[string]string{"/proc/1/cgroup": "0::/system.slice/docker-3f2a.scope"}, nil, ContainerDocker},
+		{map[string]string{"/run/.containerenv": ""}, nil, ContainerPodman},
+		{map[string]string{"/.dockerenv": ""}, map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, ContainerKubernetes},
+		{map[string]string{"/proc/1/environ": "container=lxc\x00TERM=linux"}, nil, ContainerLXC},
+		{map[string]string{"/proc/1/cgroup": "0::/init.scope"}, nil, ""},
+	}
+
+	for _, test := range tests {
+		fakePlatform(t, test.files, test.env)
+		require.Equal(t, test.want, detectContainer(), "files %v", test.files)
+	}
+}
+
+func TestDetectVirtualization(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		env   map[string]string
+		want  string
+	}{
+		{map[string]string{"/proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n"}, nil, VirtualizationWSL},
+		{map[string]string{"/sys/class/dmi/id/sys_vendor": "Amazon EC2\n"}, nil, VirtualizationAWS},
+		{map[string]string{"/sys/class/dmi/id/bios_version": "4.11.amazon\n"}, nil, VirtualizationAWS},
+		{map[string]string{"/sys/class/dmi/id/product_name": "Google Compute Engine\n"}, nil, VirtualizationGCP},
+		{map[string]string{"/sys/class/dmi/id/sys_vendor": "Microsoft Corporation\n", "/sys/class/dmi/id/chassis_asset_tag": "7783-7084-3265-9085-8269-3286-77\n"}, nil, VirtualizationAzure},
+		{map[string]string{"/sys/class/dmi/id/sys_vendor": "Microsoft Corporation\n"}, nil, ""},
+		{map[string]string{"/sys/class/dmi/id/sys_vendor": "QEMU\n"}, nil, ""},
+	}
+
+	for _, test := range tests {
+		fakePlatform(t, test.files, test.env)
+		require.Equal(t, test.want, detectVirtualization(), "files %v", test.files)
+	}
+}
+
+func TestDownloadURL(t *testing.T) {
+	url := "https://dl.influxdata.com/telegraf/releases/telegraf-1.33.1_linux_amd64.tar.gz"
+	require.Equal(t, url, SysInfo{}.DownloadURL(url))
+	require.Equal(t,
+		"https://mirror.internal/releases/dl.influxdata.com/telegraf/releases/telegraf-1.33.1_linux_amd64.tar.gz",
+		SysInfo{Mirror: "https://mirror.internal/releases/"}.DownloadURL(url))
+}
@@ -0,0 +1,201 @@
+// Package forms has the form fields shared by the agents' TUI views.
+package forms
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
+	"github.com/hostedgraphite/hg-cli/agentmanager/naming"
+	"github.com/hostedgraphite/hg-cli/profile"
+)
+
+// DefaultApiKey pre-fills the api key inputs, e.g. with the key stored by
+// `hg-cli auth login`.
+var DefaultApiKey string
+
+// Output holds the answers of the output fields.
+type Output struct {
+	prefix       string
+	segments     string
+	hostnameMode string
+	hostname     string
+	template     string
+	tagSupport   bool
+	endpoint     string
+	address      string
+	tlsCA        string
+}
+
+// ProfileOutput pre-fills the prefix and endpoint from the config file
+// profile.
+func ProfileOutput() Output {
+	p := profile.Current()
+	return Output{
+		prefix:   p.Prefix,
+		segments: strings.Join(p.PrefixSegments, ","),
+		endpoint: p.Endpoint.Mode,
+		address:  p.Endpoint.Address,
+		tlsCA:    p.Endpoint.TLSCA,
+	}
+}
+
+// OutputGroup holds the fields controlling how metrics are named and where
+// they're sent. modes are the carbon endpoints the agent supports, the
+// graphite specific fields are only shown for telegraf.
+func OutputGroup(n *Output, graphiteOptions bool, modes ...string) *huh.Group {
+	fields := NamingFields(n)
+	// A profile's endpoint the agent can't use is an error, not plaintext.
+	profileMode := n.endpoint
+
+	if graphiteOptions {
+		fields = append(fields,
+			huh.NewInput().
+				Key("template").
+				Title("Graphite Template").
+				Description("Optional, e.g. host.tags.measurement.field").
+				Prompt("Template: ").
+				Value(&n.template),
+
+			huh.NewConfirm().
+				Key("tagSupport").
+				Title("Enable Graphite Tag Support?").
+				Value(&n.tagSupport),
+		)
+	}
+
+	if len(modes) > 1 {
+		labels := map[string]string{
+			endpoint.Plaintext: "Plaintext (tcp 2003)",
+			endpoint.TLS:       "TLS (tcp 20030)",
+			endpoint.UDP:       "UDP (udp 2003)",
+		}
+		var options []huh.Option[string]
+		for _, mode := range modes {
+			options = append(options, huh.NewOption(labels[mode], mode))
+		}
+		fields = append(fields,
+			huh.NewSelect[string]().
+				Key("endpoint").
+				Title("Carbon Endpoint").
+				Options(options...).
+				Value(&n.endpoint),
+		)
+	} else {
+		fields = append(fields,
+			huh.NewNote().
+				Title("Carbon Endpoint").
+				Description("Plaintext (tcp 2003), this agent doesn't support the TLS or UDP endpoints"),
+		)
+	}
+
+	fields = append(fields,
+		huh.NewInput().
+			Key("endpointAddress").
+			Title("Custom Endpoint Address").
+			Description("Optional, host:port of a private carbon endpoint or relay").
+			Prompt("Address: ").
+			Value(&n.address).
+			Validate(func(s string) error {
+				if profileMode != "" && !slices.Contains(modes, profileMode) {
+					return fmt.Errorf("the profile selects the %s endpoint, which this agent doesn't support", profileMode)
+				}
+				return endpoint.Endpoint{Address: s}.Validate()
+			}),
+	)
+
+	if slices.Contains(modes, endpoint.TLS) {
+		fields = append(fields,
+			huh.NewInput().
+				Key("tlsCA").
+				Title("TLS CA Bundle").
+				Description("Optional, only used with the TLS endpoint").
+				Prompt("Path: ").
+				Value(&n.tlsCA),
+		)
+	}
+
+	return huh.NewGroup(fields...)
+}
+
+// NamingFields are the prefix and hostname fields, shared by every agent.
+func NamingFields(n *Output) []huh.Field {
+	return []huh.Field{
+		huh.NewInput().
+			Key("segments").
+			Title("Metric Prefix Segments").
+			Description("Optional, comma separated segments added after your api key e.g. prod,core,eu-west-1").
+			Prompt("Segments: ").
+			Value(&n.segments).
+			Validate(func(s string) error {
+				return naming.Naming{Segments: SplitList(s)}.Validate()
+			}),
+
+		huh.NewInput().
+			Key("prefix").
+			Title("Metric Prefix").
+			Description("Optional, replaces the agent name at the end of the prefix").
+			Prompt("Prefix: ").
+			Value(&n.prefix).
+			Validate(func(s string) error {
+				return naming.Naming{Prefix: s}.Validate()
+			}),
+
+		huh.NewSelect[string]().
+			Key("hostnameMode").
+			Title("Hostname").
+			Options(
+				huh.NewOption("System default", ""),
+				huh.NewOption("Short hostname", "short"),
+				huh.NewOption("FQDN", "fqdn"),
+				huh.NewOption("Cloud instance ID", "cloud"),
+				huh.NewOption("Custom", "custom"),
+			).
+			Value(&n.hostnameMode),
+
+		huh.NewInput().
+			Key("hostname").
+			TitleFunc(func() string {
+				if n.hostnameMode == "custom" {
+					return "Custom Hostname"
+				}
+				return "Custom Hostname (only used with Custom)"
+			}, &n.hostnameMode).
+			Prompt("Hostname: ").
+			Value(&n.hostname),
+	}
+}
+
+// OutputOptions adds the completed output fields to the agent options.
+func OutputOptions(form *huh.Form, options map[string]interface{}) {
+	options["segments"] = SplitList(form.GetString("segments"))
+	options["prefix"] = form.GetString("prefix")
+
+	switch mode := form.GetString("hostnameMode"); mode {
+	case "custom":
+		options["hostname"] = form.GetString("hostname")
+	default:
+		options["hostnameMode"] = mode
+	}
+
+	options["template"] = form.GetString("template")
+	options["tagSupport"] = form.GetBool("tagSupport")
+	options["endpoint"] = form.GetString("endpoint")
+	options["endpointAddress"] = form.GetString("endpointAddress")
+	if options["endpoint"] == endpoint.TLS {
+		options["tlsCA"] = form.GetString("tlsCA")
+	}
+}
+
+// SplitList splits a comma separated answer, dropping empty items.
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
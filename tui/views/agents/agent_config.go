@@ -1,12 +1,13 @@
 package agents
 
 import (
-	"github.com/hostedgraphite/hg-cli/agentmanager/otel"
-	"github.com/hostedgraphite/hg-cli/agentmanager/telegraf"
+	"github.com/hostedgraphite/hg-cli/agentmanager"
 
 	"github.com/hostedgraphite/hg-cli/styles"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
+	"github.com/hostedgraphite/hg-cli/tui/forms"
 	"github.com/hostedgraphite/hg-cli/tui/types"
+	"github.com/hostedgraphite/hg-cli/utils"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
@@ -15,9 +16,9 @@ import (
 type AgentConfigView struct {
 	form            *huh.Form
 	agent           string
+	agentViews      agentmanager.FieldViews
 	action          string
 	apiKey          string
-	selectedPlugins []string
 	sysInfo         sysinfo.SysInfo
 	serviceSettings map[string]string
 }
@@ -25,19 +26,22 @@ type AgentConfigView struct {
 func NewAgentConfigView(agent, action string, sysInfo sysinfo.SysInfo) *AgentConfigView {
 	var err error
 	var actionGroup *huh.Group
-	var settings map[string]string
-	switch agent {
-	case "Telegraf":
-		settings = telegraf.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
-	case "Otel", "OpenTelemetry":
-		settings = otel.GetServiceSettings(sysInfo.Os, sysInfo.Arch, sysInfo.PkgMngr)
+	var groups []*huh.Group
+	def, ok := agentmanager.Lookup(agent)
+	agentViews := NewAgentsFields(def.Name, sysInfo)
+	if !ok || agentViews == nil {
+		return nil
 	}
-
-	agentViews := NewAgentsFields(agent)
+	settings := def.ServiceSettings(sysInfo)
 
 	switch action {
 	case "Install":
 		actionGroup, err = agentViews.InstallView()
+		if err == nil {
+			var outputGroup *huh.Group
+			outputGroup, err = agentViews.OutputView()
+			groups = append(groups, outputGroup)
+		}
 	case "Uninstall":
 		actionGroup, err = agentViews.UninstallView()
 	case "Update Api Key":
@@ -50,15 +54,16 @@ func NewAgentConfigView(agent, action string, sysInfo sysinfo.SysInfo) *AgentCon
 		return nil
 	}
 
-	form := huh.NewForm(actionGroup).
+	form := huh.NewForm(append([]*huh.Group{actionGroup}, groups...)...).
 		WithWidth(80).
-		WithTheme(styles.AgentsPageStyle(agent)).
+		WithTheme(styles.AgentsPageStyle(def.DisplayName)).
 		WithHeight(30).
 		WithKeyMap(styles.CustomKeyMap())
 
 	return &AgentConfigView{
 		form:            form,
-		agent:           agent,
+		agent:           def.Name,
+		agentViews:      agentViews,
 		action:          action,
 		sysInfo:         sysInfo,
 		serviceSettings: settings,
@@ -85,6 +90,10 @@ func (a *AgentConfigView) Update(msg tea.Msg) (types.View, tea.Cmd) {
 		case "ctrl+c":
 			return a, tea.Quit
 		case "esc", "q":
+			// Let the plugin search consume esc/q while it's open.
+			if field, ok := a.form.GetFocusedField().(interface{ GetFiltering() bool }); ok && field.GetFiltering() {
+				break
+			}
 			return a, tea.Quit
 		case "enter":
 		}
@@ -103,16 +112,12 @@ func (a *AgentConfigView) Update(msg tea.Msg) (types.View, tea.Cmd) {
 		options := map[string]interface{}{}
 
 		a.apiKey = a.form.GetString("apikey")
+		utils.AddSecret(a.apiKey)
 		options["apikey"] = a.apiKey
 		switch a.action {
 		case "Install":
-			if a.agent == "Telegraf" {
-				plugins := a.form.Get("plugins")
-				if val, ok := plugins.([]string); ok && len(val) > 0 {
-					a.selectedPlugins = val
-				}
-				options["plugins"] = a.selectedPlugins
-			}
+			forms.OutputOptions(a.form, options)
+			a.agentViews.InstallOptions(a.form, options)
 
 		case "Update Api Key":
 			path := a.form.GetString("path")
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/agentmanager/endpoint"
 	"github.com/hostedgraphite/hg-cli/formatters"
 	"github.com/hostedgraphite/hg-cli/pipeline"
 	"github.com/hostedgraphite/hg-cli/styles"
@@ -51,6 +52,7 @@ func (a *AgentRunner) Init() tea.Cmd {
 
 	switch a.action {
 	case "Install":
+		agentmanager.PlatformDefaults(a.options, a.sysInfo)
 		agent := agentmanager.NewAgent(a.agent, a.options, a.sysInfo)
 		updates := make(chan *pipeline.Pipe)
 		installPipeline, err := agent.InstallPipeline(updates)
@@ -144,67 +146,22 @@ func (a *AgentRunner) View() string {
 	var summary formatters.SummaryContent
 	if a.runner != nil {
 		if a.runner.Pipeline.IsCompleted() {
+			data := formatters.ActionSummary{
+				Success: a.runner.Pipeline.Success(),
+				Action:  a.action,
+			}
+
 			switch a.action {
 			case "Install":
-				if a.agent == "Telegraf" {
-					summary = &formatters.TelegrafSummary{
-						ActionSummary: formatters.ActionSummary{
-							Agent:    a.agent,
-							Success:  a.runner.Pipeline.Success(),
-							Action:   a.action,
-							Config:   a.serviceSettings["configPath"],
-							StartCmd: a.serviceSettings["startHint"],
-						},
-						Plugins: a.options["plugins"].([]string),
-					}
-				} else if a.agent == "OpenTelemetry" {
-					summary = &formatters.OtelContribSummary{
-						ActionSummary: formatters.ActionSummary{
-							Agent:    a.agent,
-							Success:  a.runner.Pipeline.Success(),
-							Action:   a.action,
-							Config:   a.serviceSettings["configPath"],
-							StartCmd: a.serviceSettings["startHint"],
-						},
-						Receiver: "hostmetrics",
-						Exporter: "carbon",
-					}
-				}
+				data.Config = a.serviceSettings["configPath"]
+				data.StartCmd = a.serviceSettings["startHint"]
+				data.Endpoint = endpoint.New(a.options).Describe()
 			case "Update Api Key":
-				data := formatters.ActionSummary{
-					Agent:      a.agent,
-					Success:    a.runner.Pipeline.Success(),
-					Action:     a.action,
-					Config:     a.options["config"].(string),
-					RestartCmd: a.serviceSettings["restartHint"],
-				}
-
-				if a.agent == "Telegraf" {
-					summary = &formatters.TelegrafSummary{
-						ActionSummary: data,
-					}
-				} else if a.agent == "OpenTelemetry" {
-					summary = &formatters.OtelContribSummary{
-						ActionSummary: data,
-					}
-				}
-			case "Uninstall":
-				data := formatters.ActionSummary{
-					Agent:   a.agent,
-					Success: a.runner.Pipeline.Success(),
-					Action:  a.action,
-				}
-
-				if a.agent == "Telegraf" {
-					summary = &formatters.TelegrafSummary{
-						ActionSummary: data,
-					}
-				} else if a.agent == "OpenTelemetry" {
-					summary = &formatters.OtelContribSummary{
-						ActionSummary: data,
-					}
-				}
+				data.Config = a.options["config"].(string)
+				data.RestartCmd = a.serviceSettings["restartHint"]
 			}
+
+			summary = agentmanager.NewSummary(a.agent, data, a.options)
 		} else {
 			s := styles.DefaultStyles()
 			content := a.runner.View()
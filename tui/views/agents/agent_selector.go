@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"slices"
 
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/profile"
 	"github.com/hostedgraphite/hg-cli/styles"
 	"github.com/hostedgraphite/hg-cli/sysinfo"
 	"github.com/hostedgraphite/hg-cli/tui/types"
@@ -13,7 +15,6 @@ import (
 	"github.com/charmbracelet/huh"
 )
 
-var agents = []string{"Telegraf", "OpenTelemetry"}
 var commingSoon = []string{""}
 var agentActions = []string{"Install", "Update Api Key", "Uninstall"}
 
@@ -25,7 +26,8 @@ type AgentsView struct {
 }
 
 func NewAgentView(sysInfo sysinfo.SysInfo) *AgentsView {
-	var selectedAgent, selectedAction string
+	var selectedAction string
+	selectedAgent := profile.Current().Agent
 
 	// First form group: Select an agent
 	agentActionGroup := huh.NewGroup(
@@ -37,7 +39,7 @@ func NewAgentView(sysInfo sysinfo.SysInfo) *AgentsView {
 			Key("agent").
 			Title("Select Agent").
 			Description("Select any of the following agents, and we will guide you through their installation").
-			Options(huh.NewOptions(agents...)...).
+			Options(agentOptions()...).
 			Validate(func(agent string) error {
 				if slices.Contains(commingSoon, selectedAgent) {
 					return fmt.Errorf("sorry, this agent is not yet available")
@@ -81,6 +83,18 @@ func NewAgentView(sysInfo sysinfo.SysInfo) *AgentsView {
 	}
 }
 
+// agentOptions lists the registered agents that have TUI fields, labelled by
+// display name with the agent name as the value.
+func agentOptions() []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, def := range agentmanager.Definitions() {
+		if def.Fields != nil {
+			options = append(options, huh.NewOption(def.DisplayName, def.Name))
+		}
+	}
+	return options
+}
+
 func (a *AgentsView) Init() tea.Cmd {
 	if a.form == nil {
 		return nil
@@ -1,9 +1,16 @@
 package agents
 
-import "github.com/charmbracelet/huh"
+import (
+	"github.com/hostedgraphite/hg-cli/agentmanager"
+	"github.com/hostedgraphite/hg-cli/sysinfo"
+)
 
-type AgentsFieldViews interface {
-	InstallView() (*huh.Group, error)
-	UninstallView() (*huh.Group, error)
-	UpdateApiKeyView(defaultPath string) (*huh.Group, error)
+// NewAgentsFields builds the form fields of an agent, nil for agents that
+// aren't offered in the TUI.
+func NewAgentsFields(agent string, sysInfo sysinfo.SysInfo) agentmanager.FieldViews {
+	def, ok := agentmanager.Lookup(agent)
+	if !ok || def.Fields == nil {
+		return nil
+	}
+	return def.Fields(sysInfo)
 }
@@ -3,6 +3,8 @@ package config
 import (
 	_ "embed"
 	"fmt"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,7 +13,25 @@ import (
 var pluginsYaml []byte
 
 type PluginYaml struct {
-	Plugins []string `yaml:"plugins"`
+	Categories []string `yaml:"categories"`
+	Plugins    []Plugin `yaml:"plugins"`
+}
+
+type Plugin struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Category    string      `yaml:"category"`
+	Os          []string    `yaml:"os"`
+	Root        bool        `yaml:"root"`
+	Parameters  []Parameter `yaml:"parameters"`
+}
+
+type Parameter struct {
+	Name         string      `yaml:"name"`
+	Type         string      `yaml:"type"`
+	DefaultValue interface{} `yaml:"default"`
+	Required     bool        `yaml:"required"`
+	Description  string      `yaml:"description"`
 }
 
 func LoadPlugins() (*PluginYaml, error) {
@@ -23,3 +43,97 @@ func LoadPlugins() (*PluginYaml, error) {
 
 	return &options, nil
 }
+
+func (p Plugin) SupportsOs(os string) bool {
+	return slices.Contains(p.Os, os)
+}
+
+// RequiredParameters returns the names of the parameters that have to be set
+// in the generated config before the plugin will report anything.
+func (p Plugin) RequiredParameters() []string {
+	var required []string
+	for _, param := range p.Parameters {
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+	return required
+}
+
+// Label is the text shown in the plugin picker, it includes the category and
+// description so the picker filter can match on either of them.
+func (p Plugin) Label() string {
+	label := fmt.Sprintf("%-12s %-18s %s", p.Category, p.Name, p.Description)
+	if required := p.RequiredParameters(); len(required) > 0 {
+		label += fmt.Sprintf(" (requires: %s)", strings.Join(required, ", "))
+	}
+	if p.Root {
+		label += " [root]"
+	}
+	return label
+}
+
+func (c *PluginYaml) Lookup(name string) (Plugin, bool) {
+	for _, plugin := range c.Plugins {
+		if plugin.Name == name {
+			return plugin, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// ForOs returns the plugins supported on the given os, ordered by category
+// and then by the order they appear in the catalog.
+func (c *PluginYaml) ForOs(os string) []Plugin {
+	var plugins []Plugin
+	for _, category := range c.Categories {
+		for _, plugin := range c.Plugins {
+			if plugin.Category == category && plugin.SupportsOs(os) {
+				plugins = append(plugins, plugin)
+			}
+		}
+	}
+	return plugins
+}
+
+// SupportedOn drops any of the named plugins the catalog knows are not
+// supported on the given os. Plugins missing from the catalog are kept.
+func (c *PluginYaml) SupportedOn(os string, names []string) []string {
+	var supported []string
+	for _, name := range names {
+		if plugin, ok := c.Lookup(name); ok && !plugin.SupportsOs(os) {
+			continue
+		}
+		supported = append(supported, name)
+	}
+	return supported
+}
+
+// ValidateSelection returns an error for any of the named plugins the catalog
+// knows are not supported on the given os, or that have required parameters.
+// The generated config leaves those parameters unset, the plugin would never
+// report anything. Plugins missing from the catalog are passed through to
+// telegraf as-is.
+func (c *PluginYaml) ValidateSelection(os string, names []string) error {
+	var unsupported, incomplete []string
+	for _, name := range names {
+		plugin, ok := c.Lookup(name)
+		if !ok {
+			continue
+		}
+		if !plugin.SupportsOs(os) {
+			unsupported = append(unsupported, name)
+		} else if required := plugin.RequiredParameters(); len(required) > 0 {
+			incomplete = append(incomplete, fmt.Sprintf("%s (%s)", name, strings.Join(required, ", ")))
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("plugins not supported on %s: %s", os, strings.Join(unsupported, ", "))
+	}
+	if len(incomplete) > 0 {
+		return fmt.Errorf("plugins need parameters hg-cli doesn't set, add them to the telegraf config by hand: %s", strings.Join(incomplete, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPluginsCatalog(t *testing.T) {
+	catalog, err := LoadPlugins()
+	require.NoError(t, err)
+	require.NotEmpty(t, catalog.Plugins)
+
+	for _, plugin := range catalog.Plugins {
+		require.NotEmpty(t, plugin.Os, "plugin %s has no os support listed", plugin.Name)
+		require.True(t, slices.Contains(catalog.Categories, plugin.Category), "plugin %s has unknown category %q", plugin.Name, plugin.Category)
+	}
+
+	cpu, ok := catalog.Lookup("cpu")
+	require.True(t, ok)
+	require.Len(t, cpu.Parameters, 5)
+}
+
+func TestPluginsForOs(t *testing.T) {
+	catalog, err := LoadPlugins()
+	require.NoError(t, err)
+
+	var names []string
+	for _, plugin := range catalog.ForOs("darwin") {
+		names = append(names, plugin.Name)
+	}
+	require.Contains(t, names, "cpu")
+	require.NotContains(t, names, "kernel")
+	require.NotContains(t, names, "win_services")
+}
+
+func TestValidateSelection(t *testing.T) {
+	catalog, err := LoadPlugins()
+	require.NoError(t, err)
+
+	require.NoError(t, catalog.ValidateSelection("linux", []string{"cpu", "kernel", "not_in_catalog"}))
+	require.EqualError(t, catalog.ValidateSelection("windows", []string{"cpu", "kernel", "processes"}), "plugins not supported on windows: kernel, processes")
+	require.EqualError(t, catalog.ValidateSelection("linux", []string{"cpu", "procstat", "net_response"}), "plugins need parameters hg-cli doesn't set, add them to the telegraf config by hand: procstat (pattern), net_response (protocol, address)")
+	require.Equal(t, []string{"cpu", "mem"}, catalog.SupportedOn("windows", []string{"cpu", "kernel", "mem"}))
+}
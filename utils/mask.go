@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// AddSecret registers a value, e.g. the api key, that Mask hides from
+// pipeline output, errors and summaries.
+func AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// Mask replaces the registered secrets in s with their masked form.
+func Mask(s string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, MaskKey(secret))
+	}
+	return s
+}
+
+// MaskKey keeps the last 4 characters of a key so it can still be told apart
+// from the others, e.g. ****9f2c.
+func MaskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -3,28 +3,96 @@ package utils
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"regexp"
 	"slices"
 	"strings"
+	"time"
 )
 
-func ValidateAPIKey(apikey string) error {
+// ApiURLEnvVar points key validation at another Hosted Graphite api, e.g.
+// through a proxy.
+const ApiURLEnvVar = "HG_API_URL"
+
+const DefaultApiURL = "https://api.hostedgraphite.com"
+
+// SkipValidation leaves ValidateAPIKey with the format check only, for hosts
+// that can't reach the api. Set by --skip-validate.
+var SkipValidation bool
+
+var keyRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// KeyValidator checks api keys against the Hosted Graphite api.
+type KeyValidator struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewKeyValidator() *KeyValidator {
+	baseURL := os.Getenv(ApiURLEnvVar)
+	if baseURL == "" {
+		baseURL = DefaultApiURL
+	}
+
+	return &KeyValidator{
+		BaseURL: baseURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CheckKeyFormat rejects keys that can't be valid without asking the api,
+// they're all UUIDs. The key is left out of the error.
+func CheckKeyFormat(apikey string) error {
+	if apikey == "" {
+		return fmt.Errorf("api key is empty")
+	}
+	if !keyRegex.MatchString(apikey) {
+		return fmt.Errorf("invalid API key: expected a UUID like 01234567-89ab-cdef-0123-456789abcdef")
+	}
+	return nil
+}
+
+// Validate checks the key's format and then that the api accepts it. An
+// invalid key and an api that can't be reached give different errors.
+func (v *KeyValidator) Validate(apikey string) error {
+	if err := CheckKeyFormat(apikey); err != nil {
+		return err
+	}
+
 	// We just need to know that the api key is valid so a quick query to any metric will work,
 	// even if the metric doesn't exist.
-	url := fmt.Sprintf("https://%s@api.hostedgraphite.com/api/v1/metric/search?pattern=test", apikey)
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(v.BaseURL, "/")+"/api/v1/metric/search?pattern=test", nil)
+	if err != nil {
+		return fmt.Errorf("invalid api url %q: %v", v.BaseURL, err)
+	}
+	req.SetBasicAuth(apikey, "")
 
-	resp, err := http.Get(url)
+	resp, err := v.Client.Do(req)
 	if err != nil {
-		return fmt.Errorf("error making request: %v", err)
+		AddSecret(apikey)
+		return fmt.Errorf("can't reach %s to check the API key, use --skip-validate on hosts without access: %v", v.BaseURL, Mask(err.Error()))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid API key, received status code: %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("invalid API key, Hosted Graphite rejected it (status code %d)", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("error checking the API key, received status code: %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
+// ValidateAPIKey checks the key against the Hosted Graphite api, or only its
+// format with --skip-validate.
+func ValidateAPIKey(apikey string) error {
+	if SkipValidation {
+		return CheckKeyFormat(apikey)
+	}
+	return NewKeyValidator().Validate(apikey)
+}
+
 func AgentRequiresSudo(os, action, pkgmngr, agent string) bool {
 	needSudo := true
 
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "01234567-89ab-cdef-0123-456789abcdef"
+
+func TestKeyValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		if !ok || user != testKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	v := &KeyValidator{BaseURL: server.URL, Client: &http.Client{Timeout: time.Second}}
+
+	require.NoError(t, v.Validate(testKey))
+
+	err := v.Validate("fedcba98-7654-3210-fedc-ba9876543210")
+	require.ErrorContains(t, err, "invalid API key, Hosted Graphite rejected it")
+
+	err = v.Validate("not-a-key")
+	require.ErrorContains(t, err, "expected a UUID")
+	require.NotContains(t, err.Error(), "not-a-key")
+}
+
+func TestKeyValidatorUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	v := &KeyValidator{BaseURL: server.URL, Client: &http.Client{Timeout: time.Second}}
+
+	err := v.Validate(testKey)
+	require.ErrorContains(t, err, "can't reach")
+	require.NotContains(t, err.Error(), testKey)
+}